@@ -0,0 +1,122 @@
+// Command deadletters manages undelivered notifications through the admin API of the service.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: deadletters [flags] <command> [id]
+
+Commands:
+  list           list dead letters, -page and -size select the page
+  show <id>      show notification and its failed attempts
+  replay <id>    push dead letter again and remove it on success, "all" replays all of them until the first failure
+  purge <id>     remove dead letter without replay, "all" removes all of them
+
+Flags:
+`
+
+func main() {
+	var (
+		addr  = flag.String("addr", envOr("DEAD_LETTERS_ADDR", "http://localhost:8080"), "service address, DEAD_LETTERS_ADDR by default")
+		token = flag.String("token", os.Getenv("ADMIN_TOKEN"), "admin token, ADMIN_TOKEN by default")
+		page  = flag.Int("page", 1, "page number of list")
+		size  = flag.Int("size", 100, "page size of list")
+	)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	method, path, err := request(flag.Args(), *page, *size)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err = call(method, strings.TrimRight(*addr, "/")+"/api/v1/admin/dead-letters"+path, *token); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// request returns method and path relative to dead letters of the command.
+func request(args []string, page, size int) (string, string, error) {
+	if len(args) == 0 {
+		return "", "", fmt.Errorf("command is required")
+	}
+	command, args := args[0], args[1:]
+	if command == "list" {
+		if len(args) != 0 {
+			return "", "", fmt.Errorf("list has no arguments")
+		}
+		query := url.Values{"page": {strconv.Itoa(page)}, "size": {strconv.Itoa(size)}}
+		return http.MethodGet, "?" + query.Encode(), nil
+	}
+	if len(args) != 1 || args[0] == "" {
+		return "", "", fmt.Errorf("%s requires id", command)
+	}
+	id := args[0]
+	switch {
+	case command == "show" && id != "all":
+		return http.MethodGet, "/" + url.PathEscape(id), nil
+	case command == "replay" && id == "all":
+		return http.MethodPost, "/replay", nil
+	case command == "replay":
+		return http.MethodPost, "/" + url.PathEscape(id) + "/replay", nil
+	case command == "purge" && id == "all":
+		return http.MethodDelete, "", nil
+	case command == "purge":
+		return http.MethodDelete, "/" + url.PathEscape(id), nil
+	}
+	return "", "", fmt.Errorf("unknown command %q", command)
+}
+
+// call sends request and prints indented response body, status other than 2xx is returned as error.
+func call(method, target, token string) error {
+	req, err := http.NewRequest(method, target, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	var out bytes.Buffer
+	if json.Indent(&out, body, "", "  ") != nil {
+		out.Reset()
+		out.Write(body)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(out.String()))
+	}
+	if out.Len() > 0 {
+		fmt.Println(strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
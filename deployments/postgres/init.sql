@@ -10,3 +10,65 @@ create table if not exists users
     created_at timestamp with time zone,
     updated_at timestamp with time zone
 );
+
+create table if not exists login_attempts
+(
+    key text primary key,
+    failures integer not null default 0,
+    last_failure_at timestamp with time zone not null,
+    locked_until timestamp with time zone
+);
+
+create table if not exists password_history
+(
+    id bigserial primary key,
+    user_id uuid not null references users (id) on delete cascade,
+    password_hash text not null,
+    created_at timestamp with time zone
+);
+
+create index if not exists password_history_user_id_idx on password_history (user_id, created_at desc);
+
+create table if not exists api_keys
+(
+    id uuid primary key default gen_random_uuid(),
+    name text not null,
+    prefix text not null unique,
+    key_hash text not null,
+    scopes text not null,
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone
+);
+
+create table if not exists outbox
+(
+    id bigserial primary key,
+    key text not null,
+    type text not null,
+    payload bytea not null,
+    attempts integer not null default 0,
+    last_error text not null default '',
+    delivered_sinks jsonb not null default '[]',
+    next_attempt_at timestamp with time zone not null,
+    locked_until timestamp with time zone,
+    sent_at timestamp with time zone,
+    created_at timestamp with time zone not null
+);
+
+create index if not exists outbox_pending_idx on outbox (id) where sent_at is null;
+create index if not exists outbox_pending_key_idx on outbox (key, id) where sent_at is null;
+create index if not exists outbox_sent_at_idx on outbox (sent_at) where sent_at is not null;
+
+create table if not exists dead_letters
+(
+    id uuid primary key default gen_random_uuid(),
+    key text not null,
+    type text not null,
+    payload bytea not null,
+    attempts jsonb not null,
+    created_at timestamp with time zone not null
+);
+
+create index if not exists dead_letters_created_at_idx on dead_letters (created_at, id);
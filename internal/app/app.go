@@ -3,25 +3,35 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormLogger "gorm.io/gorm/logger"
 
 	"gorm.io/driver/postgres"
 
 	"test_task/internal/app/server"
 	"test_task/internal/config"
 	httpController "test_task/internal/controller/http"
-	"test_task/internal/datastore/kafka"
+	"test_task/internal/datastore/file"
 	postgresRepo "test_task/internal/datastore/postgres"
+	"test_task/internal/health"
+	"test_task/internal/logger"
+	"test_task/internal/metrics"
 	"test_task/internal/notificator"
+	"test_task/internal/notificator/sink"
+	"test_task/internal/password"
+	"test_task/internal/ratelimit"
+	"test_task/internal/tracing"
 	"test_task/internal/usecase"
 )
 
@@ -30,39 +40,279 @@ func Start(cfg config.Config) {
 	mainCtx, mainCtxCancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer mainCtxCancel()
 
-	l := logrus.New()
+	secrets := append([]string{logger.DSNPassword(cfg.Postgres.URL), cfg.Admin.Token, cfg.Ops.Token}, sinkSecrets(cfg.Notification.Sinks)...)
+	l, logLevel, closeLog, err := logger.New(cfg.Log, secrets...)
+	if err != nil {
+		log.Fatalf("create logger: %s", err.Error())
+	}
+	defer closeLog()
+	shutdownTracing, err := tracing.Setup(mainCtx, cfg.Tracing)
+	if err != nil {
+		l.Fatalf("setup tracing: %s", err.Error())
+		return
+	}
 	pgClient, err := gorm.Open(postgres.Open(cfg.Postgres.URL), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Error)})
+		Logger: gormLogger.New(logger.PrintfFunc(l.WithFields(logger.Fields{"component": "gorm"}).Warnf), gormLogger.Config{
+			SlowThreshold:        200 * time.Millisecond,
+			LogLevel:             gormLogger.Error,
+			ParameterizedQueries: true,
+		})})
 	if err != nil {
 		l.Fatalf("can't connect to the Postgres database: %s", err.Error())
 		return
 	}
+	appMetrics := metrics.New()
+	if err = pgClient.Use(postgresRepo.NewQueryMetrics(appMetrics)); err != nil {
+		l.Fatalf("register query metrics: %s", err.Error())
+		return
+	}
+	if err = pgClient.Use(postgresRepo.NewQueryTracing()); err != nil {
+		l.Fatalf("register query tracing: %s", err.Error())
+		return
+	}
 
 	notificatorCtx, notificatorCtxCancel := context.WithCancel(context.Background())
 	defer notificatorCtxCancel()
-	notitifcationPubSub := notificator.NewPubSub(&kafka.Mock{}, cfg.Notification.BufferSize, l)
+	sinks, err := newSinkRegistry(pgClient, l).Build(cfg.Notification.Sinks)
+	if err != nil {
+		l.Fatalf("create notification sinks: %s", err.Error())
+		return
+	}
+	notificationFanout := sink.NewFanout(sinks, appMetrics.InstrumentNotificator)
+	sinkCtx, sinkCtxCancel := context.WithCancel(context.Background())
+	sinkStopped := make(chan struct{})
+	go func() {
+		if err := notificationFanout.Start(sinkCtx); err != nil {
+			l.Errorf("closing notification sinks: %s", err.Error())
+		}
+		close(sinkStopped)
+	}()
+	// sinks are closed after notificator and relay are stopped
+	defer func() {
+		sinkCtxCancel()
+		<-sinkStopped
+	}()
+	var notificationListener *postgresRepo.Listener
+	if cfg.Notification.Listener.Enabled {
+		notificationListener = postgresRepo.NewListener(cfg.Postgres.URL, cfg.Notification.Listener, l)
+		notificationListener.Handle(notificator.Decode(func(ctx context.Context, n notificator.Notification) {
+			l.WithContext(ctx).WithFields(logger.Fields{
+				logger.FieldOperation:          "notification listen",
+				notificator.FieldOperationType: n.Type,
+				logger.FieldUserID:             n.Key,
+			}).Debug("user change received")
+		}, l))
+		listenerCtx, listenerCtxCancel := context.WithCancel(mainCtx)
+		listenerStopped := make(chan struct{})
+		go func() {
+			notificationListener.Start(listenerCtx)
+			close(listenerStopped)
+		}()
+		defer func() {
+			listenerCtxCancel()
+			<-listenerStopped
+		}()
+	}
+	deadLetterRepo, err := newDeadLetterRepository(cfg.Notification.DeadLetter, pgClient)
+	if err != nil {
+		l.Fatalf("create dead letter store: %s", err.Error())
+		return
+	}
+	backpressure, err := notificator.ParseBackpressure(cfg.Notification.Backpressure.Policy)
+	if err != nil {
+		l.Fatalf("configure notification backpressure: %s", err.Error())
+		return
+	}
+	var spill notificator.SpillQueue
+	if backpressure == notificator.BackpressureSpill {
+		spillQueue, err := file.NewSpillQueue(cfg.Notification.Backpressure.SpillPath, cfg.Notification.Backpressure.MaxSpillSizeMB)
+		if err != nil {
+			l.Fatalf("create notification spill queue: %s", err.Error())
+			return
+		}
+		defer spillQueue.Close()
+		spill = spillQueue
+	}
+	var queue *file.Queue
+	if cfg.Notification.Queue.Enabled {
+		if queue, err = file.NewQueue(cfg.Notification.Queue); err != nil {
+			l.Fatalf("open notification queue: %s", err.Error())
+			return
+		}
+		defer func() {
+			if err := queue.Close(); err != nil {
+				l.Errorf("closing notification queue: %s", err.Error())
+			}
+		}()
+	}
+	pubSubCfg := notificator.PubSubConfig{
+		BufferSize:     cfg.Notification.BufferSize,
+		Workers:        cfg.Notification.Workers,
+		MaxAttempts:    cfg.Notification.Retry.MaxAttempts,
+		Backoff:        cfg.Notification.Retry.Backoff,
+		MaxBackoff:     cfg.Notification.Retry.MaxBackoff,
+		Jitter:         cfg.Notification.Retry.Jitter,
+		AttemptTimeout: cfg.Notification.Retry.AttemptTimeout,
+		Backpressure:   backpressure,
+		BlockTimeout:   cfg.Notification.Backpressure.BlockTimeout,
+		Spill:          spill,
+		Observer:       appMetrics,
+		DrainTimeout:   cfg.Notification.DrainTimeout,
+	}
+	if queue != nil {
+		// queued notifications are read before the ones pushed after start
+		pubSubCfg.Queue = queue
+		if queue.Len() > 0 {
+			l.Info(fmt.Sprintf("%v undelivered notifications are replayed", queue.Len()))
+		}
+	}
+	notitifcationPubSub := notificator.NewPubSub(notificationFanout, deadLetterRepo, pubSubCfg, l)
+	appMetrics.RegisterNotificationQueue(notitifcationPubSub)
+	consumerStopped := make(chan struct{})
 	go func() {
 		notitifcationPubSub.Start(notificatorCtx)
+		close(consumerStopped)
 	}()
+	// spill and queue are closed after the consumer
+	defer func() {
+		notificatorCtxCancel()
+		<-consumerStopped
+	}()
+	var userNotificator usecase.Notificator = notitifcationPubSub
+	relayStopped := make(chan struct{})
+	if cfg.Notification.Outbox.Enabled {
+		outboxRepo := postgresRepo.NewOutboxRepository(pgClient)
+		userNotificator = notificator.NewOutbox(outboxRepo)
+		relay := notificator.NewRelay(outboxRepo, notificationFanout, deadLetterRepo, notificator.RelayConfig{
+			PollInterval:    cfg.Notification.Outbox.PollInterval,
+			BatchSize:       cfg.Notification.Outbox.BatchSize,
+			MaxAttempts:     cfg.Notification.Outbox.MaxAttempts,
+			Lease:           cfg.Notification.Outbox.Lease,
+			RetryBackoff:    cfg.Notification.Outbox.RetryBackoff,
+			MaxRetryBackoff: cfg.Notification.Outbox.MaxRetryBackoff,
+			Retention:       cfg.Notification.Outbox.Retention,
+			CleanupInterval: cfg.Notification.Outbox.CleanupInterval,
+		}, l)
+		go func() {
+			relay.Start(notificatorCtx)
+			close(relayStopped)
+		}()
+	} else {
+		close(relayStopped)
+	}
 
 	userRepo := postgresRepo.NewUserRepository(pgClient)
-	userUseCase := usecase.NewUser(userRepo, notitifcationPubSub, l)
+	var breachedPasswords password.BreachedList
+	if cfg.Password.BreachedListDir != "" {
+		breachedPasswords = password.NewRangeList(cfg.Password.BreachedListDir)
+	}
+	passwordValidator := password.NewValidator(cfg.Password, breachedPasswords)
+	passwordHistoryRepo := postgresRepo.NewPasswordHistoryRepository(pgClient)
+	loginAttemptRepo := postgresRepo.NewLoginAttemptRepository(pgClient)
+	authUseCase := usecase.NewAuth(userRepo, loginAttemptRepo, cfg.Login, l)
+	authController := httpController.NewAuthHandler(authUseCase, l)
+
+	userUseCase := usecase.NewUser(userRepo, postgresRepo.NewTransactor(pgClient), passwordHistoryRepo, passwordValidator, authUseCase,
+		userNotificator, l)
 	userController := httpController.NewUserHandler(userUseCase, l)
 
-	healthRepo := postgresRepo.NewHealth(pgClient)
-	healthController := httpController.NewHealthController([]httpController.HealthChecker{healthRepo})
-	echoServer := server.NewServer(cfg.HTTP)
-	httpController.InitRoutes(echoServer, httpController.Controllers{User: userController, HealthController: healthController})
+	apiKeyUseCase := usecase.NewAPIKey(postgresRepo.NewAPIKeyRepository(pgClient), cfg.APIKeys.LastUsedInterval, l)
+	apiKeyController := httpController.NewAPIKeyHandler(apiKeyUseCase, l)
+
+	var deadLetterController *httpController.DeadLetter
+	if deadLetterRepo != nil {
+		deadLetterController = httpController.NewDeadLetterHandler(notificator.NewDeadLetters(deadLetterRepo, notificationFanout), l)
+	}
+
+	healthChecks := []health.Check{
+		healthCheck(cfg.Health, "postgres", appMetrics.InstrumentHealthChecker("postgres", postgresRepo.NewHealth(pgClient))),
+	}
+	for _, s := range sinks {
+		name := "notification_" + s.Name
+		// "notification" configured check of the only sink before the registry, it's kept for existing configs
+		healthChecks = append(healthChecks, healthCheck(cfg.Health, name, appMetrics.InstrumentHealthChecker(name, s.Sink), "notification"))
+	}
+	if notificationListener != nil {
+		healthChecks = append(healthChecks,
+			healthCheck(cfg.Health, "notification_listener", appMetrics.InstrumentHealthChecker("notification_listener", notificationListener)))
+	}
+	if queue != nil {
+		healthChecks = append(healthChecks,
+			healthCheck(cfg.Health, "notification_queue", appMetrics.InstrumentHealthChecker("notification_queue", queue)))
+	}
+	healthCollector := health.NewCollector(healthChecks, health.CollectorConfig{FailureThreshold: cfg.Health.FailureThreshold, HistorySize: cfg.Health.HistorySize})
+	healthCollector.Start(mainCtx)
+	defer healthCollector.Stop()
+	healthController := httpController.NewHealthController(healthCollector)
+	serverMiddlewares := []echo.MiddlewareFunc{server.NewTracing(), server.NewHTTPMetrics(appMetrics)}
+	if cfg.HTTP.AccessLog.Enabled {
+		serverMiddlewares = append(serverMiddlewares, server.NewAccessLog(l, cfg.HTTP.AccessLog, httpController.PrincipalName))
+	}
+	echoServer := server.NewServer(cfg.HTTP, serverMiddlewares...)
+	if echoServer.IPExtractor, err = server.NewIPExtractor(cfg.HTTP.TrustedProxies); err != nil {
+		l.Fatalf("configure client ip: %s", err.Error())
+		return
+	}
+	if cfg.Metrics.Enabled && !cfg.Ops.Enabled {
+		echoServer.GET(cfg.Metrics.Path, echo.WrapHandler(appMetrics.Handler()))
+	}
+	httpController.InitRoutes(echoServer,
+		httpController.Controllers{
+			User:             userController,
+			Auth:             authController,
+			APIKey:           apiKeyController,
+			HealthController: healthController,
+			DeadLetter:       deadLetterController,
+		},
+		httpController.Middlewares{
+			AdminAuth:  httpController.NewAdminAuth(cfg.Admin.Token),
+			APIKeyAuth: httpController.NewAPIKeyAuth(apiKeyUseCase, cfg.APIKeys.Required, l),
+			RateLimit:  httpController.NewRateLimit(ratelimit.NewMemoryStore(), cfg.HTTP.RateLimits, l),
+		})
+
+	var tlsConfig *tls.Config
+	if cfg.HTTP.TLS.Enabled {
+		certReloader, err := server.NewCertReloader(cfg.HTTP.TLS.CertFile, cfg.HTTP.TLS.KeyFile, l)
+		if err != nil {
+			l.Fatalf("load tls certificate: %s", err.Error())
+			return
+		}
+		if tlsConfig, err = server.NewTLSConfig(cfg.HTTP.TLS, certReloader.GetCertificate); err != nil {
+			l.Fatalf("configure tls: %s", err.Error())
+			return
+		}
+		certReloaderCtx, certReloaderCtxCancel := context.WithCancel(mainCtx)
+		defer certReloaderCtxCancel()
+		go certReloader.Watch(certReloaderCtx, cfg.HTTP.TLS.ReloadInterval)
+	}
 
 	serverStopped := make(chan struct{}, 1)
 	go func() {
 		l.Info("HTTP server is started")
-		if err := echoServer.Start(fmt.Sprintf(":%s", cfg.HTTP.Port)); !errors.Is(err, http.ErrServerClosed) {
+		if err := server.Start(echoServer, cfg.HTTP, tlsConfig); !errors.Is(err, http.ErrServerClosed) {
 			l.Errorf("shutting down http server: %s", err.Error())
 		}
 		serverStopped <- struct{}{}
 	}()
 
+	var opsServer *echo.Echo
+	opsStopped := make(chan struct{}, 1)
+	if cfg.Ops.Enabled {
+		opsServer = server.NewOpsServer(cfg.HTTP)
+		opsControllers := httpController.OpsControllers{Ops: httpController.NewOpsHandler(logLevel, l), Health: httpController.NewDetailedHealthController(healthCollector)}
+		if cfg.Metrics.Enabled {
+			opsControllers.Metrics, opsControllers.MetricsPath = appMetrics.Handler(), cfg.Metrics.Path
+		}
+		httpController.InitOpsRoutes(opsServer, opsControllers, httpController.NewAdminAuth(cfg.Ops.Token))
+		go func() {
+			l.Info("ops HTTP server is started")
+			if err := opsServer.Start(fmt.Sprintf(":%s", cfg.Ops.Port)); !errors.Is(err, http.ErrServerClosed) {
+				l.Errorf("shutting down ops http server: %s", err.Error())
+			}
+			opsStopped <- struct{}{}
+		}()
+	}
+
 	select {
 	case <-mainCtx.Done():
 		l.Info("graceful shutting down…")
@@ -74,7 +324,43 @@ func Start(cfg config.Config) {
 		server.ShutdownServer(l, echoServer, cfg.HTTP)
 		l.Info("http server is stopped")
 		notitifcationPubSub.Stop(notificatorCtxCancel, cfg.Notification.RecheckTimeout, cfg.Notification.CloseTimeout)
+	case <-opsStopped:
+		server.ShutdownServer(l, echoServer, cfg.HTTP)
+		<-serverStopped
+		l.Info("http server is stopped")
+		notitifcationPubSub.Stop(notificatorCtxCancel, cfg.Notification.RecheckTimeout, cfg.Notification.CloseTimeout)
+	}
+	// pending outbox messages are published after restart
+	<-relayStopped
+	// ops server is stopped last to observe shutdown
+	if opsServer != nil {
+		server.ShutdownServer(l, opsServer, cfg.HTTP)
+		l.Info("ops http server is stopped")
 	}
 
+	shutdownCtx, shutdownCtxCancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer shutdownCtxCancel()
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		l.Errorf("shutting down tracing: %s", err.Error())
+	}
 	l.Info("service is stopped")
 }
+
+// healthCheck applies configuration of named check, configuration of fallback name is used if the check has none.
+func healthCheck(cfg config.Health, name string, checker health.Checker, fallback ...string) health.Check {
+	check := health.Check{Name: name, Checker: checker, Timeout: cfg.Timeout, Interval: cfg.Interval}
+	c, ok := cfg.Checks[name]
+	for i := 0; !ok && i < len(fallback); i++ {
+		c, ok = cfg.Checks[fallback[i]]
+	}
+	if ok {
+		check.Critical = c.Critical
+		if c.Timeout > 0 {
+			check.Timeout = c.Timeout
+		}
+		if c.Interval > 0 {
+			check.Interval = c.Interval
+		}
+	}
+	return check
+}
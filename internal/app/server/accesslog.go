@@ -0,0 +1,90 @@
+package server
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+)
+
+// Access log fields.
+const (
+	fieldMethod    = "method"
+	fieldRoute     = "route"
+	fieldPath      = "path"
+	fieldStatus    = "status"
+	fieldLatencyMS = "latency_ms"
+	fieldBytesIn   = "bytes_in"
+	fieldBytesOut  = "bytes_out"
+	fieldClientIP  = "client_ip"
+	fieldPrincipal = "principal"
+)
+
+// PrincipalFunc returns authenticated caller of the request, empty for anonymous.
+type PrincipalFunc func(ctx echo.Context) string
+
+// NewAccessLog logs handled requests.
+// Failed(4xx, 5xx) and slow requests are always logged, successful ones with cfg.SuccessSampleRate probability.
+// Handler error is passed to the error handler here, to log final status.
+func NewAccessLog(l logger.Logger, cfg config.AccessLog, principal PrincipalFunc) echo.MiddlewareFunc {
+	return newAccessLog(l, cfg, principal, rand.Float64)
+}
+
+func newAccessLog(l logger.Logger, cfg config.AccessLog, principal PrincipalFunc, random func() float64) echo.MiddlewareFunc {
+	skip := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, v := range cfg.SkipPaths {
+		skip[v] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+			latency := time.Since(start)
+
+			if _, ok := skip[ctx.Path()]; ok {
+				return nil
+			}
+			status := ctx.Response().Status
+			slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+			if status < http.StatusBadRequest && !slow && random() >= cfg.SuccessSampleRate {
+				return nil
+			}
+
+			req := ctx.Request()
+			fields := logger.Fields{
+				fieldMethod:    req.Method,
+				fieldRoute:     ctx.Path(),
+				fieldPath:      req.URL.Path,
+				fieldStatus:    status,
+				fieldLatencyMS: float64(latency.Microseconds()) / 1000,
+				fieldBytesIn:   max(req.ContentLength, 0),
+				fieldBytesOut:  ctx.Response().Size,
+				fieldClientIP:  ctx.RealIP(),
+			}
+			if principal != nil {
+				if p := principal(ctx); p != "" {
+					fields[fieldPrincipal] = p
+				}
+			}
+			log := l.WithContext(req.Context()).WithFields(fields)
+			switch {
+			case status >= http.StatusInternalServerError:
+				log.Error("http request failed")
+			case slow:
+				log.Warn("slow http request")
+			case status >= http.StatusBadRequest:
+				log.Warn("http request rejected")
+			default:
+				log.Info("http request")
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+	"test_task/internal/requestid"
+)
+
+func TestNewAccessLog(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		random       float64
+		expectStatus int
+		expectLog    func(l *logger.MockLogger)
+	}{
+		{
+			name:         "sampled success",
+			method:       http.MethodPost,
+			path:         "/users/1",
+			body:         `{"a":1}`,
+			random:       0.05,
+			expectStatus: http.StatusOK,
+			expectLog: func(l *logger.MockLogger) {
+				l.EXPECT().WithFields(gomock.Any()).DoAndReturn(func(fields logger.Fields) logger.Logger {
+					assert.Equal(t, http.MethodPost, fields[fieldMethod])
+					assert.Equal(t, "/users/:id", fields[fieldRoute])
+					assert.Equal(t, "/users/1", fields[fieldPath])
+					assert.Equal(t, http.StatusOK, fields[fieldStatus])
+					assert.Equal(t, int64(7), fields[fieldBytesIn])
+					assert.Equal(t, int64(2), fields[fieldBytesOut])
+					assert.Equal(t, "192.0.2.1", fields[fieldClientIP])
+					assert.Equal(t, "api_key:abcd", fields[fieldPrincipal])
+					assert.Contains(t, fields, fieldLatencyMS)
+					return l
+				})
+				l.EXPECT().Info("http request")
+			},
+		},
+		{
+			name:         "not sampled success",
+			method:       http.MethodGet,
+			path:         "/users/1",
+			random:       0.5,
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "skipped path",
+			method:       http.MethodGet,
+			path:         "/ping",
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "server error always logged",
+			method:       http.MethodGet,
+			path:         "/fail",
+			random:       0.99,
+			expectStatus: http.StatusInternalServerError,
+			expectLog: func(l *logger.MockLogger) {
+				l.EXPECT().WithFields(gomock.Any()).DoAndReturn(func(fields logger.Fields) logger.Logger {
+					assert.Equal(t, http.StatusInternalServerError, fields[fieldStatus])
+					assert.NotContains(t, fields, fieldPrincipal)
+					return l
+				})
+				l.EXPECT().Error("http request failed")
+			},
+		},
+		{
+			name:         "client error always logged",
+			method:       http.MethodGet,
+			path:         "/missing",
+			random:       0.99,
+			expectStatus: http.StatusNotFound,
+			expectLog: func(l *logger.MockLogger) {
+				l.EXPECT().WithFields(gomock.Any()).Return(l)
+				l.EXPECT().Warn("http request rejected")
+			},
+		},
+		{
+			name:         "slow request always logged",
+			method:       http.MethodGet,
+			path:         "/slow",
+			random:       0.99,
+			expectStatus: http.StatusOK,
+			expectLog: func(l *logger.MockLogger) {
+				l.EXPECT().WithFields(gomock.Any()).Return(l)
+				l.EXPECT().Warn("slow http request")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockLogger := logger.NewMockLogger(ctrl)
+			if tt.expectLog != nil {
+				mockLogger.EXPECT().WithContext(gomock.Any()).DoAndReturn(func(ctx context.Context) logger.Logger {
+					ao.NotEmpty(requestid.FromContext(ctx))
+					return mockLogger
+				})
+				tt.expectLog(mockLogger)
+			}
+
+			e := echo.New()
+			e.HTTPErrorHandler = NewEchoCustomError().Handler
+			e.Pre(NewRequestID())
+			e.Use(newAccessLog(mockLogger, config.AccessLog{
+				SuccessSampleRate: 0.1,
+				SlowThreshold:     20 * time.Millisecond,
+				SkipPaths:         []string{"/ping"},
+			}, func(ctx echo.Context) string {
+				if ctx.Path() == "/users/:id" {
+					return "api_key:abcd"
+				}
+				return ""
+			}, func() float64 { return tt.random }))
+			ok := func(ctx echo.Context) error { return ctx.String(http.StatusOK, "ok") }
+			e.Any("/users/:id", ok)
+			e.GET("/ping", ok)
+			e.GET("/fail", func(ctx echo.Context) error { return errors.New("boom") })
+			e.GET("/slow", func(ctx echo.Context) error {
+				time.Sleep(30 * time.Millisecond)
+				return ctx.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			ao.Equal(tt.expectStatus, rec.Code)
+			ao.NotEmpty(rec.Header().Get(requestid.Header))
+		})
+	}
+}
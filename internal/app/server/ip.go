@@ -0,0 +1,26 @@
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NewIPExtractor returns extractor of client IP. Without trusted proxies client IP is the peer address and
+// client-supplied headers(X-Forwarded-For, X-Real-IP) are ignored, so clients can't change their IP.
+// Behind proxies client IP is the last address of X-Forwarded-For which isn't in trustedProxies(CIDRs).
+func NewIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, v := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy: %w", err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/config"
+	httpController "test_task/internal/controller/http"
+	"test_task/internal/ratelimit"
+)
+
+func TestNewServer_SpoofedForwardedFor(t *testing.T) {
+	ao := assert.New(t)
+	e := NewServer(config.HTTP{})
+	rl := httpController.NewRateLimit(ratelimit.NewMemoryStore(), map[string]config.RateLimit{"users": {Rate: 0.001, Burst: 1}}, nil)
+	e.GET("/users", func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, ctx.RealIP())
+	}, rl.Group("users"))
+
+	do := func(forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := do("10.0.0.1")
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Equal("203.0.113.7", rec.Body.String())
+	ao.Equal(http.StatusTooManyRequests, do("10.0.0.2").Code)
+}
+
+func TestNewIPExtractor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		expected       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:1234", forwardedFor: "10.0.0.1", expected: "203.0.113.7"},
+		{name: "trusted proxy", trustedProxies: []string{"192.0.2.0/24"}, remoteAddr: "192.0.2.10:1234",
+			forwardedFor: "10.0.0.1, 198.51.100.1", expected: "198.51.100.1"},
+		{name: "proxy chain", trustedProxies: []string{"192.0.2.0/24", "198.51.100.0/24"}, remoteAddr: "192.0.2.10:1234",
+			forwardedFor: "10.0.0.1, 203.0.113.7, 198.51.100.1", expected: "203.0.113.7"},
+		{name: "untrusted peer", trustedProxies: []string{"192.0.2.0/24"}, remoteAddr: "203.0.113.7:1234",
+			forwardedFor: "10.0.0.1", expected: "203.0.113.7"},
+		{name: "private peer isn't trusted", trustedProxies: []string{"192.0.2.0/24"}, remoteAddr: "10.0.0.2:1234",
+			forwardedFor: "10.0.0.1", expected: "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor, err := NewIPExtractor(tt.trustedProxies)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, tt.forwardedFor)
+			assert.Equal(t, tt.expected, extractor(req))
+		})
+	}
+
+	_, err := NewIPExtractor([]string{"192.0.2.1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/config"
+	"test_task/internal/ratelimit"
+)
+
+// NewLoadShedder limits number of in-flight requests with adaptive limit.
+// Excess requests are rejected early with 503, low priority(GET) requests are rejected first.
+func NewLoadShedder(limiter *ratelimit.ConcurrencyLimiter, cfg config.Concurrency) echo.MiddlewareFunc {
+	skip := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, v := range cfg.SkipPaths {
+		skip[v] = struct{}{}
+	}
+	retryAfter := strconv.Itoa(int(math.Max(math.Ceil(cfg.RetryAfter.Seconds()), 1)))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) (err error) {
+			if _, ok := skip[ctx.Path()]; ok {
+				return next(ctx)
+			}
+
+			release, ok := limiter.Acquire(requestPriority(ctx.Request()))
+			if !ok {
+				ctx.Response().Header().Set(echo.HeaderRetryAfter, retryAfter)
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "server is overloaded")
+			}
+
+			start := time.Now()
+			// the slot is released on panic too, it is recovered by outer middleware and counted as overload
+			panicked := true
+			defer func() {
+				release(time.Since(start), panicked || isOverloaded(ctx, err))
+			}()
+			err = next(ctx)
+			panicked = false
+			return err
+		}
+	}
+}
+
+// requestPriority reads(lists, exports) are lower priority than writes.
+func requestPriority(r *http.Request) ratelimit.Priority {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return ratelimit.PriorityLow
+	}
+	return ratelimit.PriorityHigh
+}
+
+func isOverloaded(ctx echo.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Request().Context().Err(), context.DeadlineExceeded) {
+		return true
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code >= http.StatusInternalServerError
+	}
+	return err != nil || ctx.Response().Status >= http.StatusInternalServerError
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+	"test_task/internal/ratelimit"
+)
+
+func TestNewLoadShedder(t *testing.T) {
+	ao := assert.New(t)
+	limiter := ratelimit.NewConcurrencyLimiter(ratelimit.ConcurrencyConfig{
+		InitialLimit:     2,
+		MinLimit:         1,
+		MaxLimit:         2,
+		LatencyThreshold: time.Minute,
+		Backoff:          0.5,
+		LowPriorityShare: 0.5,
+	})
+	e := echo.New()
+	e.HTTPErrorHandler = NewEchoCustomError().Handler
+	e.Use(NewLoadShedder(limiter, config.Concurrency{RetryAfter: 2 * time.Second, SkipPaths: []string{"/ping"}}))
+
+	blocked := make(chan struct{})
+	started := make(chan struct{})
+	handler := func(ctx echo.Context) error {
+		started <- struct{}{}
+		<-blocked
+		return ctx.NoContent(http.StatusOK)
+	}
+	e.GET("/users", handler)
+	e.POST("/users", handler)
+	e.GET("/ping", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	done := make(chan int, 2)
+	go func() { done <- serve(http.MethodGet, "/users").Code }()
+	<-started
+
+	// low priority share(1 slot) is taken.
+	rec := serve(http.MethodGet, "/users")
+	ao.Equal(http.StatusServiceUnavailable, rec.Code)
+	ao.Equal("2", rec.Header().Get(echo.HeaderRetryAfter))
+
+	// write still has a slot.
+	go func() { done <- serve(http.MethodPost, "/users").Code }()
+	<-started
+	ao.Equal(http.StatusServiceUnavailable, serve(http.MethodPost, "/users").Code)
+
+	// skipped path isn't limited.
+	ao.Equal(http.StatusOK, serve(http.MethodGet, "/ping").Code)
+
+	close(blocked)
+	ao.Equal(http.StatusOK, <-done)
+	ao.Equal(http.StatusOK, <-done)
+	ao.Equal(0, limiter.Inflight())
+}
+
+func TestNewLoadShedder_overload(t *testing.T) {
+	ao := assert.New(t)
+	limiter := ratelimit.NewConcurrencyLimiter(ratelimit.ConcurrencyConfig{
+		InitialLimit:     8,
+		MinLimit:         1,
+		MaxLimit:         8,
+		LatencyThreshold: time.Minute,
+		Backoff:          0.5,
+		LowPriorityShare: 1,
+	})
+	e := echo.New()
+	e.Use(NewLoadShedder(limiter, config.Concurrency{}))
+	e.POST("/users", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusInternalServerError)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+	ao.Equal(http.StatusInternalServerError, rec.Code)
+	ao.Equal(4, limiter.Limit())
+}
+
+func TestNewLoadShedder_panic(t *testing.T) {
+	ao := assert.New(t)
+	limiter := ratelimit.NewConcurrencyLimiter(ratelimit.ConcurrencyConfig{
+		InitialLimit:     1,
+		MinLimit:         1,
+		MaxLimit:         1,
+		LatencyThreshold: time.Minute,
+		Backoff:          0.5,
+		LowPriorityShare: 1,
+	})
+	e := echo.New()
+	e.Use(middleware.Recover(), NewLoadShedder(limiter, config.Concurrency{}))
+	e.POST("/users", func(ctx echo.Context) error {
+		panic("handler failed")
+	})
+
+	// slot of panicked request is released, so the next one isn't shed
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+		ao.Equal(http.StatusInternalServerError, rec.Code)
+	}
+	ao.Equal(0, limiter.Inflight())
+}
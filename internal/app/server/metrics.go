@@ -0,0 +1,34 @@
+package server
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const unmatchedRoute = "unmatched"
+
+// HTTPObserver records handled requests.
+type HTTPObserver interface {
+	ObserveHTTPRequest(method, route string, status int, d time.Duration)
+}
+
+// NewHTTPMetrics records count and latency of requests by route template and status.
+// Requests without route are recorded as "unmatched" to keep labels bounded.
+func NewHTTPMetrics(observer HTTPObserver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+			err := next(ctx)
+			if err != nil {
+				ctx.Error(err)
+			}
+			route := ctx.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			observer.ObserveHTTPRequest(ctx.Request().Method, route, ctx.Response().Status, time.Since(start))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type observedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+type fakeHTTPObserver struct {
+	requests []observedRequest
+}
+
+func (f *fakeHTTPObserver) ObserveHTTPRequest(method, route string, status int, _ time.Duration) {
+	f.requests = append(f.requests, observedRequest{method: method, route: route, status: status})
+}
+
+func TestNewHTTPMetrics(t *testing.T) {
+	observer := &fakeHTTPObserver{}
+	e := echo.New()
+	e.HTTPErrorHandler = NewEchoCustomError().Handler
+	e.Use(NewHTTPMetrics(observer))
+	e.GET("/users/:id", func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) })
+	e.POST("/users", func(ctx echo.Context) error { return errors.New("boom") })
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodPost, "/users"},
+		{http.MethodGet, "/unknown/path"},
+	} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	assert.Equal(t, []observedRequest{
+		{method: http.MethodGet, route: "/users/:id", status: http.StatusOK},
+		{method: http.MethodGet, route: "/users/:id", status: http.StatusOK},
+		{method: http.MethodPost, route: "/users", status: http.StatusInternalServerError},
+		{method: http.MethodGet, route: unmatchedRoute, status: http.StatusNotFound},
+	}, observer.requests)
+}
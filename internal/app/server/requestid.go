@@ -0,0 +1,24 @@
+package server
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/requestid"
+)
+
+// NewRequestID accepts X-Request-ID from client or generates new one.
+// Request ID is stored in request context and returned in response header.
+func NewRequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			id := req.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+			ctx.SetRequest(req.WithContext(requestid.NewContext(req.Context(), id)))
+			ctx.Response().Header().Set(requestid.Header, id)
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/requestid"
+)
+
+func TestNewRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expectID string
+	}{
+		{name: "accepted", header: "abc-123", expectID: "abc-123"},
+		{name: "generated", header: ""},
+		{name: "invalid replaced", header: "bad\tid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			e := echo.New()
+			e.Pre(NewRequestID())
+			var fromContext string
+			e.GET("/", func(ctx echo.Context) error {
+				fromContext = requestid.FromContext(ctx.Request().Context())
+				return ctx.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			responseID := rec.Header().Get(requestid.Header)
+			ao.NotEmpty(responseID)
+			ao.Equal(responseID, fromContext)
+			if tt.expectID != "" {
+				ao.Equal(tt.expectID, responseID)
+			} else {
+				ao.NotEqual(tt.header, responseID)
+			}
+		})
+	}
+}
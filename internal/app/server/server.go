@@ -3,26 +3,45 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/net/http2"
 
 	"test_task/internal/config"
 	"test_task/internal/logger"
+	"test_task/internal/ratelimit"
 )
 
 // NewServer creates echo.Echo with configuration.
-func NewServer(cfg config.HTTP) *echo.Echo {
+// Middlewares are applied before built-in ones(recover, load shedding).
+// Client IP is the peer address, set IPExtractor(see NewIPExtractor) behind proxies.
+func NewServer(cfg config.HTTP, middlewares ...echo.MiddlewareFunc) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	e.IPExtractor = echo.ExtractIPDirect()
 	e.Server.ReadTimeout = cfg.ReadTimeout
 	e.Server.WriteTimeout = cfg.WriteTimeout
 	e.Server.IdleTimeout = cfg.IdleTimeout
 	e.HTTPErrorHandler = NewEchoCustomError().Handler
+	e.Pre(NewRequestID())
+	e.Use(middlewares...)
 	e.Use(middleware.Recover())
+	if cfg.Concurrency.Enabled {
+		e.Use(NewLoadShedder(ratelimit.NewConcurrencyLimiter(ratelimit.ConcurrencyConfig{
+			InitialLimit:     cfg.Concurrency.InitialLimit,
+			MinLimit:         cfg.Concurrency.MinLimit,
+			MaxLimit:         cfg.Concurrency.MaxLimit,
+			LatencyThreshold: cfg.Concurrency.LatencyThreshold,
+			Backoff:          cfg.Concurrency.Backoff,
+			LowPriorityShare: cfg.Concurrency.LowPriorityShare,
+		}), cfg.Concurrency))
+	}
 	e.GET("/ping", func(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusOK)
 	})
@@ -30,6 +49,37 @@ func NewServer(cfg config.HTTP) *echo.Echo {
 	return e
 }
 
+// Start serves e on cfg.Port. With tlsConfig it serves HTTPS with HTTP/2,
+// otherwise plaintext HTTP/1.1 and HTTP/2 without TLS(h2c) if enabled.
+func Start(e *echo.Echo, cfg config.HTTP, tlsConfig *tls.Config) error {
+	address := fmt.Sprintf(":%s", cfg.Port)
+	switch {
+	case tlsConfig != nil:
+		e.Server.Addr = address
+		e.Server.TLSConfig = tlsConfig
+		return e.StartServer(e.Server)
+	case cfg.H2C:
+		return e.StartH2CServer(address, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+	}
+	return e.Start(address)
+}
+
+// NewOpsServer creates echo.Echo for the admin listener.
+// Write timeout is not set, since profiles are streamed for the requested duration.
+func NewOpsServer(cfg config.HTTP) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Server.ReadTimeout = cfg.ReadTimeout
+	e.Server.IdleTimeout = cfg.IdleTimeout
+	e.HTTPErrorHandler = NewEchoCustomError().Handler
+	e.Pre(NewRequestID())
+	e.Use(middleware.Recover())
+
+	return e
+}
+
 // ShutdownServer stops echo server.
 func ShutdownServer(l logger.Logger, e *echo.Echo, cfg config.HTTP) {
 	l.Info("stopping http server")
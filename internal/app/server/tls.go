@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+)
+
+// DefaultCertReloadInterval is used when reload interval is not configured.
+const DefaultCertReloadInterval = 10 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// NewTLSConfig creates TLS configuration with HTTP/2 support, certificate is taken from getCertificate on every handshake.
+func NewTLSConfig(cfg config.TLS, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if cfg.MinVersion != "" {
+		v, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls version %q", cfg.MinVersion)
+		}
+		tlsConfig.MinVersion = v
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		suites := make(map[string]uint16)
+		for _, s := range tls.CipherSuites() {
+			suites[s.Name] = s.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client ca: %w", err)
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client ca: no certificates found")
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if cfg.ClientAuth != "" {
+		clientAuth, ok := clientAuthTypes[cfg.ClientAuth]
+		if !ok {
+			return nil, fmt.Errorf("unknown client auth %q", cfg.ClientAuth)
+		}
+		tlsConfig.ClientAuth = clientAuth
+	}
+	return tlsConfig, nil
+}
+
+// CertReloader serves certificate loaded from files and reloads it when the files change.
+// Files are polled, since mounted secrets are usually replaced by symlink swap.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	l        logger.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version fileVersion
+}
+
+// fileVersion identifies content of certificate and key files.
+type fileVersion struct {
+	certModTime time.Time
+	certSize    int64
+	keyModTime  time.Time
+	keySize     int64
+}
+
+// NewCertReloader loads certificate and creates new instance of CertReloader.
+func NewCertReloader(certFile, keyFile string, l logger.Logger) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, l: l}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns current certificate, it is used as tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads certificate if the files have changed since the last load.
+// Previous certificate is kept on error.
+func (r *CertReloader) Reload() (reloaded bool, err error) {
+	version, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	unchanged := r.cert != nil && version == r.version
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load tls certificate: %w", err)
+	}
+	r.mu.Lock()
+	r.cert, r.version = &cert, version
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch reloads certificate every interval until ctx is done.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCertReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := r.Reload()
+		if err != nil {
+			r.l.WithFields(logger.Fields{"cert_file": r.certFile}).Errorf("reload tls certificate: %s", err.Error())
+			continue
+		}
+		if reloaded {
+			r.l.WithFields(logger.Fields{"cert_file": r.certFile}).Info("tls certificate reloaded")
+		}
+	}
+}
+
+func (r *CertReloader) stat() (fileVersion, error) {
+	cert, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("stat tls certificate: %w", err)
+	}
+	key, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("stat tls key: %w", err)
+	}
+	return fileVersion{certModTime: cert.ModTime(), certSize: cert.Size(), keyModTime: key.ModTime(), keySize: key.Size()}, nil
+}
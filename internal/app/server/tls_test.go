@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+)
+
+// writeCert writes self-signed certificate for localhost and returns its pool.
+func writeCert(t *testing.T, certFile, keyFile string, serial int64) *x509.CertPool {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	writeCert(t, caFile, filepath.Join(dir, "ca.key"), 1)
+
+	tests := []struct {
+		name       string
+		cfg        config.TLS
+		expectErr  bool
+		minVersion uint16
+		clientAuth tls.ClientAuthType
+		suites     []uint16
+	}{
+		{name: "defaults", minVersion: tls.VersionTLS12},
+		{
+			name:       "min version and cipher suites",
+			cfg:        config.TLS{MinVersion: "1.3", CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}},
+			minVersion: tls.VersionTLS13,
+			suites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		},
+		{name: "mtls", cfg: config.TLS{ClientCAFile: caFile}, minVersion: tls.VersionTLS12, clientAuth: tls.RequireAndVerifyClientCert},
+		{
+			name:       "optional client certificate",
+			cfg:        config.TLS{ClientCAFile: caFile, ClientAuth: "verify_if_given"},
+			minVersion: tls.VersionTLS12,
+			clientAuth: tls.VerifyClientCertIfGiven,
+		},
+		{name: "unknown version", cfg: config.TLS{MinVersion: "2.0"}, expectErr: true},
+		{name: "insecure cipher suite", cfg: config.TLS{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}, expectErr: true},
+		{name: "unknown client auth", cfg: config.TLS{ClientAuth: "always"}, expectErr: true},
+		{name: "missing client ca", cfg: config.TLS{ClientCAFile: filepath.Join(dir, "missing.crt")}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			tlsConfig, err := NewTLSConfig(tt.cfg, nil)
+			if tt.expectErr {
+				ao.Error(err)
+				return
+			}
+			require.NoError(t, err)
+			ao.Equal(tt.minVersion, tlsConfig.MinVersion)
+			ao.Equal(tt.clientAuth, tlsConfig.ClientAuth)
+			ao.Equal(tt.suites, tlsConfig.CipherSuites)
+			ao.Equal([]string{"h2", "http/1.1"}, tlsConfig.NextProtos)
+		})
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	ao := assert.New(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(t, certFile, keyFile, 1)
+
+	r, err := NewCertReloader(certFile, keyFile, nil)
+	require.NoError(t, err)
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	ao.Equal(int64(1), cert.Leaf.SerialNumber.Int64())
+
+	reloaded, err := r.Reload()
+	ao.NoError(err)
+	ao.False(reloaded)
+
+	writeCert(t, certFile, keyFile, 2)
+	// mod time resolution of some file systems is coarse
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	reloaded, err = r.Reload()
+	ao.NoError(err)
+	ao.True(reloaded)
+	cert, _ = r.GetCertificate(nil)
+	ao.Equal(int64(2), cert.Leaf.SerialNumber.Int64())
+
+	// broken certificate keeps the previous one
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0o600))
+	_, err = r.Reload()
+	ao.Error(err)
+	cert, _ = r.GetCertificate(nil)
+	ao.Equal(int64(2), cert.Leaf.SerialNumber.Int64())
+
+	_, err = NewCertReloader(filepath.Join(dir, "missing.crt"), keyFile, nil)
+	ao.Error(err)
+}
+
+func TestCertReloader_Watch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(t, certFile, keyFile, 1)
+
+	reloaded := make(chan struct{})
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(logger.Fields{"cert_file": certFile}).Return(mockLogger)
+	mockLogger.EXPECT().Info("tls certificate reloaded").Do(func(...interface{}) { close(reloaded) })
+
+	r, err := NewCertReloader(certFile, keyFile, mockLogger)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, time.Millisecond)
+
+	writeCert(t, certFile, keyFile, 2)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("certificate is not reloaded")
+	}
+	cert, _ := r.GetCertificate(nil)
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+}
+
+func TestStart(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	pool := writeCert(t, certFile, keyFile, 1)
+	reloader, err := NewCertReloader(certFile, keyFile, nil)
+	require.NoError(t, err)
+	tlsConfig, err := NewTLSConfig(config.TLS{}, reloader.GetCertificate)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		cfg           config.HTTP
+		tlsConfig     *tls.Config
+		client        *http.Client
+		scheme        string
+		expectedProto int
+	}{
+		{
+			name:          "https with http2",
+			cfg:           config.HTTP{Port: "0"},
+			tlsConfig:     tlsConfig,
+			client:        &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}, ForceAttemptHTTP2: true}},
+			scheme:        "https",
+			expectedProto: 2,
+		},
+		{
+			name: "h2c",
+			cfg:  config.HTTP{Port: "0", H2C: true},
+			client: &http.Client{Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, network, addr)
+				},
+			}},
+			scheme:        "http",
+			expectedProto: 2,
+		},
+		{
+			name:          "plaintext http1",
+			cfg:           config.HTTP{Port: "0"},
+			client:        &http.Client{},
+			scheme:        "http",
+			expectedProto: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewServer(tt.cfg)
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- Start(e, tt.cfg, tt.tlsConfig)
+			}()
+			defer func() {
+				require.NoError(t, e.Shutdown(context.Background()))
+				assert.ErrorIs(t, <-stopped, http.ErrServerClosed)
+			}()
+
+			var addr net.Addr
+			require.Eventually(t, func() bool {
+				addr = e.ListenerAddr()
+				if tt.tlsConfig != nil {
+					addr = e.TLSListenerAddr()
+				}
+				return addr != nil
+			}, time.Second, time.Millisecond)
+
+			_, port, err := net.SplitHostPort(addr.String())
+			require.NoError(t, err)
+			resp, err := tt.client.Get(tt.scheme + "://localhost:" + port + "/ping")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.expectedProto, resp.ProtoMajor)
+		})
+	}
+}
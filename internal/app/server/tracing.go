@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"test_task/internal/requestid"
+	"test_task/internal/tracing"
+)
+
+const tracerName = "test_task/internal/app/server"
+
+// NewTracing starts server span for every request, continuing incoming W3C trace context.
+// Span is named by route template once request is routed.
+func NewTracing() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			spanCtx := tracing.Propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			spanCtx, span := tracing.Tracer(tracerName).Start(spanCtx, req.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.URLPath(req.URL.Path),
+					semconv.ClientAddress(ctx.RealIP()),
+					attribute.String(requestid.MetadataKey, requestid.FromContext(req.Context())),
+				),
+			)
+			defer span.End()
+			ctx.SetRequest(req.WithContext(spanCtx))
+
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			status := ctx.Response().Status
+			if route := ctx.Path(); route != "" {
+				span.SetName(req.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return nil
+		}
+	}
+}
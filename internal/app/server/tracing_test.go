@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestNewTracing(t *testing.T) {
+	ao := assert.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	e := echo.New()
+	e.HTTPErrorHandler = NewEchoCustomError().Handler
+	e.Pre(NewRequestID())
+	e.Use(NewTracing())
+	var handlerSpan trace.SpanContext
+	e.GET("/users/:id", func(ctx echo.Context) error {
+		handlerSpan = trace.SpanContextFromContext(ctx.Request().Context())
+		return echo.NewHTTPError(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	req.Header.Set("X-Request-ID", "abc")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	ao.Equal(http.StatusServiceUnavailable, rec.Code)
+
+	spans := recorder.Ended()
+	ao.Len(spans, 1)
+	span := spans[0]
+	ao.Equal("GET /users/:id", span.Name())
+	ao.Equal(trace.SpanKindServer, span.SpanKind())
+	ao.Equal("0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+	ao.Equal("b7ad6b7169203331", span.Parent().SpanID().String())
+	ao.Equal(span.SpanContext(), handlerSpan)
+	ao.Equal(codes.Error, span.Status().Code)
+	ao.Contains(span.Attributes(), attribute.String("http.route", "/users/:id"))
+	ao.Contains(span.Attributes(), attribute.Int("http.response.status_code", http.StatusServiceUnavailable))
+	ao.Contains(span.Attributes(), attribute.String("request_id", "abc"))
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"test_task/internal/config"
+	"test_task/internal/datastore/file"
+	"test_task/internal/datastore/kafka"
+	postgresRepo "test_task/internal/datastore/postgres"
+	"test_task/internal/datastore/rabbitmq"
+	"test_task/internal/datastore/webhook"
+	"test_task/internal/logger"
+	"test_task/internal/notificator"
+	"test_task/internal/notificator/sink"
+)
+
+// newSinkRegistry registers factories of supported notification sink types.
+func newSinkRegistry(pgClient *gorm.DB, l logger.Logger) *sink.Registry {
+	r := sink.NewRegistry()
+	r.Register("stdout", func(_ config.Sink) (sink.Sink, error) {
+		return file.NewWriter(os.Stdout), nil
+	})
+	r.Register("file", func(cfg config.Sink) (sink.Sink, error) {
+		f, err := file.NewRotatingFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	})
+	r.Register("http", func(cfg config.Sink) (sink.Sink, error) {
+		c, err := webhook.NewClient(cfg.HTTP)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	r.Register("kafka", func(cfg config.Sink) (sink.Sink, error) {
+		p, err := kafka.NewProducer(cfg.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+	r.Register("rabbitmq", func(cfg config.Sink) (sink.Sink, error) {
+		p, err := rabbitmq.NewPublisher(cfg.RabbitMQ, l)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+	r.Register("postgres", func(cfg config.Sink) (sink.Sink, error) {
+		return postgresRepo.NewNotifier(pgClient, cfg.Postgres.Channel), nil
+	})
+	return r
+}
+
+// sinkSecrets returns credentials of sinks to mask in logs, only settings of the sink type are used.
+func sinkSecrets(sinks []config.Sink) []string {
+	var secrets []string
+	for _, s := range sinks {
+		switch s.Type {
+		case "kafka":
+			secrets = append(secrets, s.Kafka.SASL.Password)
+		case "rabbitmq":
+			secrets = append(secrets, logger.DSNPassword(s.RabbitMQ.URL))
+		case "http":
+			secrets = append(secrets, logger.DSNPassword(s.HTTP.URL))
+			secrets = append(secrets, headerSecrets(s.HTTP.Headers)...)
+		}
+	}
+	return secrets
+}
+
+// headerSecrets returns values of webhook headers which carry credentials.
+func headerSecrets(headers map[string]string) []string {
+	var secrets []string
+	for name, value := range headers {
+		name = strings.ToLower(name)
+		for _, secret := range []string{"authorization", "token", "secret", "key", "password"} {
+			if strings.Contains(name, secret) {
+				secrets = append(secrets, value)
+				break
+			}
+		}
+	}
+	return secrets
+}
+
+// newDeadLetterRepository creates configured dead letter store, it returns nil if dead letters are disabled.
+func newDeadLetterRepository(cfg config.DeadLetter, pgClient *gorm.DB) (notificator.DeadLetterRepository, error) {
+	switch cfg.Store {
+	case "":
+		return nil, nil
+	case "postgres":
+		return postgresRepo.NewDeadLetterRepository(pgClient), nil
+	case "file":
+		s, err := file.NewDeadLetterStore(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown dead letter store %q", cfg.Store)
+	}
+}
@@ -6,9 +6,81 @@ import (
 )
 
 type Config struct {
-	HTTP         HTTP         `yaml:"http"`
-	Postgres     Postgres     `yaml:"postgres"`
-	Notification Notification `yaml:"notification"`
+	Log          Log            `yaml:"log"`
+	Metrics      Metrics        `yaml:"metrics"`
+	Tracing      Tracing        `yaml:"tracing"`
+	Health       Health         `yaml:"health"`
+	Ops          Ops            `yaml:"ops"`
+	HTTP         HTTP           `yaml:"http"`
+	Postgres     Postgres       `yaml:"postgres"`
+	Notification Notification   `yaml:"notification"`
+	Login        Login          `yaml:"login"`
+	Password     PasswordPolicy `yaml:"password"`
+	Admin        Admin          `yaml:"admin"`
+	APIKeys      APIKeys        `yaml:"apiKeys"`
+}
+
+// Log configures application logger.
+// Backend is logrus(default) or slog, Format is text(default) or json, Output is stdout(default), stderr or file path.
+type Log struct {
+	Backend string `yaml:"backend"`
+	Level   string `yaml:"level"`
+	Format  string `yaml:"format"`
+	Output  string `yaml:"output"`
+	// Redaction extends built-in masking of secrets and PII.
+	Redaction Redaction `yaml:"redaction"`
+}
+
+// Redaction configures masking of log fields by name(part of name, case-insensitive) and of message parts by regexp.
+// If pattern has group named "value", only the group is masked.
+type Redaction struct {
+	Fields   []string `yaml:"fields"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// Metrics configures Prometheus metrics endpoint.
+type Metrics struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+// Tracing configures OpenTelemetry spans export over OTLP/HTTP.
+// Endpoint is collector host:port, SampleRatio(0..1) is applied to traces started here,
+// incoming sampled traces are always continued.
+type Tracing struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"`
+	Insecure    bool              `yaml:"insecure"`
+	Headers     map[string]string `yaml:"headers"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	ServiceName string            `yaml:"serviceName"`
+	SampleRatio float64           `yaml:"sampleRatio"`
+}
+
+// Health configures readiness checks collected in background.
+// Timeout and Interval are applied to checks without own values,
+// component is marked down after FailureThreshold consecutive failures, last HistorySize results are kept.
+type Health struct {
+	Timeout          time.Duration          `yaml:"timeout"`
+	Interval         time.Duration          `yaml:"interval"`
+	FailureThreshold int                    `yaml:"failureThreshold"`
+	HistorySize      int                    `yaml:"historySize"`
+	Checks           map[string]HealthCheck `yaml:"checks"`
+}
+
+// HealthCheck configures named check, failed critical check makes the service not ready, other checks degrade it.
+type HealthCheck struct {
+	Critical bool          `yaml:"critical"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Ops configures admin listener with health, metrics, pprof and runtime controls.
+// Every route except probes requires "Authorization: Bearer <Token>", empty Token rejects them.
+type Ops struct {
+	Enabled bool   `yaml:"enabled"`
+	Port    string `yaml:"port"`
+	Token   string `yaml:"token"`
 }
 
 type HTTP struct {
@@ -17,6 +89,62 @@ type HTTP struct {
 	WriteTimeout    time.Duration `yaml:"writeTimeout"`
 	IdleTimeout     time.Duration `yaml:"idleTimeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// RateLimits token bucket limits per route group name(users, login, admin). Group without limit is not limited.
+	RateLimits  map[string]RateLimit `yaml:"rateLimits"`
+	Concurrency Concurrency          `yaml:"concurrency"`
+	AccessLog   AccessLog            `yaml:"accessLog"`
+	TLS         TLS                  `yaml:"tls"`
+	// H2C enables HTTP/2 without TLS for internal traffic, it is ignored when TLS is enabled.
+	H2C bool `yaml:"h2c"`
+	// TrustedProxies are CIDRs of proxies whose X-Forwarded-For is trusted. Without them client IP is the peer address.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// TLS configures HTTPS with HTTP/2. Certificate is reloaded when CertFile or KeyFile changes, files are checked every ReloadInterval.
+// MinVersion is 1.0-1.3(1.2 by default), CipherSuites are Go names of TLS 1.0-1.2 suites(TLS 1.3 suites are not configurable).
+// ClientCAFile enables client certificate verification(mTLS), ClientAuth is request, require, verify_if_given or
+// require_and_verify(default when ClientCAFile is set).
+type TLS struct {
+	Enabled        bool          `yaml:"enabled"`
+	CertFile       string        `yaml:"certFile"`
+	KeyFile        string        `yaml:"keyFile"`
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+	MinVersion     string        `yaml:"minVersion"`
+	CipherSuites   []string      `yaml:"cipherSuites"`
+	ClientCAFile   string        `yaml:"clientCAFile"`
+	ClientAuth     string        `yaml:"clientAuth"`
+}
+
+// AccessLog configures HTTP access log.
+// Failed(4xx, 5xx) requests and requests slower than SlowThreshold are always logged,
+// successful ones with SuccessSampleRate(0..1) probability. SkipPaths(route templates) aren't logged.
+type AccessLog struct {
+	Enabled           bool          `yaml:"enabled"`
+	SuccessSampleRate float64       `yaml:"successSampleRate"`
+	SlowThreshold     time.Duration `yaml:"slowThreshold"`
+	SkipPaths         []string      `yaml:"skipPaths"`
+}
+
+// Concurrency configures server-wide adaptive concurrency limit.
+// Limit starts from InitialLimit, grows while requests are faster than LatencyThreshold, multiplied by Backoff otherwise.
+// GET requests(lists, exports) may use only LowPriorityShare of the limit. SkipPaths(route templates) aren't limited.
+// Zero or out of range values are replaced with defaults, InitialLimit is kept within MinLimit-MaxLimit.
+type Concurrency struct {
+	Enabled          bool          `yaml:"enabled"`
+	InitialLimit     int           `yaml:"initialLimit"`
+	MinLimit         int           `yaml:"minLimit"`
+	MaxLimit         int           `yaml:"maxLimit"`
+	LatencyThreshold time.Duration `yaml:"latencyThreshold"`
+	Backoff          float64       `yaml:"backoff"`
+	LowPriorityShare float64       `yaml:"lowPriorityShare"`
+	RetryAfter       time.Duration `yaml:"retryAfter"`
+	SkipPaths        []string      `yaml:"skipPaths"`
+}
+
+// RateLimit allows Burst requests at once and Rate requests per second on average.
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
 }
 
 type Postgres struct {
@@ -24,7 +152,203 @@ type Postgres struct {
 }
 
 type Notification struct {
-	BufferSize     int           `yaml:"bufferSize"`
-	RecheckTimeout time.Duration `yaml:"recheckTimeout"`
-	CloseTimeout   time.Duration `yaml:"closeTimeout"`
+	BufferSize     int               `yaml:"bufferSize"`
+	Workers        int               `yaml:"workers"`
+	Backpressure   Backpressure      `yaml:"backpressure"`
+	Queue          NotificationQueue `yaml:"queue"`
+	RecheckTimeout time.Duration     `yaml:"recheckTimeout"`
+	CloseTimeout   time.Duration     `yaml:"closeTimeout"`
+	// DrainTimeout limits push of notifications left in the buffer and partitions after CloseTimeout.
+	DrainTimeout time.Duration        `yaml:"drainTimeout"`
+	Outbox       Outbox               `yaml:"outbox"`
+	Retry        Retry                `yaml:"retry"`
+	DeadLetter   DeadLetter           `yaml:"deadLetter"`
+	Sinks        []Sink               `yaml:"sinks"`
+	Listener     NotificationListener `yaml:"listener"`
+}
+
+// Backpressure configures push to the full notification buffer. Policy is one of block(wait for room until the request
+// context is done or BlockTimeout passes, zero timeout waits for the context only), fail(reject at once), drop_newest(discard
+// pushed notification), drop_oldest(discard the oldest buffered one) and spill(append to SpillPath file up to MaxSpillSizeMB,
+// spilled notifications are pushed in order once the buffer is drained). Empty policy is block.
+// Notification is pushed after the user change is committed, so the change succeeds anyway: rejected notification is logged
+// and lost, dropped one is lost. Policy isn't applied with outbox, since notifications aren't buffered then.
+type Backpressure struct {
+	Policy         string        `yaml:"policy"`
+	BlockTimeout   time.Duration `yaml:"blockTimeout"`
+	SpillPath      string        `yaml:"spillPath"`
+	MaxSpillSizeMB int           `yaml:"maxSpillSizeMB"`
+}
+
+// NotificationQueue configures durable queue which replaces in-memory buffer. Notifications are appended to segment
+// files of SegmentSizeMB in Path directory up to MaxSizeMB, offset of pushed ones is checkpointed and segments below
+// the checkpoint are removed. Fsync is one of always(every append and checkpoint), interval(every FsyncInterval)
+// and never(left to OS), interval is default. Notifications after the checkpoint are pushed again after restart.
+type NotificationQueue struct {
+	Enabled       bool          `yaml:"enabled"`
+	Path          string        `yaml:"path"`
+	SegmentSizeMB int           `yaml:"segmentSizeMB"`
+	MaxSizeMB     int           `yaml:"maxSizeMB"`
+	Fsync         string        `yaml:"fsync"`
+	FsyncInterval time.Duration `yaml:"fsyncInterval"`
+}
+
+// Retry configures delivery of buffered notifications. Push is attempted up to MaxAttempts times, every attempt is limited
+// by AttemptTimeout. Delay after the first failure is Backoff, it doubles up to MaxBackoff and is randomized by Jitter share,
+// e.g. 0.2 gives delay in [0.8, 1.2] of the computed one.
+type Retry struct {
+	MaxAttempts    int           `yaml:"maxAttempts"`
+	Backoff        time.Duration `yaml:"backoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`
+	Jitter         float64       `yaml:"jitter"`
+	AttemptTimeout time.Duration `yaml:"attemptTimeout"`
+}
+
+// DeadLetter configures store of notifications which weren't delivered after all attempts.
+// Store is one of postgres(dead_letters table), file(one JSON file per notification in Path directory),
+// empty store disables dead letters, so undelivered notifications are only logged.
+type DeadLetter struct {
+	Store string `yaml:"store"`
+	Path  string `yaml:"path"`
+}
+
+// Outbox configures transactional outbox. When enabled, notification is written in the transaction of the change
+// and relay publishes it, otherwise notifications go through in-memory buffer and are lost on crash.
+// Relay polls every PollInterval, claims up to BatchSize messages for Lease, retries failures with backoff from RetryBackoff
+// doubling up to MaxRetryBackoff, moves message to dead letters after MaxAttempts failures,
+// and removes messages sent Retention ago every CleanupInterval.
+type Outbox struct {
+	Enabled         bool          `yaml:"enabled"`
+	PollInterval    time.Duration `yaml:"pollInterval"`
+	BatchSize       int           `yaml:"batchSize"`
+	MaxAttempts     int           `yaml:"maxAttempts"`
+	Lease           time.Duration `yaml:"lease"`
+	RetryBackoff    time.Duration `yaml:"retryBackoff"`
+	MaxRetryBackoff time.Duration `yaml:"maxRetryBackoff"`
+	Retention       time.Duration `yaml:"retention"`
+	CleanupInterval time.Duration `yaml:"cleanupInterval"`
+}
+
+// Sink configures named notification sink, every notification is pushed to all sinks.
+// Type is one of stdout, file, http, kafka, rabbitmq, postgres, only options of that type are used. Name defaults to Type.
+type Sink struct {
+	Name     string         `yaml:"name"`
+	Type     string         `yaml:"type"`
+	File     FileSink       `yaml:"file"`
+	HTTP     HTTPSink       `yaml:"http"`
+	Kafka    Kafka          `yaml:"kafka"`
+	RabbitMQ RabbitMQ       `yaml:"rabbitmq"`
+	Postgres PostgresNotify `yaml:"postgres"`
+}
+
+// FileSink configures NDJSON file, it is rotated when it exceeds MaxSizeMB, MaxBackups rotated files are kept.
+type FileSink struct {
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"maxSizeMB"`
+	MaxBackups int    `yaml:"maxBackups"`
+}
+
+// HTTPSink configures webhook, notification is sent as POST body to URL with Headers, any status except 2xx is failure.
+// HealthURL is requested with GET by healthcheck, without it webhook is always healthy.
+type HTTPSink struct {
+	URL       string            `yaml:"url"`
+	Headers   map[string]string `yaml:"headers"`
+	Timeout   time.Duration     `yaml:"timeout"`
+	HealthURL string            `yaml:"healthURL"`
+}
+
+// Kafka configures notification producer. Notifications are keyed by user ID, so notifications of one user
+// go to one partition in order. Acks is one of all, leader, none. Compression is one of none, gzip, snappy, lz4, zstd.
+// Idempotent producer requires acks all. Timeout limits delivery of one notification including retries.
+type Kafka struct {
+	Brokers     []string      `yaml:"brokers"`
+	Topic       string        `yaml:"topic"`
+	ClientID    string        `yaml:"clientID"`
+	Acks        string        `yaml:"acks"`
+	Compression string        `yaml:"compression"`
+	Idempotent  bool          `yaml:"idempotent"`
+	Timeout     time.Duration `yaml:"timeout"`
+	TLS         KafkaTLS      `yaml:"tls"`
+	SASL        KafkaSASL     `yaml:"sasl"`
+}
+
+// KafkaTLS configures TLS connection to brokers. CAFile replaces system roots, CertFile and KeyFile enable client certificate.
+type KafkaTLS struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"caFile"`
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+// KafkaSASL configures broker authentication. Mechanism is one of plain, scram-sha-256, scram-sha-512, empty disables SASL.
+type KafkaSASL struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+// RabbitMQ configures AMQP notification publisher. Durable Exchange of ExchangeType(topic by default) is declared on connect,
+// notifications are routed by <RoutingKeyPrefix>.<operation type>, e.g. user.insert. Push waits for publisher confirm up to Timeout.
+// Lost connection is restored with backoff from ReconnectBackoff doubling up to MaxReconnectBackoff.
+type RabbitMQ struct {
+	URL                 string        `yaml:"url"`
+	Exchange            string        `yaml:"exchange"`
+	ExchangeType        string        `yaml:"exchangeType"`
+	RoutingKeyPrefix    string        `yaml:"routingKeyPrefix"`
+	Timeout             time.Duration `yaml:"timeout"`
+	ReconnectBackoff    time.Duration `yaml:"reconnectBackoff"`
+	MaxReconnectBackoff time.Duration `yaml:"maxReconnectBackoff"`
+}
+
+// PostgresNotify configures notifications over Postgres LISTEN/NOTIFY, no broker is required.
+// Notifications are published with pg_notify on Channel, payload is limited to 8000 bytes.
+type PostgresNotify struct {
+	Channel string `yaml:"channel"`
+}
+
+// NotificationListener subscribes to Postgres Channel and dispatches notifications of all service instances
+// to in-process handlers, lost connection is restored with backoff from ReconnectBackoff doubling up to MaxReconnectBackoff.
+type NotificationListener struct {
+	Enabled             bool          `yaml:"enabled"`
+	Channel             string        `yaml:"channel"`
+	ReconnectBackoff    time.Duration `yaml:"reconnectBackoff"`
+	MaxReconnectBackoff time.Duration `yaml:"maxReconnectBackoff"`
+}
+
+// Login configures brute-force protection of the login endpoint.
+// Failures are counted per account and per client IP, a counter is reset when its last failure is older than FailureWindow.
+type Login struct {
+	MaxAccountFailures int           `yaml:"maxAccountFailures"`
+	MaxIPFailures      int           `yaml:"maxIPFailures"`
+	FailureWindow      time.Duration `yaml:"failureWindow"`
+	DelayStep          time.Duration `yaml:"delayStep"`
+	MaxDelay           time.Duration `yaml:"maxDelay"`
+	LockoutDuration    time.Duration `yaml:"lockoutDuration"`
+}
+
+// PasswordPolicy configures password requirements.
+// BreachedListDir is a directory with breached passwords range files, empty value disables the check.
+type PasswordPolicy struct {
+	MinLength       int    `yaml:"minLength"`
+	RequireUpper    bool   `yaml:"requireUpper"`
+	RequireLower    bool   `yaml:"requireLower"`
+	RequireDigit    bool   `yaml:"requireDigit"`
+	RequireSymbol   bool   `yaml:"requireSymbol"`
+	HistorySize     int    `yaml:"historySize"`
+	BreachedListDir string `yaml:"breachedListDir"`
+}
+
+// Admin configures access to the administrative API.
+// Empty Token disables the administrative API.
+type Admin struct {
+	Token string `yaml:"token"`
+}
+
+// APIKeys configures service-to-service authentication.
+// If Required, users API rejects requests without API key, otherwise anonymous requests aren't restricted by scopes.
+// LastUsedInterval limits last-used timestamp writes.
+type APIKeys struct {
+	Required         bool          `yaml:"required"`
+	LastUsedInterval time.Duration `yaml:"lastUsedInterval"`
 }
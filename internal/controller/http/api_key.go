@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/controller/http/dto"
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/usecase"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=api_key.go --destination=api_key_mock.go --package=http
+
+// APIKeyAuthenticator checks plain API key.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, plainKey string) (entity.APIKey, error)
+}
+
+// APIKeyUseCase describes API keys management methods.
+type APIKeyUseCase interface {
+	Issue(ctx context.Context, name string, scopes []string, expiresAt time.Time) (entity.APIKey, string, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+// APIKey is responsible for handling API keys management requests. Admin only.
+type APIKey struct {
+	apiKeyService APIKeyUseCase
+	logger        logger.Logger
+}
+
+// NewAPIKeyHandler creates new APIKey handler.
+func NewAPIKeyHandler(apiKeyService APIKeyUseCase, l logger.Logger) *APIKey {
+	return &APIKey{apiKeyService: apiKeyService, logger: l}
+}
+
+func (a *APIKey) Issue(ctx echo.Context) error {
+	var req dto.APIKeyIssueRequest
+	err := ctx.Bind(&req)
+	if err != nil {
+		requestLogger(a.logger, ctx, "api key issue").Error(fmt.Errorf("bind: %w", err))
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
+	}
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	key, plainKey, err := a.apiKeyService.Issue(ctx.Request().Context(), req.Name, req.Scopes, expiresAt)
+	if errors.Is(err, usecase.ErrInvalidScope) {
+		return ctx.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		requestLogger(a.logger, ctx, "api key issue").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(http.StatusCreated, BaseResponse{Data: dto.APIKeyIssueResponse{
+		APIKeyResponse: dto.MapAPIKeyToAPIKeyResponse(key),
+		Key:            plainKey,
+	}})
+}
+
+func (a *APIKey) List(ctx echo.Context) error {
+	keys, err := a.apiKeyService.List(ctx.Request().Context())
+	if err != nil {
+		requestLogger(a.logger, ctx, "api key list").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.MapAPIKeysToAPIKeyResponses(keys)})
+}
+
+func (a *APIKey) Revoke(ctx echo.Context) error {
+	err := a.apiKeyService.Revoke(ctx.Request().Context(), ctx.Param("id"))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
+	if err != nil {
+		requestLogger(a.logger, ctx, "api key revoke").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/usecase"
+)
+
+func TestAPIKey_Issue(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "successful issue",
+			requestBody: `{"name":"billing","scopes":["users:read"],"expires_at":"2025-01-01T00:00:00Z"}`,
+			mockSetup: func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger) {
+				mockAPIKeyUseCase.EXPECT().Issue(gomock.Any(), "billing", []string{"users:read"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).
+					Return(entity.APIKey{ID: "1", Name: "billing", Prefix: "0a1b2c3d", Scopes: []string{"users:read"}, CreatedAt: createdAt}, "tt_0a1b2c3d_secret", nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"data":{"id":"1","name":"billing","prefix":"0a1b2c3d","scopes":["users:read"],"created_at":"2024-05-01T12:00:00Z","key":"tt_0a1b2c3d_secret"}}` + "\n",
+		},
+		{
+			name:        "invalid scope",
+			requestBody: `{"name":"billing","scopes":["users:admin"]}`,
+			mockSetup: func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger) {
+				mockAPIKeyUseCase.EXPECT().Issue(gomock.Any(), "billing", []string{"users:admin"}, time.Time{}).
+					Return(entity.APIKey{}, "", usecase.ErrInvalidScope)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":"invalid scope"}` + "\n",
+		},
+		{
+			name:        "bind error",
+			requestBody: `invalid json`,
+			mockSetup: func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger) {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Bad Request"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockAPIKeyUseCase := NewMockAPIKeyUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockAPIKeyUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewAPIKeyHandler(mockAPIKeyUseCase, mockLogger)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			ao.NoError(handler.Issue(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.Equal(tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestAPIKey_List(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockAPIKeyUseCase := NewMockAPIKeyUseCase(ctrl)
+	revokedAt := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	mockAPIKeyUseCase.EXPECT().List(gomock.Any()).Return([]entity.APIKey{
+		{ID: "1", Name: "billing", Prefix: "0a1b2c3d", Scopes: []string{"users:read"}, RevokedAt: revokedAt},
+	}, nil)
+
+	e := echo.New()
+	handler := NewAPIKeyHandler(mockAPIKeyUseCase, logger.NewMockLogger(ctrl))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	ao.NoError(handler.List(c))
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Equal(`{"data":[{"id":"1","name":"billing","prefix":"0a1b2c3d","scopes":["users:read"],"revoked_at":"2024-05-02T12:00:00Z","created_at":"0001-01-01T00:00:00Z"}]}`+"\n", rec.Body.String())
+}
+
+func TestAPIKey_Revoke(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockSetup      func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger)
+		expectedStatus int
+	}{
+		{
+			name: "successful revoke",
+			mockSetup: func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger) {
+				mockAPIKeyUseCase.EXPECT().Revoke(gomock.Any(), "1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "not found",
+			mockSetup: func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger) {
+				mockAPIKeyUseCase.EXPECT().Revoke(gomock.Any(), "1").Return(datastore.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "service error",
+			mockSetup: func(mockAPIKeyUseCase *MockAPIKeyUseCase, mockLogger *logger.MockLogger) {
+				mockAPIKeyUseCase.EXPECT().Revoke(gomock.Any(), "1").Return(errors.New("service error"))
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockAPIKeyUseCase := NewMockAPIKeyUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockAPIKeyUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewAPIKeyHandler(mockAPIKeyUseCase, mockLogger)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			ao.NoError(handler.Revoke(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/controller/http/dto"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/usecase"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=auth.go --destination=auth_mock.go --package=http
+
+// AuthUseCase describes login methods.
+type AuthUseCase interface {
+	Login(ctx context.Context, credentials entity.Credentials) (entity.User, error)
+	Unlock(ctx context.Context, login, clientIP string) error
+}
+
+// Auth is responsible for handling login and lockout requests.
+type Auth struct {
+	authService AuthUseCase
+	logger      logger.Logger
+}
+
+// NewAuthHandler creates new Auth handler.
+func NewAuthHandler(authService AuthUseCase, l logger.Logger) *Auth {
+	return &Auth{authService: authService, logger: l}
+}
+
+func (a *Auth) Login(ctx echo.Context) error {
+	var req dto.LoginRequest
+	err := ctx.Bind(&req)
+	if err != nil {
+		requestLogger(a.logger, ctx, "login").Error(fmt.Errorf("bind: %w", err))
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
+	}
+
+	result, err := a.authService.Login(ctx.Request().Context(), dto.MapLoginRequestToEntity(req, ctx.RealIP()))
+	if err != nil {
+		var lockedErr *usecase.LockedError
+		switch {
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": http.StatusText(http.StatusUnauthorized)})
+		case errors.As(err, &lockedErr):
+			return lockedOut(ctx, lockedErr)
+		}
+		requestLogger(a.logger, ctx, "login").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.MapUserToUserViewResponse(result)})
+}
+
+// Unlock removes lockout for login and/or client IP. Admin only.
+func (a *Auth) Unlock(ctx echo.Context) error {
+	var req dto.UnlockRequest
+	err := ctx.Bind(&req)
+	if err != nil {
+		requestLogger(a.logger, ctx, "unlock").Error(fmt.Errorf("bind: %w", err))
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
+	}
+	if req.Login == "" && req.ClientIP == "" {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "login or client_ip is required"})
+	}
+
+	err = a.authService.Unlock(ctx.Request().Context(), req.Login, req.ClientIP)
+	if err != nil {
+		requestLogger(a.logger, ctx, "unlock").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// lockedOut asks client to retry after lockout ends.
+func lockedOut(ctx echo.Context, err *usecase.LockedError) error {
+	retryAfter := math.Ceil(time.Until(err.Until).Seconds())
+	ctx.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Max(retryAfter, 1))))
+	return ctx.JSON(http.StatusTooManyRequests, map[string]string{"error": http.StatusText(http.StatusTooManyRequests)})
+}
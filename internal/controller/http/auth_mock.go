@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: auth.go
+
+// Package http is a generated GoMock package.
+package http
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "test_task/internal/entity"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuthUseCase is a mock of AuthUseCase interface.
+type MockAuthUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthUseCaseMockRecorder
+}
+
+// MockAuthUseCaseMockRecorder is the mock recorder for MockAuthUseCase.
+type MockAuthUseCaseMockRecorder struct {
+	mock *MockAuthUseCase
+}
+
+// NewMockAuthUseCase creates a new mock instance.
+func NewMockAuthUseCase(ctrl *gomock.Controller) *MockAuthUseCase {
+	mock := &MockAuthUseCase{ctrl: ctrl}
+	mock.recorder = &MockAuthUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthUseCase) EXPECT() *MockAuthUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Login mocks base method.
+func (m *MockAuthUseCase) Login(ctx context.Context, credentials entity.Credentials) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, credentials)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthUseCaseMockRecorder) Login(ctx, credentials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthUseCase)(nil).Login), ctx, credentials)
+}
+
+// Unlock mocks base method.
+func (m *MockAuthUseCase) Unlock(ctx context.Context, login, clientIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, login, clientIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockAuthUseCaseMockRecorder) Unlock(ctx, login, clientIP interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockAuthUseCase)(nil).Unlock), ctx, login, clientIP)
+}
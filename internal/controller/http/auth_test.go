@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/usecase"
+)
+
+func TestAuth_Login(t *testing.T) {
+	tests := []struct {
+		name               string
+		requestBody        string
+		mockSetup          func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger)
+		expectedStatus     int
+		expectedBody       string
+		expectedRetryAfter string
+	}{
+		{
+			name:        "successful login",
+			requestBody: `{"login":"jdoe","password":"password123"}`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockAuthUseCase.EXPECT().Login(gomock.Any(), entity.Credentials{Login: "jdoe", Password: "password123", ClientIP: "192.0.2.1"}).
+					Return(entity.User{ID: "1", Nickname: "jdoe", Password: "password123"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"id":"1","first_name":"","last_name":"","nickname":"jdoe","email":"","country":""}}` + "\n",
+		},
+		{
+			name:        "failed login due to bind error",
+			requestBody: `invalid json`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Bad Request"}` + "\n",
+		},
+		{
+			name:        "invalid credentials",
+			requestBody: `{"login":"jdoe","password":"wrong"}`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockAuthUseCase.EXPECT().Login(gomock.Any(), gomock.Any()).Return(entity.User{}, usecase.ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Unauthorized"}` + "\n",
+		},
+		{
+			name:        "locked",
+			requestBody: `{"login":"jdoe","password":"password123"}`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockAuthUseCase.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(entity.User{}, &usecase.LockedError{Until: time.Now().Add(time.Minute)})
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedBody:       `{"error":"Too Many Requests"}` + "\n",
+			expectedRetryAfter: "60",
+		},
+		{
+			name:        "service error",
+			requestBody: `{"login":"jdoe","password":"password123"}`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockAuthUseCase.EXPECT().Login(gomock.Any(), gomock.Any()).Return(entity.User{}, errors.New("service error"))
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockAuthUseCase := NewMockAuthUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockAuthUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewAuthHandler(mockAuthUseCase, mockLogger)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := handler.Login(c)
+			ao.NoError(err)
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.Equal(tt.expectedBody, rec.Body.String())
+			ao.Equal(tt.expectedRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestAuth_Unlock(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger)
+		expectedStatus int
+	}{
+		{
+			name:        "successful unlock",
+			requestBody: `{"login":"jdoe","client_ip":"10.0.0.1"}`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockAuthUseCase.EXPECT().Unlock(gomock.Any(), "jdoe", "10.0.0.1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "empty request",
+			requestBody:    `{}`,
+			mockSetup:      func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			requestBody: `{"login":"jdoe"}`,
+			mockSetup: func(mockAuthUseCase *MockAuthUseCase, mockLogger *logger.MockLogger) {
+				mockAuthUseCase.EXPECT().Unlock(gomock.Any(), "jdoe", "").Return(errors.New("service error"))
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockAuthUseCase := NewMockAuthUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockAuthUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewAuthHandler(mockAuthUseCase, mockLogger)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			ao.NoError(handler.Unlock(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+		})
+	}
+}
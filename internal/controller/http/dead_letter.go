@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/controller/http/dto"
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/notificator"
+	"test_task/internal/pagination"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=dead_letter.go --destination=dead_letter_mock.go --package=http
+
+const defaultDeadLetterPageSize = 100
+
+// DeadLetterUseCase describes management methods of undelivered notifications.
+type DeadLetterUseCase interface {
+	List(ctx context.Context, page pagination.Pagination) ([]entity.DeadLetter, int64, error)
+	Get(ctx context.Context, id string) (entity.DeadLetter, error)
+	Replay(ctx context.Context, id string) error
+	ReplayAll(ctx context.Context) (int, error)
+	Delete(ctx context.Context, id string) error
+	Purge(ctx context.Context) (int64, error)
+}
+
+// DeadLetter is responsible for handling dead letters management requests. Admin only.
+type DeadLetter struct {
+	deadLetterService DeadLetterUseCase
+	logger            logger.Logger
+}
+
+// NewDeadLetterHandler creates new DeadLetter handler.
+func NewDeadLetterHandler(deadLetterService DeadLetterUseCase, l logger.Logger) *DeadLetter {
+	return &DeadLetter{deadLetterService: deadLetterService, logger: l}
+}
+
+func (d *DeadLetter) List(ctx echo.Context) error {
+	var req dto.DeadLetterListRequest
+	if err := ctx.Bind(&req); err != nil {
+		requestLogger(d.logger, ctx, "dead letter list").Error(fmt.Errorf("bind: %w", err))
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
+	}
+	if req.Size <= 0 {
+		req.Size = defaultDeadLetterPageSize
+	}
+	letters, total, err := d.deadLetterService.List(ctx.Request().Context(), req.Pagination)
+	if err != nil {
+		requestLogger(d.logger, ctx, "dead letter list").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, PaginatedBaseResponse{
+		Pagination: ResponsePagination{
+			CurrentPage: max(req.Number, 1),
+			LastPage:    pagination.CalculateLastPage(int(total), req.Size),
+			Total:       total,
+		},
+		Data: dto.MapDeadLettersToDeadLetterResponses(letters),
+	})
+}
+
+func (d *DeadLetter) Get(ctx echo.Context) error {
+	letter, err := d.deadLetterService.Get(ctx.Request().Context(), ctx.Param("id"))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
+	if err != nil {
+		requestLogger(d.logger, ctx, "dead letter get").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.MapDeadLetterToDeadLetterDetailsResponse(letter)})
+}
+
+// Replay pushes dead letter again, it is removed on success. Sink failure is reported with 502.
+func (d *DeadLetter) Replay(ctx echo.Context) error {
+	err := d.deadLetterService.Replay(ctx.Request().Context(), ctx.Param("id"))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
+	if errors.Is(err, notificator.ErrReplay) {
+		requestLogger(d.logger, ctx, "dead letter replay").Warn(err)
+		return ctx.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		requestLogger(d.logger, ctx, "dead letter replay").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.DeadLetterReplayResponse{Replayed: 1}})
+}
+
+// ReplayAll replays dead letters until the first failure, number of replayed letters is returned in both cases.
+func (d *DeadLetter) ReplayAll(ctx echo.Context) error {
+	replayed, err := d.deadLetterService.ReplayAll(ctx.Request().Context())
+	if errors.Is(err, notificator.ErrReplay) {
+		requestLogger(d.logger, ctx, "dead letter replay").Warn(err)
+		return ctx.JSON(http.StatusBadGateway, map[string]interface{}{"error": err.Error(), "replayed": replayed})
+	}
+	if err != nil {
+		requestLogger(d.logger, ctx, "dead letter replay").Error(err)
+		return ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":    http.StatusText(http.StatusInternalServerError),
+			"replayed": replayed,
+		})
+	}
+	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.DeadLetterReplayResponse{Replayed: replayed}})
+}
+
+func (d *DeadLetter) Delete(ctx echo.Context) error {
+	err := d.deadLetterService.Delete(ctx.Request().Context(), ctx.Param("id"))
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
+	if err != nil {
+		requestLogger(d.logger, ctx, "dead letter delete").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Purge removes all dead letters.
+func (d *DeadLetter) Purge(ctx echo.Context) error {
+	deleted, err := d.deadLetterService.Purge(ctx.Request().Context())
+	if err != nil {
+		requestLogger(d.logger, ctx, "dead letter purge").Error(err)
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.DeadLetterPurgeResponse{Deleted: deleted}})
+}
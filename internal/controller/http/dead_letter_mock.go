@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: dead_letter.go
+
+// Package http is a generated GoMock package.
+package http
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "test_task/internal/entity"
+	pagination "test_task/internal/pagination"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDeadLetterUseCase is a mock of DeadLetterUseCase interface.
+type MockDeadLetterUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadLetterUseCaseMockRecorder
+}
+
+// MockDeadLetterUseCaseMockRecorder is the mock recorder for MockDeadLetterUseCase.
+type MockDeadLetterUseCaseMockRecorder struct {
+	mock *MockDeadLetterUseCase
+}
+
+// NewMockDeadLetterUseCase creates a new mock instance.
+func NewMockDeadLetterUseCase(ctrl *gomock.Controller) *MockDeadLetterUseCase {
+	mock := &MockDeadLetterUseCase{ctrl: ctrl}
+	mock.recorder = &MockDeadLetterUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLetterUseCase) EXPECT() *MockDeadLetterUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockDeadLetterUseCase) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockDeadLetterUseCaseMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDeadLetterUseCase)(nil).Delete), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockDeadLetterUseCase) Get(ctx context.Context, id string) (entity.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(entity.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDeadLetterUseCaseMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeadLetterUseCase)(nil).Get), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockDeadLetterUseCase) List(ctx context.Context, page pagination.Pagination) ([]entity.DeadLetter, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, page)
+	ret0, _ := ret[0].([]entity.DeadLetter)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockDeadLetterUseCaseMockRecorder) List(ctx, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDeadLetterUseCase)(nil).List), ctx, page)
+}
+
+// Purge mocks base method.
+func (m *MockDeadLetterUseCase) Purge(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockDeadLetterUseCaseMockRecorder) Purge(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockDeadLetterUseCase)(nil).Purge), ctx)
+}
+
+// Replay mocks base method.
+func (m *MockDeadLetterUseCase) Replay(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockDeadLetterUseCaseMockRecorder) Replay(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockDeadLetterUseCase)(nil).Replay), ctx, id)
+}
+
+// ReplayAll mocks base method.
+func (m *MockDeadLetterUseCase) ReplayAll(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayAll", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayAll indicates an expected call of ReplayAll.
+func (mr *MockDeadLetterUseCaseMockRecorder) ReplayAll(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayAll", reflect.TypeOf((*MockDeadLetterUseCase)(nil).ReplayAll), ctx)
+}
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/notificator"
+	"test_task/internal/pagination"
+)
+
+func TestDeadLetter_List(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "default page size",
+			query: "",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().List(gomock.Any(), pagination.Pagination{Size: defaultDeadLetterPageSize}).
+					Return([]entity.DeadLetter{{
+						ID:        "1",
+						Key:       "42",
+						Type:      "Update",
+						Attempts:  []entity.DeliveryAttempt{{Error: "push: timeout"}, {Error: "push: closed"}},
+						CreatedAt: createdAt,
+					}}, int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"pagination":{"current_page":1,"last_page":1,"total":1},` +
+				`"data":[{"id":"1","key":"42","type":"Update","attempts":2,"last_error":"push: closed","created_at":"2024-05-01T12:00:00Z"}]}` + "\n",
+		},
+		{
+			name:  "page",
+			query: "?page=2&size=10",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().List(gomock.Any(), pagination.Pagination{Number: 2, Size: 10}).
+					Return(nil, int64(25), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"pagination":{"current_page":2,"last_page":3,"total":25},"data":[]}` + "\n",
+		},
+		{
+			name: "store error",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, int64(0), errors.New("read directory"))
+				mockLogger.EXPECT().Error(errors.New("read directory"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockDeadLetterUseCase := NewMockDeadLetterUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockDeadLetterUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewDeadLetterHandler(mockDeadLetterUseCase, mockLogger)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil), rec)
+
+			ao.NoError(handler.List(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.Equal(tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestDeadLetter_Get(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockDeadLetterUseCase := NewMockDeadLetterUseCase(ctrl)
+	failedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockDeadLetterUseCase.EXPECT().Get(gomock.Any(), "1").Return(entity.DeadLetter{
+		ID:        "1",
+		Key:       "42",
+		Type:      "Delete",
+		Payload:   []byte(`{"Type":"Delete","Key":"42"}`),
+		Attempts:  []entity.DeliveryAttempt{{At: failedAt, Error: "push: timeout"}},
+		CreatedAt: failedAt,
+	}, nil)
+	mockDeadLetterUseCase.EXPECT().Get(gomock.Any(), "2").Return(entity.DeadLetter{}, datastore.ErrNotFound)
+
+	e := echo.New()
+	handler := NewDeadLetterHandler(mockDeadLetterUseCase, logger.NewMockLogger(ctrl))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	ao.NoError(handler.Get(c))
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Equal(`{"data":{"id":"1","key":"42","type":"Delete","attempts":1,"last_error":"push: timeout","created_at":"2024-05-01T12:00:00Z",`+
+		`"payload":{"Type":"Delete","Key":"42"},"history":[{"at":"2024-05-01T12:00:00Z","error":"push: timeout"}]}}`+"\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	ao.NoError(handler.Get(c))
+	ao.Equal(http.StatusNotFound, rec.Code)
+}
+
+func TestDeadLetter_Replay(t *testing.T) {
+	replayErr := fmt.Errorf("%w: %w", notificator.ErrReplay, errors.New("timeout"))
+	tests := []struct {
+		name           string
+		mockSetup      func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "replayed",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().Replay(gomock.Any(), "1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"replayed":1}}` + "\n",
+		},
+		{
+			name: "not found",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().Replay(gomock.Any(), "1").Return(datastore.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Not Found"}` + "\n",
+		},
+		{
+			name: "sink error",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().Replay(gomock.Any(), "1").Return(replayErr)
+				mockLogger.EXPECT().Warn(replayErr)
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"error":"replay failed: timeout"}` + "\n",
+		},
+		{
+			name: "delete error",
+			mockSetup: func(mockDeadLetterUseCase *MockDeadLetterUseCase, mockLogger *logger.MockLogger) {
+				mockDeadLetterUseCase.EXPECT().Replay(gomock.Any(), "1").Return(errors.New("delete: conn closed"))
+				mockLogger.EXPECT().Error(errors.New("delete: conn closed"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockDeadLetterUseCase := NewMockDeadLetterUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockDeadLetterUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewDeadLetterHandler(mockDeadLetterUseCase, mockLogger)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			ao.NoError(handler.Replay(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.Equal(tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestDeadLetter_ReplayAll(t *testing.T) {
+	replayErr := fmt.Errorf("%w: %w", notificator.ErrReplay, errors.New("timeout"))
+	tests := []struct {
+		name           string
+		replayed       int
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "all replayed",
+			replayed:       3,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"replayed":3}}` + "\n",
+		},
+		{
+			name:           "sink error",
+			replayed:       2,
+			err:            replayErr,
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"error":"replay failed: timeout","replayed":2}` + "\n",
+		},
+		{
+			name:           "store error",
+			err:            errors.New("conn closed"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Internal Server Error","replayed":0}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockDeadLetterUseCase := NewMockDeadLetterUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().Warn(gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockDeadLetterUseCase.EXPECT().ReplayAll(gomock.Any()).Return(tt.replayed, tt.err)
+
+			e := echo.New()
+			handler := NewDeadLetterHandler(mockDeadLetterUseCase, mockLogger)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+
+			ao.NoError(handler.ReplayAll(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.Equal(tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestDeadLetter_Delete(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockDeadLetterUseCase := NewMockDeadLetterUseCase(ctrl)
+	mockDeadLetterUseCase.EXPECT().Delete(gomock.Any(), "1").Return(nil)
+	mockDeadLetterUseCase.EXPECT().Delete(gomock.Any(), "2").Return(datastore.ErrNotFound)
+
+	e := echo.New()
+	handler := NewDeadLetterHandler(mockDeadLetterUseCase, logger.NewMockLogger(ctrl))
+	for id, expectedStatus := range map[string]int{"1": http.StatusOK, "2": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		ao.NoError(handler.Delete(c))
+		ao.Equal(expectedStatus, rec.Code, "id=%s", id)
+	}
+}
+
+func TestDeadLetter_Purge(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockDeadLetterUseCase := NewMockDeadLetterUseCase(ctrl)
+	mockDeadLetterUseCase.EXPECT().Purge(gomock.Any()).Return(int64(7), nil)
+
+	e := echo.New()
+	handler := NewDeadLetterHandler(mockDeadLetterUseCase, logger.NewMockLogger(ctrl))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+	ao.NoError(handler.Purge(c))
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Equal(`{"data":{"deleted":7}}`+"\n", rec.Body.String())
+}
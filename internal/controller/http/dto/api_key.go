@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"test_task/internal/entity"
+)
+
+type (
+	APIKeyIssueRequest struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	APIKeyResponse struct {
+		ID         string     `json:"id"`
+		Name       string     `json:"name"`
+		Prefix     string     `json:"prefix"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+		RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	// APIKeyIssueResponse contains plain key, it is shown only once.
+	APIKeyIssueResponse struct {
+		APIKeyResponse
+		Key string `json:"key"`
+	}
+)
+
+func MapAPIKeyToAPIKeyResponse(key entity.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  optionalTime(key.ExpiresAt),
+		LastUsedAt: optionalTime(key.LastUsedAt),
+		RevokedAt:  optionalTime(key.RevokedAt),
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func MapAPIKeysToAPIKeyResponses(keys []entity.APIKey) []APIKeyResponse {
+	res := make([]APIKeyResponse, 0, len(keys))
+	for _, v := range keys {
+		res = append(res, MapAPIKeyToAPIKeyResponse(v))
+	}
+	return res
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
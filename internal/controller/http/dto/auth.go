@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"test_task/internal/entity"
+)
+
+type (
+	LoginRequest struct {
+		Login    string `json:"login"`
+		Password string `json:"password"`
+	}
+
+	UnlockRequest struct {
+		Login    string `json:"login"`
+		ClientIP string `json:"client_ip"`
+	}
+)
+
+func MapLoginRequestToEntity(request LoginRequest, clientIP string) entity.Credentials {
+	return entity.Credentials{
+		Login:    request.Login,
+		Password: request.Password,
+		ClientIP: clientIP,
+	}
+}
+
+func MapUserToUserViewResponse(entity entity.User) UserViewResponse {
+	return UserViewResponse{
+		ID:        entity.ID,
+		FirstName: entity.FirstName,
+		LastName:  entity.LastName,
+		Nickname:  entity.Nickname,
+		Email:     entity.Email,
+		Country:   entity.Country,
+	}
+}
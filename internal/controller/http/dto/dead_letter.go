@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"test_task/internal/entity"
+	"test_task/internal/pagination"
+)
+
+type (
+	DeadLetterListRequest struct {
+		pagination.Pagination
+	}
+
+	DeadLetterResponse struct {
+		ID        string    `json:"id"`
+		Key       string    `json:"key"`
+		Type      string    `json:"type"`
+		Attempts  int       `json:"attempts"`
+		LastError string    `json:"last_error"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// DeadLetterDetailsResponse contains notification and all failed attempts.
+	DeadLetterDetailsResponse struct {
+		DeadLetterResponse
+		Payload json.RawMessage           `json:"payload"`
+		History []DeliveryAttemptResponse `json:"history"`
+	}
+
+	DeliveryAttemptResponse struct {
+		At    time.Time `json:"at"`
+		Error string    `json:"error"`
+	}
+
+	DeadLetterReplayResponse struct {
+		Replayed int `json:"replayed"`
+	}
+
+	DeadLetterPurgeResponse struct {
+		Deleted int64 `json:"deleted"`
+	}
+)
+
+func MapDeadLetterToDeadLetterResponse(letter entity.DeadLetter) DeadLetterResponse {
+	res := DeadLetterResponse{
+		ID:        letter.ID,
+		Key:       letter.Key,
+		Type:      letter.Type,
+		Attempts:  len(letter.Attempts),
+		CreatedAt: letter.CreatedAt,
+	}
+	if len(letter.Attempts) > 0 {
+		res.LastError = letter.Attempts[len(letter.Attempts)-1].Error
+	}
+	return res
+}
+
+func MapDeadLettersToDeadLetterResponses(letters []entity.DeadLetter) []DeadLetterResponse {
+	res := make([]DeadLetterResponse, 0, len(letters))
+	for _, v := range letters {
+		res = append(res, MapDeadLetterToDeadLetterResponse(v))
+	}
+	return res
+}
+
+func MapDeadLetterToDeadLetterDetailsResponse(letter entity.DeadLetter) DeadLetterDetailsResponse {
+	history := make([]DeliveryAttemptResponse, 0, len(letter.Attempts))
+	for _, v := range letter.Attempts {
+		history = append(history, DeliveryAttemptResponse{At: v.At, Error: v.Error})
+	}
+	return DeadLetterDetailsResponse{
+		DeadLetterResponse: MapDeadLetterToDeadLetterResponse(letter),
+		Payload:            letter.Payload,
+		History:            history,
+	}
+}
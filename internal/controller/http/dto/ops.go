@@ -0,0 +1,11 @@
+package dto
+
+type (
+	LogLevelRequest struct {
+		Level string `json:"level"`
+	}
+
+	LogLevelResponse struct {
+		Level string `json:"level"`
+	}
+)
@@ -15,6 +15,12 @@ type (
 		UserInputCore
 	}
 
+	ChangePasswordRequest struct {
+		ID          string `param:"id"`
+		OldPassword string `json:"old_password"`
+		NewPassword string `json:"new_password"`
+	}
+
 	UserListRequest struct {
 		UserFilters
 		pagination.Pagination
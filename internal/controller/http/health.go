@@ -1,32 +1,49 @@
 package http
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	"test_task/internal/health"
 )
 
 //go:generate go run github.com/golang/mock/mockgen --source=health.go --destination=health_mock.go --package=http
 
-type HealthChecker interface {
-	Health(ctx context.Context) error
+// HealthReporter returns state of components, it must not block on checks.
+type HealthReporter interface {
+	Report() health.Report
 }
 
 type Health struct {
-	healthCheckers []HealthChecker
+	reporter HealthReporter
+	detailed bool
+}
+
+// NewHealthController creates Health which serves only status of every component, so it is safe on the public port.
+func NewHealthController(reporter HealthReporter) *Health {
+	return &Health{reporter: reporter}
 }
 
-func NewHealthController(healthCheckers []HealthChecker) *Health {
-	return &Health{healthCheckers: healthCheckers}
+// NewDetailedHealthController creates Health which serves errors, latency and history of components.
+func NewDetailedHealthController(reporter HealthReporter) *Health {
+	return &Health{reporter: reporter, detailed: true}
 }
 
-func (h *Health) View(ctx echo.Context) error {
-	for _, v := range h.healthCheckers {
-		err := v.Health(ctx.Request().Context())
-		if err != nil {
-			return ctx.NoContent(http.StatusInternalServerError)
-		}
+// Live reports that the process is able to serve requests, dependencies are not checked.
+func (h *Health) Live(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// Ready serves collected state, service is not ready when any critical component is down.
+func (h *Health) Ready(ctx echo.Context) error {
+	report := h.reporter.Report()
+	code := http.StatusOK
+	if report.Status == health.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	if !h.detailed {
+		return ctx.JSON(code, report.Summary())
 	}
-	return ctx.NoContent(http.StatusOK)
+	return ctx.JSON(code, report)
 }
@@ -5,45 +5,45 @@
 package http
 
 import (
-	context "context"
 	reflect "reflect"
+	health "test_task/internal/health"
 
 	gomock "github.com/golang/mock/gomock"
 )
 
-// MockHealthChecker is a mock of HealthChecker interface.
-type MockHealthChecker struct {
+// MockHealthReporter is a mock of HealthReporter interface.
+type MockHealthReporter struct {
 	ctrl     *gomock.Controller
-	recorder *MockHealthCheckerMockRecorder
+	recorder *MockHealthReporterMockRecorder
 }
 
-// MockHealthCheckerMockRecorder is the mock recorder for MockHealthChecker.
-type MockHealthCheckerMockRecorder struct {
-	mock *MockHealthChecker
+// MockHealthReporterMockRecorder is the mock recorder for MockHealthReporter.
+type MockHealthReporterMockRecorder struct {
+	mock *MockHealthReporter
 }
 
-// NewMockHealthChecker creates a new mock instance.
-func NewMockHealthChecker(ctrl *gomock.Controller) *MockHealthChecker {
-	mock := &MockHealthChecker{ctrl: ctrl}
-	mock.recorder = &MockHealthCheckerMockRecorder{mock}
+// NewMockHealthReporter creates a new mock instance.
+func NewMockHealthReporter(ctrl *gomock.Controller) *MockHealthReporter {
+	mock := &MockHealthReporter{ctrl: ctrl}
+	mock.recorder = &MockHealthReporterMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockHealthChecker) EXPECT() *MockHealthCheckerMockRecorder {
+func (m *MockHealthReporter) EXPECT() *MockHealthReporterMockRecorder {
 	return m.recorder
 }
 
-// Health mocks base method.
-func (m *MockHealthChecker) Health(ctx context.Context) error {
+// Report mocks base method.
+func (m *MockHealthReporter) Report() health.Report {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Health", ctx)
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "Report")
+	ret0, _ := ret[0].(health.Report)
 	return ret0
 }
 
-// Health indicates an expected call of Health.
-func (mr *MockHealthCheckerMockRecorder) Health(ctx interface{}) *gomock.Call {
+// Report indicates an expected call of Report.
+func (mr *MockHealthReporterMockRecorder) Report() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockHealthChecker)(nil).Health), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockHealthReporter)(nil).Report))
 }
@@ -1,7 +1,7 @@
 package http
 
 import (
-	"errors"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,40 +9,37 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/health"
 )
 
-func TestHealth_View(t *testing.T) {
+func TestHealth_Ready(t *testing.T) {
 	tests := []struct {
 		name           string
-		mockSetup      func(mockHealthChecker1 *MockHealthChecker, mockHealthChecker2 *MockHealthChecker)
+		report         health.Report
 		expectedStatus int
-		expectedErr    error
 	}{
 		{
-			name: "all health checks pass",
-			mockSetup: func(mockHealthChecker1 *MockHealthChecker, mockHealthChecker2 *MockHealthChecker) {
-				mockHealthChecker1.EXPECT().Health(gomock.Any()).Return(nil)
-				mockHealthChecker2.EXPECT().Health(gomock.Any()).Return(nil)
-			},
+			name: "all components are up",
+			report: health.Report{Status: health.StatusUp, Components: []health.Component{
+				{Name: "postgres", Status: health.StatusUp, Critical: true},
+			}},
 			expectedStatus: http.StatusOK,
-			expectedErr:    nil,
 		},
 		{
-			name: "first health check fails",
-			mockSetup: func(mockHealthChecker1 *MockHealthChecker, mockHealthChecker2 *MockHealthChecker) {
-				mockHealthChecker1.EXPECT().Health(gomock.Any()).Return(errors.New("health check failed"))
-			},
-			expectedStatus: http.StatusInternalServerError,
-			expectedErr:    nil,
+			name: "critical component is down",
+			report: health.Report{Status: health.StatusDown, Components: []health.Component{
+				{Name: "postgres", Status: health.StatusDown, Critical: true, Error: "connection refused", ConsecutiveFailures: 3},
+			}},
+			expectedStatus: http.StatusServiceUnavailable,
 		},
 		{
-			name: "second health check fails",
-			mockSetup: func(mockHealthChecker1 *MockHealthChecker, mockHealthChecker2 *MockHealthChecker) {
-				mockHealthChecker1.EXPECT().Health(gomock.Any()).Return(nil)
-				mockHealthChecker2.EXPECT().Health(gomock.Any()).Return(errors.New("health check failed"))
-			},
-			expectedStatus: http.StatusInternalServerError,
-			expectedErr:    nil,
+			name: "non-critical component is down",
+			report: health.Report{Status: health.StatusDegraded, Components: []health.Component{
+				{Name: "notification", Status: health.StatusDown, Error: "broker unavailable"},
+			}},
+			expectedStatus: http.StatusOK,
 		},
 	}
 
@@ -50,22 +47,45 @@ func TestHealth_View(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ao := assert.New(t)
 			ctrl := gomock.NewController(t)
-			mockHealthChecker1 := NewMockHealthChecker(ctrl)
-			mockHealthChecker2 := NewMockHealthChecker(ctrl)
-
-			tt.mockSetup(mockHealthChecker1, mockHealthChecker2)
+			mockReporter := NewMockHealthReporter(ctrl)
+			mockReporter.EXPECT().Report().Return(tt.report).Times(2)
 
 			e := echo.New()
-			handler := NewHealthController([]HealthChecker{mockHealthChecker1, mockHealthChecker2})
-
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
-			rec := httptest.NewRecorder()
-			c := e.NewContext(req, rec)
+			for _, handler := range []*Health{NewHealthController(mockReporter), NewDetailedHealthController(mockReporter)} {
+				req := httptest.NewRequest(http.MethodGet, ReadinessPath, nil)
+				rec := httptest.NewRecorder()
+				c := e.NewContext(req, rec)
 
-			err := handler.View(c)
+				ao.NoError(handler.Ready(c))
+				ao.Equal(tt.expectedStatus, rec.Code)
 
-			ao.Equal(tt.expectedStatus, rec.Code)
-			ao.Equal(tt.expectedErr, err)
+				var report health.Report
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+				ao.Equal(tt.report.Status, report.Status)
+				ao.Equal(tt.report.Components[0].Status, report.Components[0].Status)
+				if !handler.detailed {
+					// public port doesn't expose details of components
+					ao.NotContains(rec.Body.String(), "error")
+					ao.NotContains(rec.Body.String(), "latency_ms")
+					continue
+				}
+				ao.Equal(tt.report.Components[0].Error, report.Components[0].Error)
+				ao.Equal(tt.report.Components[0].ConsecutiveFailures, report.Components[0].ConsecutiveFailures)
+			}
 		})
 	}
 }
+
+func TestHealth_Live(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	// liveness must not depend on dependencies
+	handler := NewHealthController(NewMockHealthReporter(ctrl))
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, LivenessPath, nil), rec)
+
+	ao.NoError(handler.Live(c))
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.JSONEq(`{"status":"up"}`, rec.Body.String())
+}
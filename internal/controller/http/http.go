@@ -1,5 +1,11 @@
 package http
 
+import (
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/logger"
+)
+
 type BaseResponse struct {
 	Data interface{} `json:"data,omitempty"`
 }
@@ -14,3 +20,8 @@ type PaginatedBaseResponse struct {
 	Pagination ResponsePagination `json:"pagination"`
 	Data       interface{}        `json:"data,omitempty"`
 }
+
+// requestLogger returns logger with request context and operation field.
+func requestLogger(l logger.Logger, ctx echo.Context, operation string) logger.Logger {
+	return l.WithContext(ctx.Request().Context()).WithFields(logger.Fields{logger.FieldOperation: operation})
+}
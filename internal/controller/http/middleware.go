@@ -0,0 +1,99 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/usecase"
+)
+
+const (
+	principalContextKey = "principal"
+	apiKeyAuthScheme    = "ApiKey"
+)
+
+// Middlewares combines route-specific middlewares.
+type Middlewares struct {
+	AdminAuth  echo.MiddlewareFunc
+	APIKeyAuth echo.MiddlewareFunc
+	RateLimit  *RateLimit
+}
+
+// NewAdminAuth checks "Authorization: Bearer <token>" header against the configured admin token.
+// Every request is rejected if token is empty.
+func NewAdminAuth(token string) echo.MiddlewareFunc {
+	return middleware.KeyAuth(func(key string, ctx echo.Context) (bool, error) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(key), []byte(token)) != 1 {
+			return false, nil
+		}
+		ctx.Set(principalContextKey, entity.Principal{Type: entity.PrincipalAdmin, ID: string(entity.PrincipalAdmin)})
+		return true, nil
+	})
+}
+
+// NewAPIKeyAuth authenticates "Authorization: ApiKey <key>" header.
+// Requests without API key are passed through as anonymous, unless required.
+func NewAPIKeyAuth(authenticator APIKeyAuthenticator, required bool, l logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			scheme, plainKey, _ := strings.Cut(ctx.Request().Header.Get(echo.HeaderAuthorization), " ")
+			if !strings.EqualFold(scheme, apiKeyAuthScheme) || plainKey == "" {
+				if required {
+					return echo.NewHTTPError(http.StatusUnauthorized, "missing api key")
+				}
+				return next(ctx)
+			}
+
+			key, err := authenticator.Authenticate(ctx.Request().Context(), plainKey)
+			if errors.Is(err, usecase.ErrInvalidAPIKey) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid api key")
+			}
+			if err != nil {
+				requestLogger(l, ctx, "api key auth").Error(err)
+				return echo.NewHTTPError(http.StatusInternalServerError)
+			}
+			ctx.Set(principalContextKey, entity.Principal{Type: entity.PrincipalAPIKey, ID: key.Prefix, Scopes: key.Scopes})
+			return next(ctx)
+		}
+	}
+}
+
+// RequireScope rejects requests unless principal is admin or API key with scope. Anonymous requests are passed,
+// they reach it only if API key isn't required(see NewAPIKeyAuth).
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			p, ok := PrincipalFromContext(ctx)
+			if !ok {
+				return next(ctx)
+			}
+			if p.Type != entity.PrincipalAdmin && !p.HasScope(scope) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("%s scope is required", scope))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// PrincipalFromContext returns authenticated caller of the request.
+func PrincipalFromContext(ctx echo.Context) (entity.Principal, bool) {
+	p, ok := ctx.Get(principalContextKey).(entity.Principal)
+	return p, ok
+}
+
+// PrincipalName returns authenticated caller name of the request, empty for anonymous.
+func PrincipalName(ctx echo.Context) string {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return p.String()
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/usecase"
+)
+
+func TestNewAdminAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		header         string
+		expectedStatus int
+	}{
+		{name: "valid token", token: "secret", header: "Bearer secret", expectedStatus: http.StatusOK},
+		{name: "invalid token", token: "secret", header: "Bearer wrong", expectedStatus: http.StatusUnauthorized},
+		{name: "missing header", token: "secret", header: "", expectedStatus: http.StatusBadRequest},
+		{name: "admin API disabled", token: "", header: "Bearer ", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/", func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusOK)
+			}, NewAdminAuth(tt.token))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestNewAPIKeyAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		required       bool
+		mockSetup      func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "valid key with scope",
+			header: "ApiKey tt_0a1b2c3d_secret",
+			mockSetup: func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger) {
+				mockAuthenticator.EXPECT().Authenticate(gomock.Any(), "tt_0a1b2c3d_secret").
+					Return(entity.APIKey{Prefix: "0a1b2c3d", Scopes: []string{entity.ScopeUsersRead}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "api_key:0a1b2c3d",
+		},
+		{
+			name:   "valid key without scope",
+			header: "ApiKey tt_0a1b2c3d_secret",
+			mockSetup: func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger) {
+				mockAuthenticator.EXPECT().Authenticate(gomock.Any(), "tt_0a1b2c3d_secret").
+					Return(entity.APIKey{Prefix: "0a1b2c3d", Scopes: []string{entity.ScopeUsersWrite}}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "invalid key",
+			header: "ApiKey tt_0a1b2c3d_wrong",
+			mockSetup: func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger) {
+				mockAuthenticator.EXPECT().Authenticate(gomock.Any(), "tt_0a1b2c3d_wrong").Return(entity.APIKey{}, usecase.ErrInvalidAPIKey)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "authenticator error",
+			header: "ApiKey tt_0a1b2c3d_secret",
+			mockSetup: func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger) {
+				mockAuthenticator.EXPECT().Authenticate(gomock.Any(), gomock.Any()).Return(entity.APIKey{}, errors.New("db error"))
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "anonymous request",
+			mockSetup:      func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "anonymous request when key is required",
+			header:         "Bearer token",
+			required:       true,
+			mockSetup:      func(mockAuthenticator *MockAPIKeyAuthenticator, mockLogger *logger.MockLogger) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockAuthenticator := NewMockAPIKeyAuthenticator(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockAuthenticator, mockLogger)
+
+			e := echo.New()
+			e.GET("/", func(ctx echo.Context) error {
+				p, _ := PrincipalFromContext(ctx)
+				if p.Type == "" {
+					return ctx.NoContent(http.StatusOK)
+				}
+				return ctx.String(http.StatusOK, string(p.Type)+":"+p.ID)
+			}, NewAPIKeyAuth(mockAuthenticator, tt.required, mockLogger), RequireScope(entity.ScopeUsersRead))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			ao.Equal(tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				ao.Equal(tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/pprof"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/controller/http/dto"
+	"test_task/internal/logger"
+	"test_task/internal/version"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=ops.go --destination=ops_mock.go --package=http
+
+// LevelSwitcher changes log level at runtime.
+type LevelSwitcher interface {
+	Level() logger.Level
+	Set(level logger.Level)
+}
+
+// Ops is responsible for handling operational requests of the admin listener.
+type Ops struct {
+	level  LevelSwitcher
+	logger logger.Logger
+}
+
+// NewOpsHandler creates new Ops handler.
+func NewOpsHandler(level LevelSwitcher, l logger.Logger) *Ops {
+	return &Ops{level: level, logger: l}
+}
+
+// Version returns build information.
+func (o *Ops) Version(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, version.Get())
+}
+
+// Goroutines dumps stacks of all goroutines.
+func (o *Ops) Goroutines(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	ctx.Response().WriteHeader(http.StatusOK)
+	return pprof.Lookup("goroutine").WriteTo(ctx.Response(), 2)
+}
+
+// LogLevel returns current log level.
+func (o *Ops) LogLevel(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, dto.LogLevelResponse{Level: o.level.Level().String()})
+}
+
+// SetLogLevel changes log level.
+func (o *Ops) SetLogLevel(ctx echo.Context) error {
+	var req dto.LogLevelRequest
+	err := ctx.Bind(&req)
+	if err != nil {
+		requestLogger(o.logger, ctx, "set log level").Error(fmt.Errorf("bind: %w", err))
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
+	}
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil || req.Level == "" {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("unknown log level %q", req.Level)})
+	}
+
+	previous := o.level.Level()
+	o.level.Set(level)
+	requestLogger(o.logger, ctx, "set log level").
+		WithFields(logger.Fields{"previous_level": previous.String(), "level": level.String()}).
+		Warn("log level changed")
+	return ctx.JSON(http.StatusOK, dto.LogLevelResponse{Level: level.String()})
+}
@@ -0,0 +1,61 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ops.go
+
+// Package http is a generated GoMock package.
+package http
+
+import (
+	reflect "reflect"
+	logger "test_task/internal/logger"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLevelSwitcher is a mock of LevelSwitcher interface.
+type MockLevelSwitcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockLevelSwitcherMockRecorder
+}
+
+// MockLevelSwitcherMockRecorder is the mock recorder for MockLevelSwitcher.
+type MockLevelSwitcherMockRecorder struct {
+	mock *MockLevelSwitcher
+}
+
+// NewMockLevelSwitcher creates a new mock instance.
+func NewMockLevelSwitcher(ctrl *gomock.Controller) *MockLevelSwitcher {
+	mock := &MockLevelSwitcher{ctrl: ctrl}
+	mock.recorder = &MockLevelSwitcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLevelSwitcher) EXPECT() *MockLevelSwitcherMockRecorder {
+	return m.recorder
+}
+
+// Level mocks base method.
+func (m *MockLevelSwitcher) Level() logger.Level {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Level")
+	ret0, _ := ret[0].(logger.Level)
+	return ret0
+}
+
+// Level indicates an expected call of Level.
+func (mr *MockLevelSwitcherMockRecorder) Level() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Level", reflect.TypeOf((*MockLevelSwitcher)(nil).Level))
+}
+
+// Set mocks base method.
+func (m *MockLevelSwitcher) Set(level logger.Level) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Set", level)
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockLevelSwitcherMockRecorder) Set(level interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockLevelSwitcher)(nil).Set), level)
+}
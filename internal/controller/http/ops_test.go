@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/logger"
+	"test_task/internal/version"
+)
+
+func TestOps_SetLogLevel(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(level *MockLevelSwitcher, l *logger.MockLogger)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success",
+			body: `{"level":"debug"}`,
+			mockSetup: func(level *MockLevelSwitcher, l *logger.MockLogger) {
+				level.EXPECT().Level().Return(logger.InfoLevel)
+				level.EXPECT().Set(logger.DebugLevel)
+				l.EXPECT().WithFields(logger.Fields{"previous_level": "info", "level": "debug"}).Return(l)
+				l.EXPECT().Warn("log level changed")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"level":"debug"}`,
+		},
+		{
+			name:           "unknown level",
+			body:           `{"level":"verbose"}`,
+			mockSetup:      func(level *MockLevelSwitcher, l *logger.MockLogger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unknown log level \"verbose\""}`,
+		},
+		{
+			name:           "empty level",
+			body:           `{}`,
+			mockSetup:      func(level *MockLevelSwitcher, l *logger.MockLogger) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unknown log level \"\""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockLevel := NewMockLevelSwitcher(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(logger.Fields{logger.FieldOperation: "set log level"}).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockLevel, mockLogger)
+
+			req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			ao.NoError(NewOpsHandler(mockLevel, mockLogger).SetLogLevel(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.JSONEq(tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestOps_LogLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLevel := NewMockLevelSwitcher(ctrl)
+	mockLevel.EXPECT().Level().Return(logger.WarnLevel)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/log/level", nil), rec)
+
+	assert.NoError(t, NewOpsHandler(mockLevel, nil).LogLevel(c))
+	assert.JSONEq(t, `{"level":"warn"}`, rec.Body.String())
+}
+
+func TestInitOpsRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLevel := NewMockLevelSwitcher(ctrl)
+	e := echo.New()
+	InitOpsRoutes(e, OpsControllers{
+		Ops:         NewOpsHandler(mockLevel, nil),
+		Health:      NewHealthController(NewMockHealthReporter(ctrl)),
+		Metrics:     http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("metrics")) }),
+		MetricsPath: "/metrics",
+	}, NewAdminAuth("ops-token"))
+
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "liveness is public", path: LivenessPath, expectedStatus: http.StatusOK},
+		{name: "version requires token", path: "/version", expectedStatus: http.StatusBadRequest},
+		{name: "wrong token", path: "/version", token: "admin-token", expectedStatus: http.StatusUnauthorized},
+		{name: "version", path: "/version", token: "ops-token", expectedStatus: http.StatusOK, expectedBody: version.Version},
+		{name: "metrics", path: "/metrics", token: "ops-token", expectedStatus: http.StatusOK, expectedBody: "metrics"},
+		{name: "metrics require token", path: "/metrics", expectedStatus: http.StatusBadRequest},
+		{name: "goroutines", path: "/debug/goroutines", token: "ops-token", expectedStatus: http.StatusOK, expectedBody: "goroutine"},
+		{name: "pprof index", path: "/debug/pprof/", token: "ops-token", expectedStatus: http.StatusOK, expectedBody: "heap"},
+		{name: "pprof profile", path: "/debug/pprof/heap?debug=1", token: "ops-token", expectedStatus: http.StatusOK, expectedBody: "heap profile"},
+		{name: "pprof requires token", path: "/debug/pprof/heap", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.expectedBody)
+		})
+	}
+
+	var info version.Info
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer ops-token")
+	e.ServeHTTP(rec, req)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.NotEmpty(t, info.GoVersion)
+}
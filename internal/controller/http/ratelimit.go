@@ -0,0 +1,96 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+	"test_task/internal/ratelimit"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+)
+
+// RateLimit limits requests per route group. Client is identified by client IP before authentication(Group)
+// and by verified principal after it(Principal), so a key used from many IPs has its own budget too.
+type RateLimit struct {
+	store  ratelimit.Store
+	limits map[string]config.RateLimit
+	logger logger.Logger
+}
+
+// NewRateLimit creates new instance of RateLimit.
+func NewRateLimit(store ratelimit.Store, limits map[string]config.RateLimit, l logger.Logger) *RateLimit {
+	return &RateLimit{store: store, limits: limits, logger: l}
+}
+
+// Group returns middleware for route group, which limits clients by IP. It must be applied before authentication
+// middlewares, so requests with invalid credentials are limited too.
+// Group without configured limit isn't limited. Store failure doesn't reject request.
+func (r *RateLimit) Group(name string) echo.MiddlewareFunc {
+	return r.limit(name, func(ctx echo.Context) (string, bool) {
+		return "ip:" + ctx.RealIP(), true
+	})
+}
+
+// Principal returns middleware for route group, which limits authenticated principal(API key, admin).
+// It must be applied after authentication middlewares, anonymous requests are limited by Group only.
+func (r *RateLimit) Principal(name string) echo.MiddlewareFunc {
+	return r.limit(name, func(ctx echo.Context) (string, bool) {
+		p, ok := PrincipalFromContext(ctx)
+		return string(p.Type) + ":" + p.ID, ok
+	})
+}
+
+// limit returns middleware which takes token of the group for client identity, request without identity isn't limited.
+func (r *RateLimit) limit(name string, identity func(ctx echo.Context) (string, bool)) echo.MiddlewareFunc {
+	var (
+		cfg config.RateLimit
+		ok  bool
+	)
+	if r != nil {
+		cfg, ok = r.limits[name]
+	}
+	if !ok || cfg.Rate <= 0 || cfg.Burst <= 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+	limit := ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			id, ok := identity(ctx)
+			if !ok {
+				return next(ctx)
+			}
+			res, err := r.store.Take(ctx.Request().Context(), name+"|"+id, limit)
+			if err != nil {
+				requestLogger(r.logger, ctx, "rate limit").WithFields(logger.Fields{"group": name}).Error(err)
+				return next(ctx)
+			}
+
+			h := ctx.Response().Header()
+			h.Set(headerRateLimitLimit, strconv.Itoa(limit.Burst))
+			h.Set(headerRateLimitRemaining, strconv.Itoa(res.Remaining))
+			h.Set(headerRateLimitReset, ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set(echo.HeaderRetryAfter, ceilSeconds(res.RetryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/ratelimit"
+)
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store error")
+}
+
+func TestRateLimit_Group(t *testing.T) {
+	ao := assert.New(t)
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, ctx echo.Context) {
+		var he *echo.HTTPError
+		if errors.As(err, &he) {
+			_ = ctx.NoContent(he.Code)
+		}
+	}
+	rl := NewRateLimit(ratelimit.NewMemoryStore(), map[string]config.RateLimit{"users": {Rate: 0.001, Burst: 2}}, nil)
+	withPrincipal := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if key := ctx.Request().Header.Get("X-Test-Key"); key != "" {
+				ctx.Set(principalContextKey, entity.Principal{Type: entity.PrincipalAPIKey, ID: key})
+			}
+			return next(ctx)
+		}
+	}
+	ok := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}
+	e.GET("/users", ok, rl.Group("users"), withPrincipal, rl.Principal("users"))
+	e.GET("/other", ok, rl.Group("other"), withPrincipal, rl.Principal("other"))
+
+	do := func(path, ip, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		if key != "" {
+			req.Header.Set("X-Test-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/users", "10.0.0.1", "")
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Equal("2", rec.Header().Get("RateLimit-Limit"))
+	ao.Equal("1", rec.Header().Get("RateLimit-Remaining"))
+	ao.Equal(http.StatusOK, do("/users", "10.0.0.1", "").Code)
+
+	rec = do("/users", "10.0.0.1", "")
+	ao.Equal(http.StatusTooManyRequests, rec.Code)
+	ao.Equal("0", rec.Header().Get("RateLimit-Remaining"))
+	ao.Equal("1000", rec.Header().Get("Retry-After"))
+	// IP is limited before authentication, so the principal doesn't get own budget on the same IP.
+	ao.Equal(http.StatusTooManyRequests, do("/users", "10.0.0.1", "0a1b2c3d").Code)
+
+	// principal is limited across IPs.
+	ao.Equal(http.StatusOK, do("/users", "10.0.0.2", "0a1b2c3d").Code)
+	ao.Equal(http.StatusOK, do("/users", "10.0.0.3", "0a1b2c3d").Code)
+	rec = do("/users", "10.0.0.4", "0a1b2c3d")
+	ao.Equal(http.StatusTooManyRequests, rec.Code)
+	ao.Equal("0", rec.Header().Get("RateLimit-Remaining"))
+	// other IP has its own budget.
+	ao.Equal(http.StatusOK, do("/users", "10.0.0.5", "").Code)
+	// group without limit.
+	rec = do("/other", "10.0.0.1", "")
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Empty(rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimit_GroupStoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any())
+
+	e := echo.New()
+	rl := NewRateLimit(failingRateLimitStore{}, map[string]config.RateLimit{"users": {Rate: 1, Burst: 1}}, mockLogger)
+	e.GET("/", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}, rl.Group("users"))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
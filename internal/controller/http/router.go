@@ -1,38 +1,115 @@
 package http
 
 import (
+	"net/http"
+	"net/http/pprof"
+
 	"github.com/labstack/echo/v4"
+
+	"test_task/internal/entity"
 )
 
 const (
 	// APIv1 initial API group.
 	APIv1 = "api/v1/"
+	// LivenessPath and ReadinessPath are probes of orchestrator.
+	LivenessPath  = "/livez"
+	ReadinessPath = "/readyz"
 
 	usersGroupName = "users"
+	adminGroupName = "admin"
+	loginGroupName = "login"
 )
 
 // Controllers combines all handlers in one struct for a following routing.
 type Controllers struct {
 	User             *User
+	Auth             *Auth
+	APIKey           *APIKey
 	HealthController *Health
+	// DeadLetter is nil if dead letters are disabled.
+	DeadLetter *DeadLetter
 }
 
 // InitRoutes initializes all service routes.
-func InitRoutes(e *echo.Echo, handlers Controllers) {
+func InitRoutes(e *echo.Echo, handlers Controllers, mw Middlewares) {
 	apiV1Group := e.Group(APIv1)
 
 	// init service routes
-	apiV1Group.GET("health", handlers.HealthController.View)
+	e.GET(LivenessPath, handlers.HealthController.Live)
+	e.GET(ReadinessPath, handlers.HealthController.Ready)
+	apiV1Group.GET("health", handlers.HealthController.Ready)
+	apiV1Group.GET("schemas/user-event/v1", UserEventSchema)
 
 	// init API
-	NewUserRoutes(apiV1Group, handlers.User)
+	NewUserRoutes(apiV1Group, handlers.User, handlers.Auth, mw)
+	NewAdminRoutes(apiV1Group, handlers.Auth, handlers.APIKey, mw)
+	if handlers.DeadLetter != nil {
+		NewDeadLetterRoutes(apiV1Group, handlers.DeadLetter, mw)
+	}
 }
 
 // NewUserRoutes registers routes for user entity.
-func NewUserRoutes(e *echo.Group, h *User) {
+func NewUserRoutes(e *echo.Group, h *User, a *Auth, mw Middlewares) {
+	rateLimit, principalRateLimit := mw.RateLimit.Group(usersGroupName), mw.RateLimit.Principal(usersGroupName)
+	read := []echo.MiddlewareFunc{rateLimit, mw.APIKeyAuth, principalRateLimit, RequireScope(entity.ScopeUsersRead)}
+	write := []echo.MiddlewareFunc{rateLimit, mw.APIKeyAuth, principalRateLimit, RequireScope(entity.ScopeUsersWrite)}
+
 	userGroup := e.Group(usersGroupName)
-	userGroup.POST("", h.Create)
-	userGroup.GET("", h.List)
-	userGroup.PUT("/:id", h.Update)
-	userGroup.DELETE("/:id", h.Delete)
+	userGroup.POST("/login", a.Login, mw.RateLimit.Group(loginGroupName))
+	userGroup.POST("", h.Create, write...)
+	userGroup.GET("", h.List, read...)
+	userGroup.PUT("/:id", h.Update, write...)
+	userGroup.PUT("/:id/password", h.ChangePassword, write...)
+	userGroup.DELETE("/:id", h.Delete, write...)
+}
+
+// NewAdminRoutes registers administrative routes.
+func NewAdminRoutes(e *echo.Group, a *Auth, k *APIKey, mw Middlewares) {
+	adminGroup := e.Group(adminGroupName, mw.RateLimit.Group(adminGroupName), mw.AdminAuth)
+	adminGroup.POST("/lockouts/unlock", a.Unlock)
+	adminGroup.POST("/api-keys", k.Issue)
+	adminGroup.GET("/api-keys", k.List)
+	adminGroup.DELETE("/api-keys/:id", k.Revoke)
+}
+
+// NewDeadLetterRoutes registers administrative routes of undelivered notifications.
+func NewDeadLetterRoutes(e *echo.Group, d *DeadLetter, mw Middlewares) {
+	deadLetterGroup := e.Group(adminGroupName+"/dead-letters", mw.RateLimit.Group(adminGroupName), mw.AdminAuth)
+	deadLetterGroup.GET("", d.List)
+	deadLetterGroup.DELETE("", d.Purge)
+	deadLetterGroup.POST("/replay", d.ReplayAll)
+	deadLetterGroup.GET("/:id", d.Get)
+	deadLetterGroup.DELETE("/:id", d.Delete)
+	deadLetterGroup.POST("/:id/replay", d.Replay)
+}
+
+// OpsControllers combines handlers of the admin listener.
+type OpsControllers struct {
+	Ops    *Ops
+	Health *Health
+	// Metrics is served on MetricsPath, nil handler disables it.
+	Metrics     http.Handler
+	MetricsPath string
+}
+
+// InitOpsRoutes initializes routes of the admin listener. Probes are public, other routes require auth.
+func InitOpsRoutes(e *echo.Echo, handlers OpsControllers, auth echo.MiddlewareFunc) {
+	e.GET(LivenessPath, handlers.Health.Live)
+	e.GET(ReadinessPath, handlers.Health.Ready)
+	if handlers.Metrics != nil {
+		e.GET(handlers.MetricsPath, echo.WrapHandler(handlers.Metrics), auth)
+	}
+
+	e.GET("/version", handlers.Ops.Version, auth)
+	e.GET("/log/level", handlers.Ops.LogLevel, auth)
+	e.PUT("/log/level", handlers.Ops.SetLogLevel, auth)
+	e.GET("/debug/goroutines", handlers.Ops.Goroutines, auth)
+
+	e.GET("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)), auth)
+	e.GET("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)), auth)
+	e.GET("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)), auth)
+	e.GET("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)), auth)
+	e.POST("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)), auth)
+	e.GET("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)), auth)
 }
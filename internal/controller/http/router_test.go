@@ -3,15 +3,22 @@ package http
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+	"test_task/internal/entity"
+	"test_task/internal/ratelimit"
+	"test_task/internal/usecase"
 )
 
 func TestInitRoutes(t *testing.T) {
 	e := echo.New()
-	InitRoutes(e, Controllers{User: nil})
+	InitRoutes(e, Controllers{User: nil}, Middlewares{AdminAuth: NewAdminAuth(""), APIKeyAuth: NewAPIKeyAuth(nil, false, nil)})
 	tests := []struct {
 		method string
 		path   string
@@ -36,6 +43,30 @@ func TestInitRoutes(t *testing.T) {
 			method: http.MethodGet,
 			path:   fmt.Sprintf(APIv1 + "health"),
 		},
+		{
+			method: http.MethodPost,
+			path:   fmt.Sprintf(APIv1 + "users/login"),
+		},
+		{
+			method: http.MethodPut,
+			path:   fmt.Sprintf(APIv1 + "users/:id/password"),
+		},
+		{
+			method: http.MethodPost,
+			path:   fmt.Sprintf(APIv1 + "admin/lockouts/unlock"),
+		},
+		{
+			method: http.MethodPost,
+			path:   fmt.Sprintf(APIv1 + "admin/api-keys"),
+		},
+		{
+			method: http.MethodGet,
+			path:   fmt.Sprintf(APIv1 + "admin/api-keys"),
+		},
+		{
+			method: http.MethodDelete,
+			path:   fmt.Sprintf(APIv1 + "admin/api-keys/:id"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
@@ -50,3 +81,63 @@ func TestInitRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestInitRoutes_DeadLetters(t *testing.T) {
+	routes := []string{
+		http.MethodGet + " /" + APIv1 + "admin/dead-letters",
+		http.MethodDelete + " /" + APIv1 + "admin/dead-letters",
+		http.MethodPost + " /" + APIv1 + "admin/dead-letters/replay",
+		http.MethodGet + " /" + APIv1 + "admin/dead-letters/:id",
+		http.MethodDelete + " /" + APIv1 + "admin/dead-letters/:id",
+		http.MethodPost + " /" + APIv1 + "admin/dead-letters/:id/replay",
+	}
+	registered := func(e *echo.Echo) map[string]bool {
+		res := map[string]bool{}
+		for _, r := range e.Routes() {
+			res[r.Method+" "+r.Path] = true
+		}
+		return res
+	}
+	mw := Middlewares{AdminAuth: NewAdminAuth(""), APIKeyAuth: NewAPIKeyAuth(nil, false, nil)}
+
+	e := echo.New()
+	InitRoutes(e, Controllers{DeadLetter: NewDeadLetterHandler(nil, nil)}, mw)
+	for _, route := range routes {
+		assert.True(t, registered(e)[route], "route %s should be registered", route)
+	}
+
+	e = echo.New()
+	InitRoutes(e, Controllers{}, mw)
+	for _, route := range routes {
+		assert.False(t, registered(e)[route], "route %s should not be registered without dead letters", route)
+	}
+}
+
+func TestInitRoutes_RateLimitBeforeAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAuthenticator := NewMockAPIKeyAuthenticator(ctrl)
+	// the second attempt is rejected by the limit of the IP without authentication, though its key differs
+	mockAuthenticator.EXPECT().Authenticate(gomock.Any(), "tt_0a1b2c3d_wrong").Return(entity.APIKey{}, usecase.ErrInvalidAPIKey)
+
+	e := echo.New()
+	InitRoutes(e, Controllers{}, Middlewares{
+		AdminAuth:  NewAdminAuth("token"),
+		APIKeyAuth: NewAPIKeyAuth(mockAuthenticator, false, nil),
+		RateLimit: NewRateLimit(ratelimit.NewMemoryStore(), map[string]config.RateLimit{
+			usersGroupName: {Rate: 0.001, Burst: 1},
+			adminGroupName: {Rate: 0.001, Burst: 1},
+		}, nil),
+	})
+	do := func(method, path, authorization string) int {
+		req := httptest.NewRequest(method, "/"+APIv1+path, nil)
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "users", "ApiKey tt_0a1b2c3d_wrong"))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "users", "ApiKey tt_1b2c3d4e_wrong"))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "admin/lockouts/unlock", "Bearer wrong"))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "admin/lockouts/unlock", "Bearer wrong"))
+}
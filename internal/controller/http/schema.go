@@ -0,0 +1,17 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"test_task/internal/notificator"
+)
+
+// schemaContentType is media type of JSON Schema.
+const schemaContentType = "application/schema+json"
+
+// UserEventSchema serves JSON Schema of user notifications for consumers.
+func UserEventSchema(ctx echo.Context) error {
+	return ctx.Blob(http.StatusOK, schemaContentType, notificator.UserEventJSONSchema)
+}
@@ -0,0 +1,25 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/notificator"
+)
+
+func TestUserEventSchema(t *testing.T) {
+	ao := assert.New(t)
+	e := echo.New()
+	InitRoutes(e, Controllers{}, Middlewares{AdminAuth: NewAdminAuth(""), APIKeyAuth: NewAPIKeyAuth(nil, false, nil)})
+
+	// schema is public
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+APIv1+"schemas/user-event/v1", nil))
+	ao.Equal(http.StatusOK, rec.Code)
+	ao.Equal(schemaContentType, rec.Header().Get(echo.HeaderContentType))
+	ao.JSONEq(string(notificator.UserEventJSONSchema), rec.Body.String())
+}
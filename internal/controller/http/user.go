@@ -2,15 +2,19 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 
 	"test_task/internal/controller/http/dto"
+	"test_task/internal/datastore"
 	"test_task/internal/entity"
 	"test_task/internal/logger"
 	"test_task/internal/pagination"
+	"test_task/internal/password"
+	"test_task/internal/usecase"
 )
 
 //go:generate go run github.com/golang/mock/mockgen --source=user.go --destination=user_mock.go --package=http
@@ -21,6 +25,7 @@ type UserUseCase interface {
 	Update(ctx context.Context, user entity.User) (entity.User, error)
 	Delete(ctx context.Context, id string) error
 	GetList(ctx context.Context, filter entity.UserFilter) ([]entity.User, int64, error)
+	ChangePassword(ctx context.Context, id, oldPassword, newPassword, clientIP string) error
 }
 
 // User is responsible for handling any user-related requests.
@@ -38,12 +43,16 @@ func (u *User) Create(ctx echo.Context) error {
 	var req dto.UserCreateRequest
 	err := ctx.Bind(&req)
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user create: bind: %w", err))
+		requestLogger(u.logger, ctx, "user create").Error(fmt.Errorf("bind: %w", err))
 		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
 	}
 	result, err := u.userService.Create(ctx.Request().Context(), dto.MapUserCreateRequestToEntity(req))
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		return passwordPolicyViolation(ctx, policyErr)
+	}
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user create: %w", err))
+		requestLogger(u.logger, ctx, "user create").Error(err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.MapUserToEntityUserResponse(result)})
@@ -54,12 +63,19 @@ func (u *User) Update(ctx echo.Context) error {
 	var req dto.UserUpdateRequest
 	err := ctx.Bind(&req)
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user update: bind: %w", err))
+		requestLogger(u.logger, ctx, "user update").Error(fmt.Errorf("bind: %w", err))
 		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
 	}
 	result, err := u.userService.Update(ctx.Request().Context(), dto.MapUserUpdateRequestToEntity(req))
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		return passwordPolicyViolation(ctx, policyErr)
+	}
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user update: %w", err))
+		requestLogger(u.logger, ctx, "user update").WithFields(logger.Fields{logger.FieldUserID: req.ID}).Error(err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 	return ctx.JSON(http.StatusOK, BaseResponse{Data: dto.MapUserToEntityUserResponse(result)})
@@ -69,8 +85,11 @@ func (u *User) Update(ctx echo.Context) error {
 func (u *User) Delete(ctx echo.Context) error {
 	id := ctx.Param("id")
 	err := u.userService.Delete(ctx.Request().Context(), id)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user delete: %w", err))
+		requestLogger(u.logger, ctx, "user delete").WithFields(logger.Fields{logger.FieldUserID: id}).Error(err)
 		return ctx.NoContent(http.StatusInternalServerError)
 	}
 	return ctx.NoContent(http.StatusOK)
@@ -80,12 +99,12 @@ func (u *User) List(ctx echo.Context) error {
 	var req dto.UserListRequest
 	err := ctx.Bind(&req)
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user list: bind: %w", err))
+		requestLogger(u.logger, ctx, "user list").Error(fmt.Errorf("bind: %w", err))
 		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
 	}
 	result, total, err := u.userService.GetList(ctx.Request().Context(), dto.MapUserListRequestToEntity(req))
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user list: %w", err))
+		requestLogger(u.logger, ctx, "user list").Error(err)
 		/* Just an example of how it can be, but according to YAGNI I can omit it .
 		if errors.Is(err, datastore.ErrNotFound)
 			return ErrNotFound
@@ -101,3 +120,39 @@ func (u *User) List(ctx echo.Context) error {
 		Data: dto.MapUsersToEntityUserListResponse(result),
 	})
 }
+
+func (u *User) ChangePassword(ctx echo.Context) error {
+	var req dto.ChangePasswordRequest
+	err := ctx.Bind(&req)
+	if err != nil {
+		requestLogger(u.logger, ctx, "user change password").Error(fmt.Errorf("bind: %w", err))
+		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": http.StatusText(http.StatusBadRequest)})
+	}
+
+	err = u.userService.ChangePassword(ctx.Request().Context(), req.ID, req.OldPassword, req.NewPassword, ctx.RealIP())
+	var (
+		policyErr *password.PolicyError
+		lockedErr *usecase.LockedError
+	)
+	switch {
+	case err == nil:
+		return ctx.NoContent(http.StatusOK)
+	case errors.As(err, &policyErr):
+		return passwordPolicyViolation(ctx, policyErr)
+	case errors.Is(err, usecase.ErrInvalidCredentials):
+		return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": http.StatusText(http.StatusUnauthorized)})
+	case errors.As(err, &lockedErr):
+		return lockedOut(ctx, lockedErr)
+	case errors.Is(err, datastore.ErrNotFound):
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+	}
+	requestLogger(u.logger, ctx, "user change password").WithFields(logger.Fields{logger.FieldUserID: req.ID}).Error(err)
+	return ctx.NoContent(http.StatusInternalServerError)
+}
+
+func passwordPolicyViolation(ctx echo.Context, err *password.PolicyError) error {
+	return ctx.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":   "password policy violation",
+		"reasons": err.Reasons,
+	})
+}
@@ -35,6 +35,20 @@ func (m *MockUserUseCase) EXPECT() *MockUserUseCaseMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUserUseCase) ChangePassword(ctx context.Context, id, oldPassword, newPassword, clientIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, id, oldPassword, newPassword, clientIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserUseCaseMockRecorder) ChangePassword(ctx, id, oldPassword, newPassword, clientIP interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserUseCase)(nil).ChangePassword), ctx, id, oldPassword, newPassword, clientIP)
+}
+
 // Create mocks base method.
 func (m *MockUserUseCase) Create(ctx context.Context, user entity.User) (entity.User, error) {
 	m.ctrl.T.Helper()
@@ -7,13 +7,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 
 	"test_task/internal/entity"
+	"test_task/internal/datastore"
 	"test_task/internal/logger"
+	"test_task/internal/password"
+	"test_task/internal/usecase"
 )
 
 func TestUser_Create(t *testing.T) {
@@ -53,12 +57,23 @@ func TestUser_Create(t *testing.T) {
 			expectedBody:   `{"error":"Bad Request"}` + "\n",
 			expectedErr:    nil,
 		},
+		{
+			name:        "failed creation due to password policy",
+			requestBody: `{"first_name":"John", "last_name":"Doe", "nickname":"jdoe", "password":"p", "email":"jdoe@example.com", "country":"USA"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(entity.User{}, &password.PolicyError{Reasons: []string{"must be at least 10 characters long"}})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":"password policy violation","reasons":["must be at least 10 characters long"]}` + "\n",
+			expectedErr:    nil,
+		},
 		{
 			name:        "failed creation due to service error",
 			requestBody: `{"first_name":"John", "last_name":"Doe", "nickname":"jdoe", "password":"password123", "email":"jdoe@example.com", "country":"USA"}`,
 			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
 				mockUserUseCase.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.User{}, errors.New("service error"))
-				mockLogger.EXPECT().Error(fmt.Errorf("user create: %w", errors.New("service error")))
+				mockLogger.EXPECT().Error(errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedErr:    nil,
@@ -72,6 +87,8 @@ func TestUser_Create(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockUserUseCase := NewMockUserUseCase(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
 
 			tt.mockSetup(mockUserUseCase, mockLogger)
 
@@ -129,12 +146,22 @@ func TestUser_Update(t *testing.T) {
 			expectedBody:   `{"error":"Bad Request"}` + "\n",
 			expectedErr:    nil,
 		},
+		{
+			name:        "user not found",
+			requestBody: `{"id":"1","first_name":"John"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().Update(gomock.Any(), gomock.Any()).Return(entity.User{}, fmt.Errorf("repo get user: %w", datastore.ErrNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Not Found"}` + "\n",
+			expectedErr:    nil,
+		},
 		{
 			name:        "failed update due to service error",
 			requestBody: `{"id":"1","first_name":"John","last_name":"Doe","nickname":"jdoe","password":"password123","email":"jdoe@example.com","country":"USA"}`,
 			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
 				mockUserUseCase.EXPECT().Update(gomock.Any(), gomock.Any()).Return(entity.User{}, errors.New("service error"))
-				mockLogger.EXPECT().Error(fmt.Errorf("user update: %w", errors.New("service error")))
+				mockLogger.EXPECT().Error(errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   ``,
@@ -148,6 +175,8 @@ func TestUser_Update(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockUserUseCase := NewMockUserUseCase(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
 
 			tt.mockSetup(mockUserUseCase, mockLogger)
 
@@ -187,6 +216,16 @@ func TestUser_Delete(t *testing.T) {
 			expectedBody:   ``,
 			expectedErr:    nil,
 		},
+		{
+			name:    "user not found",
+			paramID: "1",
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().Delete(gomock.Any(), "1").Return(fmt.Errorf("repo get user: %w", datastore.ErrNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Not Found"}` + "\n",
+			expectedErr:    nil,
+		},
 		{
 			name:    "failed deletion due to service error",
 			paramID: "1",
@@ -206,6 +245,8 @@ func TestUser_Delete(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockUserUseCase := NewMockUserUseCase(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
 
 			tt.mockSetup(mockUserUseCase, mockLogger)
 
@@ -284,6 +325,8 @@ func TestUser_List(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockUserUseCase := NewMockUserUseCase(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
 
 			tt.mockSetup(mockUserUseCase, mockLogger)
 
@@ -303,3 +346,104 @@ func TestUser_List(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "successful change",
+			requestBody: `{"old_password":"Old-pass1","new_password":"New-pass1"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().ChangePassword(gomock.Any(), "1", "Old-pass1", "New-pass1", "192.0.2.1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "bind error",
+			requestBody: `invalid json`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Bad Request"}` + "\n",
+		},
+		{
+			name:        "password policy violation",
+			requestBody: `{"old_password":"Old-pass1","new_password":"jdoe"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().ChangePassword(gomock.Any(), "1", "Old-pass1", "jdoe", gomock.Any()).
+					Return(&password.PolicyError{Reasons: []string{"must contain a digit", "must not contain the nickname"}})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"error":"password policy violation","reasons":["must contain a digit","must not contain the nickname"]}` + "\n",
+		},
+		{
+			name:        "wrong old password",
+			requestBody: `{"old_password":"wrong","new_password":"New-pass1"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().ChangePassword(gomock.Any(), "1", "wrong", "New-pass1", gomock.Any()).Return(usecase.ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Unauthorized"}` + "\n",
+		},
+		{
+			name:        "locked out",
+			requestBody: `{"old_password":"wrong","new_password":"New-pass1"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().ChangePassword(gomock.Any(), "1", "wrong", "New-pass1", gomock.Any()).
+					Return(&usecase.LockedError{Until: time.Now().Add(time.Minute)})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `{"error":"Too Many Requests"}` + "\n",
+		},
+		{
+			name:        "user not found",
+			requestBody: `{"old_password":"Old-pass1","new_password":"New-pass1"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().ChangePassword(gomock.Any(), "1", gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("repo get user: %w", datastore.ErrNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"Not Found"}` + "\n",
+		},
+		{
+			name:        "service error",
+			requestBody: `{"old_password":"Old-pass1","new_password":"New-pass1"}`,
+			mockSetup: func(mockUserUseCase *MockUserUseCase, mockLogger *logger.MockLogger) {
+				mockUserUseCase.EXPECT().ChangePassword(gomock.Any(), "1", gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("service error"))
+				mockLogger.EXPECT().Error(gomock.Any())
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockUserUseCase := NewMockUserUseCase(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockUserUseCase, mockLogger)
+
+			e := echo.New()
+			handler := NewUserHandler(mockUserUseCase, mockLogger)
+			req := httptest.NewRequest(http.MethodPut, "/", bytes.NewReader([]byte(tt.requestBody)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			ao.NoError(handler.ChangePassword(c))
+			ao.Equal(tt.expectedStatus, rec.Code)
+			ao.Equal(tt.expectedBody, rec.Body.String())
+		})
+	}
+}
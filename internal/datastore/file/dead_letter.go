@@ -0,0 +1,213 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/pagination"
+)
+
+const deadLetterExt = ".json"
+
+// deadLetter is JSON document of one dead letter.
+type deadLetter struct {
+	ID        string            `json:"id"`
+	Key       string            `json:"key"`
+	Type      string            `json:"type"`
+	Payload   json.RawMessage   `json:"payload"`
+	Attempts  []deliveryAttempt `json:"attempts"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type deliveryAttempt struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error"`
+}
+
+// DeadLetterStore keeps every dead letter in its own file <id>.json in the directory,
+// so it doesn't require a database and survives restarts.
+type DeadLetterStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDeadLetterStore creates the directory if it doesn't exist.
+func NewDeadLetterStore(dir string) (*DeadLetterStore, error) {
+	if dir == "" {
+		return nil, errors.New("no path")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	return &DeadLetterStore{dir: dir}, nil
+}
+
+// Add writes dead letter to a temporary file and renames it, so partially written letter is never read.
+func (s *DeadLetterStore) Add(_ context.Context, letter entity.DeadLetter) (entity.DeadLetter, error) {
+	letter.ID = uuid.NewString()
+	data, err := json.Marshal(mapEntityDeadLetter(letter))
+	if err != nil {
+		return entity.DeadLetter{}, fmt.Errorf("marshal: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return entity.DeadLetter{}, fmt.Errorf("create: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return entity.DeadLetter{}, fmt.Errorf("write: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path(letter.ID)); err != nil {
+		return entity.DeadLetter{}, fmt.Errorf("rename: %w", err)
+	}
+	return letter, nil
+}
+
+// List returns page of dead letters, the oldest first.
+func (s *DeadLetterStore) List(_ context.Context, page pagination.Pagination) ([]entity.DeadLetter, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read directory: %w", err)
+	}
+	letters := make([]entity.DeadLetter, 0, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), deadLetterExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		letter, err := s.read(id)
+		if err != nil {
+			return nil, 0, err
+		}
+		letters = append(letters, letter)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if !letters[i].CreatedAt.Equal(letters[j].CreatedAt) {
+			return letters[i].CreatedAt.Before(letters[j].CreatedAt)
+		}
+		return letters[i].ID < letters[j].ID
+	})
+
+	total := int64(len(letters))
+	offset := min(pagination.CalculateOffset(page.Number, page.Size), len(letters))
+	letters = letters[offset:]
+	if page.Size > 0 && page.Size < len(letters) {
+		letters = letters[:page.Size]
+	}
+	return letters, total, nil
+}
+
+func (s *DeadLetterStore) Get(_ context.Context, id string) (entity.DeadLetter, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entity.DeadLetter{}, datastore.ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(id)
+}
+
+// Delete removes dead letter. Returns datastore.ErrNotFound if there is no dead letter with id.
+func (s *DeadLetterStore) Delete(_ context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return datastore.ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return datastore.ErrNotFound
+	}
+	return err
+}
+
+// Purge removes all dead letters.
+func (s *DeadLetterStore) Purge(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("read directory: %w", err)
+	}
+	var deleted int64
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), deadLetterExt) || e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *DeadLetterStore) read(id string) (entity.DeadLetter, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return entity.DeadLetter{}, datastore.ErrNotFound
+	}
+	if err != nil {
+		return entity.DeadLetter{}, err
+	}
+	var letter deadLetter
+	if err = json.Unmarshal(data, &letter); err != nil {
+		return entity.DeadLetter{}, fmt.Errorf("unmarshal %s: %w", id, err)
+	}
+	return mapDeadLetterEntity(letter), nil
+}
+
+func (s *DeadLetterStore) path(id string) string {
+	return filepath.Join(s.dir, id+deadLetterExt)
+}
+
+func mapEntityDeadLetter(letter entity.DeadLetter) deadLetter {
+	attempts := make([]deliveryAttempt, 0, len(letter.Attempts))
+	for _, v := range letter.Attempts {
+		attempts = append(attempts, deliveryAttempt{At: v.At, Error: v.Error})
+	}
+	return deadLetter{
+		ID:        letter.ID,
+		Key:       letter.Key,
+		Type:      letter.Type,
+		Payload:   letter.Payload,
+		Attempts:  attempts,
+		CreatedAt: letter.CreatedAt,
+	}
+}
+
+func mapDeadLetterEntity(letter deadLetter) entity.DeadLetter {
+	attempts := make([]entity.DeliveryAttempt, 0, len(letter.Attempts))
+	for _, v := range letter.Attempts {
+		attempts = append(attempts, entity.DeliveryAttempt{At: v.At, Error: v.Error})
+	}
+	return entity.DeadLetter{
+		ID:        letter.ID,
+		Key:       letter.Key,
+		Type:      letter.Type,
+		Payload:   letter.Payload,
+		Attempts:  attempts,
+		CreatedAt: letter.CreatedAt,
+	}
+}
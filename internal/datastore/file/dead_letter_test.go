@@ -0,0 +1,83 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/pagination"
+)
+
+func TestNewDeadLetterStore(t *testing.T) {
+	ao := assert.New(t)
+	_, err := NewDeadLetterStore("")
+	ao.EqualError(err, "no path")
+
+	dir := filepath.Join(t.TempDir(), "dead-letters")
+	_, err = NewDeadLetterStore(dir)
+	ao.NoError(err)
+	ao.DirExists(dir)
+}
+
+func TestDeadLetterStore(t *testing.T) {
+	ao := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewDeadLetterStore(dir)
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var added []entity.DeadLetter
+	for i, key := range []string{"3", "1", "2"} {
+		letter, err := s.Add(ctx, entity.DeadLetter{
+			Key:       key,
+			Type:      "Update",
+			Payload:   []byte(`{"Type":"Update","Key":"` + key + `"}`),
+			Attempts:  []entity.DeliveryAttempt{{At: now, Error: "push: timeout"}},
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+		ao.NotEmpty(letter.ID)
+		added = append(added, letter)
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	ao.Len(entries, 3)
+
+	got, err := s.Get(ctx, added[1].ID)
+	ao.NoError(err)
+	ao.Equal(added[1], got)
+	_, err = s.Get(ctx, "../file")
+	ao.ErrorIs(err, datastore.ErrNotFound)
+
+	letters, total, err := s.List(ctx, pagination.Pagination{Number: 2, Size: 2})
+	ao.NoError(err)
+	ao.Equal(int64(3), total)
+	ao.Equal([]entity.DeadLetter{added[2]}, letters)
+	letters, _, err = s.List(ctx, pagination.Pagination{})
+	ao.NoError(err)
+	ao.Equal(added, letters)
+	letters, _, err = s.List(ctx, pagination.Pagination{Number: 3, Size: 2})
+	ao.NoError(err)
+	ao.Empty(letters)
+
+	ao.NoError(s.Delete(ctx, added[0].ID))
+	ao.ErrorIs(s.Delete(ctx, added[0].ID), datastore.ErrNotFound)
+	_, err = s.Get(ctx, added[0].ID)
+	ao.ErrorIs(err, datastore.ErrNotFound)
+
+	deleted, err := s.Purge(ctx)
+	ao.NoError(err)
+	ao.Equal(int64(2), deleted)
+	letters, total, err = s.List(ctx, pagination.Pagination{})
+	ao.NoError(err)
+	ao.Empty(letters)
+	ao.Zero(total)
+}
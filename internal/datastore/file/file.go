@@ -0,0 +1,160 @@
+// Package file implements notification sinks writing newline delimited JSON to stdout or rotated files
+// the dead letter store keeping undelivered notifications in a directory, the spill queue of notifications
+// which didn't fit the buffer and the durable queue replacing the buffer.
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"test_task/internal/config"
+)
+
+const (
+	DefaultMaxSizeMB  = 100
+	DefaultMaxBackups = 5
+)
+
+// Writer writes every notification as a line to w.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Push(_ context.Context, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.w.Write(line(data))
+	return err
+}
+
+func (w *Writer) Health(_ context.Context) error {
+	return nil
+}
+
+// RotatingFile writes every notification as a line to the file. The file is renamed to <path>.1 when the next line
+// would exceed max size, previous backups are shifted and the oldest one over max backups is removed.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// err is the last write error, it is reported by Health until the next successful write
+	err error
+}
+
+// NewRotatingFile opens or creates the file for appending, zero limits are replaced with defaults.
+func NewRotatingFile(cfg config.FileSink) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("no path")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = DefaultMaxSizeMB
+	}
+	if cfg.MaxBackups <= 0 {
+		cfg.MaxBackups = DefaultMaxBackups
+	}
+	f := &RotatingFile{path: cfg.Path, maxSize: int64(cfg.MaxSizeMB) << 20, maxBackups: cfg.MaxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) Push(_ context.Context, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = f.write(line(data))
+	return f.err
+}
+
+func (f *RotatingFile) write(data []byte) error {
+	if f.file == nil {
+		// previous rotation failed
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// Health reports the last write error.
+func (f *RotatingFile) Health(_ context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	f.file = nil
+	if err := os.Remove(backup(f.path, f.maxBackups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove oldest backup: %w", err)
+	}
+	for i := f.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backup(f.path, i), backup(f.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("shift backup: %w", err)
+		}
+	}
+	if err := os.Rename(f.path, backup(f.path, 1)); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	return f.open()
+}
+
+func backup(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+func line(data []byte) []byte {
+	return append(data[:len(data):len(data)], '\n')
+}
@@ -0,0 +1,78 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/config"
+)
+
+func TestWriter_Push(t *testing.T) {
+	ao := assert.New(t)
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	data := []byte(`{"Type":"Insert"}`)
+	ao.NoError(w.Push(context.Background(), data))
+	ao.NoError(w.Push(context.Background(), []byte(`{"Type":"Delete"}`)))
+	ao.Equal("{\"Type\":\"Insert\"}\n{\"Type\":\"Delete\"}\n", buf.String())
+	ao.Equal(`{"Type":"Insert"}`, string(data))
+	ao.NoError(w.Health(context.Background()))
+}
+
+func TestNewRotatingFile(t *testing.T) {
+	ao := assert.New(t)
+	_, err := NewRotatingFile(config.FileSink{})
+	ao.EqualError(err, "no path")
+
+	_, err = NewRotatingFile(config.FileSink{Path: filepath.Join(t.TempDir(), "missing", "users.ndjson")})
+	ao.ErrorIs(err, os.ErrNotExist)
+
+	f, err := NewRotatingFile(config.FileSink{Path: filepath.Join(t.TempDir(), "users.ndjson")})
+	require.NoError(t, err)
+	defer f.Close()
+	ao.Equal(int64(DefaultMaxSizeMB)<<20, f.maxSize)
+	ao.Equal(DefaultMaxBackups, f.maxBackups)
+}
+
+func TestRotatingFile_Push(t *testing.T) {
+	ao := assert.New(t)
+	path := filepath.Join(t.TempDir(), "users.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("0000\n"), 0o644))
+	f, err := NewRotatingFile(config.FileSink{Path: path, MaxBackups: 2})
+	require.NoError(t, err)
+	// 3 lines per file
+	f.maxSize = 15
+
+	for _, line := range []string{"1111", "2222", "3333", "4444", "5555", "6666", "7777", "8888", "9999"} {
+		ao.NoError(f.Push(context.Background(), []byte(line)))
+	}
+	ao.NoError(f.Health(context.Background()))
+	ao.NoError(f.Close())
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.ReplaceAll(string(data), "\n", " ")
+	}
+	ao.Equal("9999 ", read(path))
+	ao.Equal("6666 7777 8888 ", read(path+".1"))
+	ao.Equal("3333 4444 5555 ", read(path+".2"))
+	ao.NoFileExists(path + ".3")
+}
+
+func TestRotatingFile_HealthReportsWriteError(t *testing.T) {
+	ao := assert.New(t)
+	f, err := NewRotatingFile(config.FileSink{Path: filepath.Join(t.TempDir(), "users.ndjson")})
+	require.NoError(t, err)
+	require.NoError(t, f.file.Close())
+
+	ao.Error(f.Push(context.Background(), []byte("data")))
+	ao.Error(f.Health(context.Background()))
+}
@@ -0,0 +1,570 @@
+package file
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"test_task/internal/config"
+)
+
+const (
+	DefaultSegmentSizeMB  = 16
+	DefaultMaxQueueSizeMB = 1024
+	DefaultFsyncInterval  = time.Second
+)
+
+// Fsync policies of Queue.
+const (
+	FsyncAlways   = "always"
+	FsyncInterval = "interval"
+	FsyncNever    = "never"
+)
+
+const (
+	segmentExt     = ".log"
+	checkpointName = "checkpoint"
+	// recordHeaderSize is size of data length and CRC32 of data preceding every record
+	recordHeaderSize = 8
+)
+
+var (
+	errQueueFull     = errors.New("queue is full")
+	errCorruptRecord = errors.New("corrupt record")
+)
+
+// segment is file of records starting with offset base.
+type segment struct {
+	base  uint64
+	file  *os.File
+	size  int64
+	count uint64
+}
+
+// Queue is durable FIFO queue of notifications kept in append-only log of segment files <base offset>.log in the directory.
+// Record is data length and CRC32 of data followed by data, offset is sequence number of the record in the log.
+// Acknowledged offsets advance the checkpoint over their contiguous prefix, so workers may acknowledge out of order.
+// The checkpoint is saved to the checkpoint file, segments below it are removed. After restart records are read
+// from the saved checkpoint, so notifications pushed after it was saved are pushed again.
+// Read is called by one consumer.
+type Queue struct {
+	dir           string
+	segmentSize   int64
+	maxSize       int64
+	fsync         string
+	fsyncInterval time.Duration
+
+	mu       sync.Mutex
+	segments []*segment
+	size     int64
+	// next is offset of the next appended record
+	next uint64
+	// unsynced is set by append and reset by sync of the last segment
+	unsynced bool
+
+	readSegment int
+	readPos     int64
+	readOffset  uint64
+
+	checkpoint uint64
+	saved      uint64
+	acked      map[uint64]struct{}
+	// err is the last error of background sync, it is reported by Health until the next successful sync
+	err error
+
+	appended chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewQueue opens the log in the directory or creates it, partially written last record is cut off.
+// Reading starts from the saved checkpoint. Zero values of cfg are replaced with defaults.
+func NewQueue(cfg config.NotificationQueue) (*Queue, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("no path")
+	}
+	if cfg.SegmentSizeMB <= 0 {
+		cfg.SegmentSizeMB = DefaultSegmentSizeMB
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = DefaultMaxQueueSizeMB
+	}
+	if cfg.FsyncInterval <= 0 {
+		cfg.FsyncInterval = DefaultFsyncInterval
+	}
+	switch cfg.Fsync {
+	case "":
+		cfg.Fsync = FsyncInterval
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("unknown fsync policy %q", cfg.Fsync)
+	}
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	q := &Queue{
+		dir:           cfg.Path,
+		segmentSize:   int64(cfg.SegmentSizeMB) << 20,
+		maxSize:       int64(cfg.MaxSizeMB) << 20,
+		fsync:         cfg.Fsync,
+		fsyncInterval: cfg.FsyncInterval,
+		acked:         map[uint64]struct{}{},
+		appended:      make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	if err := q.open(); err != nil {
+		q.closeSegments()
+		return nil, err
+	}
+	go q.syncLoop()
+	return q, nil
+}
+
+// open loads checkpoint and segments and positions reading at the checkpoint.
+func (q *Queue) open() error {
+	data, err := os.ReadFile(filepath.Join(q.dir, checkpointName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read checkpoint: %w", err)
+	}
+	if err == nil {
+		if q.checkpoint, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err != nil {
+			return fmt.Errorf("parse checkpoint: %w", err)
+		}
+	}
+	bases, err := q.segmentBases()
+	if err != nil {
+		return err
+	}
+	for i, base := range bases {
+		f, err := os.OpenFile(q.segmentPath(base), os.O_RDWR, 0o644)
+		if err != nil {
+			return fmt.Errorf("open segment: %w", err)
+		}
+		seg := &segment{base: base, file: f}
+		q.segments = append(q.segments, seg)
+		if i < len(bases)-1 {
+			// earlier segments are complete, they were synced when the next one was created
+			info, err := f.Stat()
+			if err != nil {
+				return fmt.Errorf("stat segment: %w", err)
+			}
+			seg.size, seg.count = info.Size(), bases[i+1]-base
+		} else if err = q.recover(seg); err != nil {
+			return err
+		}
+		q.size += seg.size
+	}
+	if len(q.segments) == 0 {
+		if err = q.createSegment(q.checkpoint); err != nil {
+			return err
+		}
+	}
+	last := q.segments[len(q.segments)-1]
+	q.next = last.base + last.count
+	q.checkpoint = min(max(q.checkpoint, q.segments[0].base), q.next)
+	q.saved = q.checkpoint
+	if err = q.seek(q.checkpoint); err != nil {
+		return err
+	}
+	return q.compact()
+}
+
+// segmentBases returns sorted base offsets of segment files.
+func (q *Queue) segmentBases() ([]uint64, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+	var bases []uint64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		base, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases, nil
+}
+
+// recover counts records of the last segment and truncates partially written record at the end.
+// Corrupt record inside the segment is counted, it is skipped by Read.
+func (q *Queue) recover(seg *segment) error {
+	info, err := seg.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat segment: %w", err)
+	}
+	for {
+		data, err := readRecord(seg.file, seg.size, info.Size())
+		length := int64(len(data))
+		if errors.Is(err, errCorruptRecord) {
+			// record at the end is partially written
+			if data == nil {
+				break
+			}
+			length = int64(binary.BigEndian.Uint32(data))
+			if seg.size+recordHeaderSize+length == info.Size() {
+				break
+			}
+		} else if err != nil {
+			return fmt.Errorf("recover segment: %w", err)
+		}
+		seg.size += recordHeaderSize + length
+		seg.count++
+	}
+	if seg.size < info.Size() {
+		if err = seg.file.Truncate(seg.size); err != nil {
+			return fmt.Errorf("truncate segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// seek positions reading at offset.
+func (q *Queue) seek(offset uint64) error {
+	q.readSegment = sort.Search(len(q.segments), func(i int) bool { return q.segments[i].base > offset }) - 1
+	seg := q.segments[q.readSegment]
+	q.readPos, q.readOffset = 0, seg.base
+	for q.readOffset < offset {
+		data, err := readRecord(seg.file, q.readPos, seg.size)
+		if errors.Is(err, errCorruptRecord) {
+			if data == nil {
+				// the rest of torn segment is lost, read moves to the next segment
+				q.readPos, q.readOffset = seg.size, offset
+				return nil
+			}
+			data = make([]byte, binary.BigEndian.Uint32(data))
+		} else if err != nil {
+			return fmt.Errorf("seek to %d: %w", offset, err)
+		}
+		q.readPos += recordHeaderSize + int64(len(data))
+		q.readOffset++
+	}
+	return nil
+}
+
+// Append writes notification to the end of the log, it is synced at once with FsyncAlways.
+func (q *Queue) Append(data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(data))
+	copy(record[recordHeaderSize:], data)
+	if q.size+int64(len(record)) > q.maxSize {
+		return errQueueFull
+	}
+	last := q.segments[len(q.segments)-1]
+	if last.size > 0 && last.size+int64(len(record)) > q.segmentSize {
+		if err := q.roll(); err != nil {
+			return err
+		}
+		last = q.segments[len(q.segments)-1]
+	}
+	_, err := last.file.WriteAt(record, last.size)
+	if err == nil && q.fsync == FsyncAlways {
+		err = last.file.Sync()
+	}
+	if err != nil {
+		// partially written record would be read as corrupt
+		last.file.Truncate(last.size)
+		return fmt.Errorf("write: %w", err)
+	}
+	last.size += int64(len(record))
+	last.count++
+	q.size += int64(len(record))
+	q.next++
+	q.unsynced = true
+	select {
+	case q.appended <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// roll syncs the last segment and starts a new one.
+func (q *Queue) roll() error {
+	if err := q.syncLast(); err != nil {
+		return err
+	}
+	return q.createSegment(q.next)
+}
+
+func (q *Queue) createSegment(base uint64) error {
+	f, err := os.OpenFile(q.segmentPath(base), os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("create segment: %w", err)
+	}
+	q.segments = append(q.segments, &segment{base: base, file: f})
+	if q.fsync != FsyncNever {
+		return syncDir(q.dir)
+	}
+	return nil
+}
+
+// Read waits for the next record, it returns offset of the record to acknowledge after push.
+// Corrupt record is acknowledged and reported as error. Torn record ends its segment, it and the following records
+// of the segment are acknowledged and reported as one error.
+func (q *Queue) Read(ctx context.Context) (uint64, []byte, error) {
+	for {
+		q.mu.Lock()
+		if q.readOffset < q.next {
+			offset, data, err := q.read()
+			q.mu.Unlock()
+			return offset, data, err
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-q.appended:
+		}
+	}
+}
+
+func (q *Queue) read() (uint64, []byte, error) {
+	seg := q.segments[q.readSegment]
+	if q.readPos >= seg.size && q.readSegment < len(q.segments)-1 {
+		q.readSegment++
+		seg, q.readPos = q.segments[q.readSegment], 0
+		// offsets missing in the previous segment are never read
+		for ; q.readOffset < seg.base; q.readOffset++ {
+			q.ack(q.readOffset)
+		}
+	}
+	offset := q.readOffset
+	data, err := readRecord(seg.file, q.readPos, seg.size)
+	if errors.Is(err, errCorruptRecord) && data == nil {
+		// segment which wasn't synced before crash can be torn, the rest of its records is lost
+		end := q.next
+		if q.readSegment < len(q.segments)-1 {
+			end = q.segments[q.readSegment+1].base
+		}
+		q.readPos = seg.size
+		for ; q.readOffset < end; q.readOffset++ {
+			q.ack(q.readOffset)
+		}
+		return offset, nil, fmt.Errorf("offsets %d-%d: %w", offset, end-1, err)
+	}
+	if errors.Is(err, errCorruptRecord) {
+		// length is still valid, since record is inside the segment
+		length := int64(binary.BigEndian.Uint32(data))
+		q.readPos += recordHeaderSize + length
+		q.readOffset++
+		q.ack(offset)
+		return offset, nil, fmt.Errorf("offset %d: %w", offset, err)
+	}
+	if err != nil {
+		return offset, nil, fmt.Errorf("offset %d: %w", offset, err)
+	}
+	q.readPos += recordHeaderSize + int64(len(data))
+	q.readOffset++
+	return offset, data, nil
+}
+
+// readRecord reads record at pos of the file which ends at end. Data of corrupt record is its header.
+func readRecord(f *os.File, pos, end int64) ([]byte, error) {
+	if end-pos < recordHeaderSize {
+		return nil, fmt.Errorf("%w: no header", errCorruptRecord)
+	}
+	header := make([]byte, recordHeaderSize)
+	if _, err := f.ReadAt(header, pos); err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	length := int64(binary.BigEndian.Uint32(header))
+	if end-pos-recordHeaderSize < length {
+		return nil, fmt.Errorf("%w: truncated", errCorruptRecord)
+	}
+	data := make([]byte, length)
+	if _, err := f.ReadAt(data, pos+recordHeaderSize); err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+		return header, fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+	}
+	return data, nil
+}
+
+// Ack marks record at offset as pushed, the checkpoint is saved at once with FsyncAlways.
+func (q *Queue) Ack(offset uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ack(offset)
+	if q.fsync == FsyncAlways {
+		return q.saveCheckpoint()
+	}
+	return nil
+}
+
+func (q *Queue) ack(offset uint64) {
+	if offset < q.checkpoint {
+		return
+	}
+	q.acked[offset] = struct{}{}
+	for {
+		if _, ok := q.acked[q.checkpoint]; !ok {
+			return
+		}
+		delete(q.acked, q.checkpoint)
+		q.checkpoint++
+	}
+}
+
+// saveCheckpoint writes the checkpoint to a temporary file and renames it, then segments below it are removed.
+func (q *Queue) saveCheckpoint() error {
+	if q.checkpoint == q.saved {
+		return nil
+	}
+	tmp, err := os.CreateTemp(q.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(strconv.FormatUint(q.checkpoint, 10))
+	if err == nil && q.fsync != FsyncNever {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(q.dir, checkpointName)); err != nil {
+		return fmt.Errorf("rename checkpoint: %w", err)
+	}
+	if q.fsync != FsyncNever {
+		// rename is durable only after directory sync
+		if err = syncDir(q.dir); err != nil {
+			return err
+		}
+	}
+	q.saved = q.checkpoint
+	return q.compact()
+}
+
+// compact removes segments below the saved checkpoint, the last segment is kept for appends.
+func (q *Queue) compact() error {
+	for len(q.segments) > 1 && q.segments[1].base <= q.saved {
+		seg := q.segments[0]
+		if err := seg.file.Close(); err != nil {
+			return fmt.Errorf("close segment: %w", err)
+		}
+		if err := os.Remove(q.segmentPath(seg.base)); err != nil {
+			return fmt.Errorf("remove segment: %w", err)
+		}
+		q.segments = q.segments[1:]
+		q.size -= seg.size
+		if q.readSegment > 0 {
+			q.readSegment--
+		} else {
+			// removed segment was read to the end
+			q.readPos = 0
+		}
+	}
+	return nil
+}
+
+// syncLoop syncs appended records with FsyncInterval and saves the checkpoint.
+func (q *Queue) syncLoop() {
+	defer close(q.stopped)
+	ticker := time.NewTicker(q.fsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+			q.mu.Lock()
+			q.err = q.sync()
+			q.mu.Unlock()
+		}
+	}
+}
+
+func (q *Queue) sync() error {
+	if err := q.syncLast(); err != nil {
+		return err
+	}
+	return q.saveCheckpoint()
+}
+
+func (q *Queue) syncLast() error {
+	if !q.unsynced || q.fsync == FsyncNever {
+		return nil
+	}
+	if err := q.segments[len(q.segments)-1].file.Sync(); err != nil {
+		return fmt.Errorf("sync segment: %w", err)
+	}
+	q.unsynced = false
+	return nil
+}
+
+// Len returns number of records which aren't acknowledged.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int(q.next - q.checkpoint)
+}
+
+// Health reports the last error of background sync.
+func (q *Queue) Health(_ context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
+
+// Close syncs appended records, saves the checkpoint and closes segments.
+func (q *Queue) Close() error {
+	close(q.stop)
+	<-q.stopped
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.sync()
+	if closeErr := q.closeSegments(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (q *Queue) closeSegments() error {
+	var err error
+	for _, seg := range q.segments {
+		if closeErr := seg.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	q.segments = nil
+	return err
+}
+
+func (q *Queue) segmentPath(base uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", base, segmentExt))
+}
+
+// syncDir makes created and renamed files of the directory durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open directory: %w", err)
+	}
+	defer d.Close()
+	if err = d.Sync(); err != nil {
+		return fmt.Errorf("sync directory: %w", err)
+	}
+	return nil
+}
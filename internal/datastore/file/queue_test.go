@@ -0,0 +1,237 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/config"
+)
+
+func TestNewQueue(t *testing.T) {
+	ao := assert.New(t)
+	_, err := NewQueue(config.NotificationQueue{})
+	ao.EqualError(err, "no path")
+	_, err = NewQueue(config.NotificationQueue{Path: t.TempDir(), Fsync: "sometimes"})
+	ao.EqualError(err, `unknown fsync policy "sometimes"`)
+
+	q, err := NewQueue(config.NotificationQueue{Path: filepath.Join(t.TempDir(), "queue")})
+	require.NoError(t, err)
+	defer q.Close()
+	ao.Equal(int64(DefaultSegmentSizeMB)<<20, q.segmentSize)
+	ao.Equal(int64(DefaultMaxQueueSizeMB)<<20, q.maxSize)
+	ao.Equal(FsyncInterval, q.fsync)
+	ao.Equal(DefaultFsyncInterval, q.fsyncInterval)
+	ao.Equal(0, q.Len())
+}
+
+// readAll reads n records.
+func readAll(t *testing.T, q *Queue, n int) ([]uint64, []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var (
+		offsets []uint64
+		records []string
+	)
+	for i := 0; i < n; i++ {
+		offset, data, err := q.Read(ctx)
+		require.NoError(t, err)
+		offsets = append(offsets, offset)
+		records = append(records, string(data))
+	}
+	return offsets, records
+}
+
+func TestQueue(t *testing.T) {
+	ao := assert.New(t)
+	dir := t.TempDir()
+	cfg := config.NotificationQueue{Path: dir, Fsync: FsyncAlways}
+	q, err := NewQueue(cfg)
+	require.NoError(t, err)
+	// 2 records of 12 bytes per segment
+	q.segmentSize = 24
+
+	for i := 0; i < 5; i++ {
+		ao.NoError(q.Append([]byte(fmt.Sprintf("msg%d", i))))
+	}
+	ao.Equal(5, q.Len())
+	ao.Len(q.segments, 3)
+
+	offsets, records := readAll(t, q, 5)
+	ao.Equal([]uint64{0, 1, 2, 3, 4}, offsets)
+	ao.Equal([]string{"msg0", "msg1", "msg2", "msg3", "msg4"}, records)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = q.Read(ctx)
+	ao.ErrorIs(err, context.DeadlineExceeded)
+
+	// out of order acknowledgement advances the checkpoint over contiguous prefix
+	ao.NoError(q.Ack(1))
+	ao.NoError(q.Ack(2))
+	ao.Equal(5, q.Len())
+	ao.NoError(q.Ack(0))
+	ao.Equal(2, q.Len())
+	// the first segment is compacted
+	ao.Len(q.segments, 2)
+	_, err = os.Stat(filepath.Join(dir, "00000000000000000000.log"))
+	ao.ErrorIs(err, os.ErrNotExist)
+	ao.NoError(q.Close())
+
+	// not acknowledged records are replayed before appended ones
+	q, err = NewQueue(cfg)
+	require.NoError(t, err)
+	ao.Equal(2, q.Len())
+	ao.NoError(q.Append([]byte("msg5")))
+	offsets, records = readAll(t, q, 3)
+	ao.Equal([]uint64{3, 4, 5}, offsets)
+	ao.Equal([]string{"msg3", "msg4", "msg5"}, records)
+	for _, offset := range offsets {
+		ao.NoError(q.Ack(offset))
+	}
+	ao.Equal(0, q.Len())
+	ao.NoError(q.Health(context.Background()))
+	ao.NoError(q.Close())
+	data, err := os.ReadFile(filepath.Join(dir, checkpointName))
+	require.NoError(t, err)
+	ao.Equal("6", string(data))
+}
+
+func TestQueue_Recover(t *testing.T) {
+	ao := assert.New(t)
+	dir := t.TempDir()
+	cfg := config.NotificationQueue{Path: dir, Fsync: FsyncNever}
+	q, err := NewQueue(cfg)
+	require.NoError(t, err)
+	ao.NoError(q.Append([]byte("msg0")))
+	ao.NoError(q.Append([]byte("msg1")))
+	ao.NoError(q.Close())
+
+	// partially written record is cut off
+	path := filepath.Join(dir, "00000000000000000000.log")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 4, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = NewQueue(cfg)
+	require.NoError(t, err)
+	ao.Equal(2, q.Len())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	ao.Equal(int64(24), info.Size())
+	ao.NoError(q.Append([]byte("msg2")))
+	_, records := readAll(t, q, 3)
+	ao.Equal([]string{"msg0", "msg1", "msg2"}, records)
+	ao.NoError(q.Close())
+
+	// corrupt record is skipped and acknowledged
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[recordHeaderSize] = 'x'
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	q, err = NewQueue(cfg)
+	require.NoError(t, err)
+	defer q.Close()
+	_, _, err = q.Read(context.Background())
+	ao.ErrorIs(err, errCorruptRecord)
+	offsets, records := readAll(t, q, 2)
+	ao.Equal([]uint64{1, 2}, offsets)
+	ao.Equal([]string{"msg1", "msg2"}, records)
+	ao.NoError(q.Ack(1))
+	ao.Equal(1, q.Len())
+}
+
+func TestQueue_TornSegment(t *testing.T) {
+	// tornQueue writes segments [msg0 msg1] [msg2 msg3] [msg4], then tears msg1 and the header of msg2,
+	// as if the segments weren't synced before crash.
+	tornQueue := func(t *testing.T, checkpoint string) *Queue {
+		dir := t.TempDir()
+		cfg := config.NotificationQueue{Path: dir, Fsync: FsyncNever}
+		q, err := NewQueue(cfg)
+		require.NoError(t, err)
+		q.segmentSize = 24
+		for i := 0; i < 5; i++ {
+			require.NoError(t, q.Append([]byte(fmt.Sprintf("msg%d", i))))
+		}
+		require.NoError(t, q.Close())
+		require.NoError(t, os.Truncate(filepath.Join(dir, "00000000000000000000.log"), 18))
+		require.NoError(t, os.Truncate(filepath.Join(dir, "00000000000000000002.log"), 5))
+		if checkpoint != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, checkpointName), []byte(checkpoint), 0o644))
+		}
+		q, err = NewQueue(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { q.Close() })
+		return q
+	}
+
+	t.Run("read", func(t *testing.T) {
+		ao := assert.New(t)
+		q := tornQueue(t, "")
+		ao.Equal(5, q.Len())
+		ctx := context.Background()
+		offset, data, err := q.Read(ctx)
+		ao.NoError(err)
+		ao.Equal(uint64(0), offset)
+		ao.Equal("msg0", string(data))
+		// the rest of torn segment is acknowledged
+		_, _, err = q.Read(ctx)
+		ao.ErrorIs(err, errCorruptRecord)
+		ao.ErrorContains(err, "offsets 1-1")
+		_, _, err = q.Read(ctx)
+		ao.ErrorIs(err, errCorruptRecord)
+		ao.ErrorContains(err, "offsets 2-3")
+		offsets, records := readAll(t, q, 1)
+		ao.Equal([]uint64{4}, offsets)
+		ao.Equal([]string{"msg4"}, records)
+		ao.NoError(q.Ack(0))
+		ao.Equal(1, q.Len())
+		ao.NoError(q.Ack(4))
+		ao.Equal(0, q.Len())
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		ao := assert.New(t)
+		q := tornQueue(t, "3")
+		ao.Equal(2, q.Len())
+		offsets, records := readAll(t, q, 1)
+		ao.Equal([]uint64{4}, offsets)
+		ao.Equal([]string{"msg4"}, records)
+		ao.Equal(1, q.Len())
+	})
+}
+
+func TestQueue_Full(t *testing.T) {
+	ao := assert.New(t)
+	q, err := NewQueue(config.NotificationQueue{Path: t.TempDir()})
+	require.NoError(t, err)
+	defer q.Close()
+	q.maxSize = 20
+
+	ao.NoError(q.Append([]byte("msg0")))
+	ao.ErrorIs(q.Append([]byte("msg1")), errQueueFull)
+	ao.Equal(1, q.Len())
+}
+
+func TestQueue_SyncLoop(t *testing.T) {
+	ao := assert.New(t)
+	dir := t.TempDir()
+	q, err := NewQueue(config.NotificationQueue{Path: dir, FsyncInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer q.Close()
+
+	ao.NoError(q.Append([]byte("msg0")))
+	offsets, _ := readAll(t, q, 1)
+	ao.NoError(q.Ack(offsets[0]))
+	ao.Eventually(func() bool {
+		data, err := os.ReadFile(filepath.Join(dir, checkpointName))
+		return err == nil && string(data) == "1"
+	}, time.Second, time.Millisecond)
+}
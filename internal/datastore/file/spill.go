@@ -0,0 +1,211 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// DefaultMaxSpillSizeMB limits spill file if limit isn't configured.
+const DefaultMaxSpillSizeMB = 100
+
+var errSpillFull = errors.New("spill file is full")
+
+// SpillQueue is FIFO queue of notifications kept as lines of the file. Lines are appended to the end and taken from
+// the read offset, lines before the oldest taken line which isn't acknowledged are consumed. Max size limits live lines
+// only, consumed prefix is cut off once it exceeds half of max size, so the file doesn't grow over 1.5 of max size.
+// Read offset is kept in memory only, so lines left in the file are read again after restart and notification may be
+// pushed twice.
+type SpillQueue struct {
+	path    string
+	maxSize int64
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	offset int64
+	// base is number of bytes cut off from the beginning of the file, offsets of taken lines are base+position
+	base int64
+	// count is number of lines which aren't taken, taken are offsets of taken lines which aren't acknowledged in order
+	count int
+	taken []int64
+	// next is the line at offset without newline, it is read by Peek and kept until Take
+	next []byte
+}
+
+// NewSpillQueue opens or creates the file, lines left by the previous run are queued.
+// Zero maxSizeMB is replaced with default.
+func NewSpillQueue(path string, maxSizeMB int) (*SpillQueue, error) {
+	if path == "" {
+		return nil, errors.New("no path")
+	}
+	if maxSizeMB <= 0 {
+		maxSizeMB = DefaultMaxSpillSizeMB
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	q := &SpillQueue{path: path, maxSize: int64(maxSizeMB) << 20, file: f}
+	if err = q.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// load counts complete lines of the file, partially written last line is cut off.
+func (q *SpillQueue) load() error {
+	r := bufio.NewReader(q.file)
+	for {
+		l, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		q.size += int64(len(l))
+		q.count++
+	}
+	if err := q.file.Truncate(q.size); err != nil {
+		return fmt.Errorf("truncate: %w", err)
+	}
+	return nil
+}
+
+// Push appends notification to the file, error is returned if live lines would exceed max size.
+func (q *SpillQueue) Push(data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	l := line(data)
+	if q.size-q.consumed()+int64(len(l)) > q.maxSize {
+		return errSpillFull
+	}
+	if _, err := q.file.WriteAt(l, q.size); err != nil {
+		// partially written line would break the next one
+		q.file.Truncate(q.size)
+		return fmt.Errorf("write: %w", err)
+	}
+	q.size += int64(len(l))
+	q.count++
+	return nil
+}
+
+// Peek returns the oldest notification which isn't taken, nil if there is none.
+func (q *SpillQueue) Peek() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.peek()
+}
+
+func (q *SpillQueue) peek() ([]byte, error) {
+	if q.count == 0 || q.next != nil {
+		return q.next, nil
+	}
+	l, err := bufio.NewReader(io.NewSectionReader(q.file, q.offset, q.size-q.offset)).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	q.next = bytes.TrimSuffix(l, []byte{'\n'})
+	return q.next, nil
+}
+
+// Take moves read offset past the oldest notification, the line is kept in the file until Ack.
+// It returns offset to acknowledge.
+func (q *SpillQueue) Take() (uint64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	next, err := q.peek()
+	if err != nil || next == nil {
+		return 0, err
+	}
+	offset := q.base + q.offset
+	q.offset += int64(len(next)) + 1
+	q.next = nil
+	q.count--
+	q.taken = append(q.taken, offset)
+	return uint64(offset), nil
+}
+
+// Ack acknowledges taken notification, the file is truncated when all lines are taken and acknowledged.
+// Consumed prefix is cut off once it exceeds half of max size.
+func (q *SpillQueue) Ack(offset uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.Index(q.taken, int64(offset))
+	if i < 0 {
+		return nil
+	}
+	q.taken = slices.Delete(q.taken, i, i+1)
+	if len(q.taken) == 0 && q.count == 0 {
+		if err := q.file.Truncate(0); err != nil {
+			return fmt.Errorf("truncate: %w", err)
+		}
+		q.base += q.size
+		q.size, q.offset = 0, 0
+		return nil
+	}
+	if consumed := q.consumed(); consumed > q.maxSize/2 {
+		return q.compact(consumed)
+	}
+	return nil
+}
+
+// consumed returns size of the prefix which is taken and acknowledged.
+func (q *SpillQueue) consumed() int64 {
+	if len(q.taken) > 0 {
+		return q.taken[0] - q.base
+	}
+	return q.offset
+}
+
+// compact replaces the file with its live lines, the new file is written aside and renamed,
+// so the old one is kept whole if it fails.
+func (q *SpillQueue) compact(consumed int64) error {
+	tmp := q.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("compact: open: %w", err)
+	}
+	_, err = io.Copy(f, io.NewSectionReader(q.file, consumed, q.size-consumed))
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, q.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("compact: %w", err)
+	}
+	q.file.Close()
+	q.file = f
+	q.base += consumed
+	q.size -= consumed
+	q.offset -= consumed
+	return nil
+}
+
+// Len returns number of notifications which aren't taken.
+func (q *SpillQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
+
+func (q *SpillQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
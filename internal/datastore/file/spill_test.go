@@ -0,0 +1,130 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillQueue(t *testing.T) {
+	ao := assert.New(t)
+	_, err := NewSpillQueue("", 0)
+	ao.EqualError(err, "no path")
+
+	path := filepath.Join(t.TempDir(), "spill", "notifications.ndjson")
+	q, err := NewSpillQueue(path, 0)
+	require.NoError(t, err)
+	ao.Equal(int64(DefaultMaxSpillSizeMB)<<20, q.maxSize)
+	// 3 lines fit
+	q.maxSize = 15
+
+	next, err := q.Peek()
+	ao.NoError(err)
+	ao.Nil(next)
+	_, err = q.Take()
+	ao.NoError(err)
+	ao.NoError(q.Ack(0))
+
+	for _, line := range []string{"1111", "2222", "3333"} {
+		ao.NoError(q.Push([]byte(line)))
+	}
+	ao.ErrorIs(q.Push([]byte("4444")), errSpillFull)
+	ao.Equal(3, q.Len())
+
+	next, err = q.Peek()
+	ao.NoError(err)
+	ao.Equal("1111", string(next))
+	next, err = q.Peek()
+	ao.NoError(err)
+	ao.Equal("1111", string(next))
+	_, err = q.Take()
+	ao.NoError(err)
+	next, err = q.Peek()
+	ao.NoError(err)
+	ao.Equal("2222", string(next))
+	ao.Equal(2, q.Len())
+	ao.NoError(q.Close())
+
+	// lines which aren't acknowledged are read again after restart, partially written line is cut off
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"Type":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	q, err = NewSpillQueue(path, 1)
+	require.NoError(t, err)
+	defer q.Close()
+	ao.Equal(3, q.Len())
+	var offsets []uint64
+	for _, expected := range []string{"1111", "2222", "3333"} {
+		next, err = q.Peek()
+		ao.NoError(err)
+		ao.Equal(expected, string(next))
+		offset, err := q.Take()
+		ao.NoError(err)
+		offsets = append(offsets, offset)
+	}
+	ao.Equal([]uint64{0, 5, 10}, offsets)
+	ao.Equal(0, q.Len())
+	// the file is kept until every taken line is acknowledged, unknown offset is ignored
+	ao.NoError(q.Ack(offsets[2]))
+	ao.NoError(q.Ack(offsets[1]))
+	ao.NoError(q.Ack(offsets[1]))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	ao.Equal("1111\n2222\n3333\n", string(data))
+	ao.NoError(q.Ack(offsets[0]))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	ao.Empty(data)
+
+	ao.NoError(q.Push([]byte("5555")))
+	next, err = q.Peek()
+	ao.NoError(err)
+	ao.Equal("5555", string(next))
+}
+
+func TestSpillQueue_compact(t *testing.T) {
+	ao := assert.New(t)
+	path := filepath.Join(t.TempDir(), "notifications.ndjson")
+	q, err := NewSpillQueue(path, 0)
+	require.NoError(t, err)
+	defer q.Close()
+	// 4 lines fit, consumed prefix is cut off when it exceeds 2 lines
+	q.maxSize = 20
+
+	for _, line := range []string{"1111", "2222", "3333", "4444"} {
+		ao.NoError(q.Push([]byte(line)))
+	}
+	take := func() uint64 {
+		offset, err := q.Take()
+		ao.NoError(err)
+		return offset
+	}
+	first, second := take(), take()
+	// line acknowledged out of order isn't consumed while older one is in flight
+	ao.NoError(q.Ack(second))
+	ao.ErrorIs(q.Push([]byte("5555")), errSpillFull)
+	ao.NoError(q.Ack(first))
+
+	// under steady load taken and pushed lines overlap, so the queue is never empty
+	next := take()
+	for i := 5; i < 100; i++ {
+		ao.NoError(q.Push([]byte(fmt.Sprintf("%04d", i))))
+		ao.NoError(q.Ack(next))
+		next = take()
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		ao.LessOrEqual(info.Size(), q.maxSize*3/2)
+	}
+	ao.Equal(1, q.Len())
+	// taken line which isn't acknowledged is kept in the file
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	ao.True(strings.HasSuffix(string(data), "0098\n0099\n"), string(data))
+}
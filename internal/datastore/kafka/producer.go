@@ -0,0 +1,246 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+
+	"test_task/internal/config"
+	"test_task/internal/message"
+	"test_task/internal/tracing"
+)
+
+// DefaultTimeout limits delivery of one notification when timeout isn't configured.
+const DefaultTimeout = 10 * time.Second
+
+// HeaderType record header with notification operation type.
+const HeaderType = "type"
+
+// Producer publishes notifications to Kafka topic. Push waits until the record is acknowledged.
+type Producer struct {
+	client *kgo.Client
+	topic  string
+}
+
+// NewProducer creates producer, connection to brokers is established on first use.
+func NewProducer(cfg config.Kafka) (*Producer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("no brokers")
+	}
+	if cfg.Topic == "" {
+		return nil, errors.New("no topic")
+	}
+	opts, err := options(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create client: %w", err)
+	}
+	return &Producer{client: client, topic: cfg.Topic}, nil
+}
+
+// Push publishes data keyed by notification key from ctx, so notifications of one user are ordered.
+func (p *Producer) Push(ctx context.Context, data []byte) error {
+	attrs := message.FromContext(ctx)
+	record := &kgo.Record{Topic: p.topic, Value: data}
+	if attrs.Key != "" {
+		record.Key = []byte(attrs.Key)
+	}
+	if attrs.Type != "" {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: HeaderType, Value: []byte(attrs.Type)})
+	}
+	tracing.Propagator.Inject(ctx, recordCarrier{record: record})
+	if err := p.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("produce: %w", err)
+	}
+	return nil
+}
+
+// Health checks that a broker is reachable and serves the topic.
+func (p *Producer) Health(ctx context.Context) error {
+	topic := kmsg.NewMetadataRequestTopic()
+	topic.Topic = kmsg.StringPtr(p.topic)
+	req := kmsg.NewPtrMetadataRequest()
+	req.Topics = append(req.Topics, topic)
+	req.AllowAutoTopicCreation = false
+	resp, err := req.RequestWith(ctx, p.client)
+	if err != nil {
+		return fmt.Errorf("metadata: %w", err)
+	}
+	for _, t := range resp.Topics {
+		if err = kerr.ErrorForCode(t.ErrorCode); err != nil {
+			return fmt.Errorf("topic %s: %w", p.topic, err)
+		}
+	}
+	return nil
+}
+
+// Close closes connections to brokers.
+func (p *Producer) Close() error {
+	p.client.Close()
+	return nil
+}
+
+// options maps configuration to client options.
+func options(cfg config.Kafka) ([]kgo.Opt, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.DefaultProduceTopic(cfg.Topic),
+		kgo.RecordDeliveryTimeout(timeout),
+		// records are partitioned by key, records without key are spread over partitions
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+	}
+	if cfg.ClientID != "" {
+		opts = append(opts, kgo.ClientID(cfg.ClientID))
+	}
+
+	acks, err := parseAcks(cfg.Acks)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, kgo.RequiredAcks(acks))
+	if cfg.Idempotent {
+		if acks != kgo.AllISRAcks() {
+			return nil, errors.New("idempotent producer requires acks all")
+		}
+	} else {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	}
+
+	codec, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, kgo.ProducerBatchCompression(codec))
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+	if cfg.SASL.Mechanism != "" {
+		mechanism, err := newSASL(cfg.SASL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+	return opts, nil
+}
+
+func parseAcks(acks string) (kgo.Acks, error) {
+	switch strings.ToLower(acks) {
+	case "", "all":
+		return kgo.AllISRAcks(), nil
+	case "leader":
+		return kgo.LeaderAck(), nil
+	case "none":
+		return kgo.NoAck(), nil
+	}
+	return kgo.Acks{}, fmt.Errorf("unknown acks %q", acks)
+}
+
+func parseCompression(compression string) (kgo.CompressionCodec, error) {
+	switch strings.ToLower(compression) {
+	case "", "none":
+		return kgo.NoCompression(), nil
+	case "gzip":
+		return kgo.GzipCompression(), nil
+	case "snappy":
+		return kgo.SnappyCompression(), nil
+	case "lz4":
+		return kgo.Lz4Compression(), nil
+	case "zstd":
+		return kgo.ZstdCompression(), nil
+	}
+	return kgo.CompressionCodec{}, fmt.Errorf("unknown compression %q", compression)
+}
+
+func newTLSConfig(cfg config.KafkaTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func newSASL(cfg config.KafkaSASL) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.Mechanism) {
+	case "plain":
+		return plain.Auth{User: cfg.Username, Pass: cfg.Password}.AsMechanism(), nil
+	case "scram-sha-256":
+		return scram.Auth{User: cfg.Username, Pass: cfg.Password}.AsSha256Mechanism(), nil
+	case "scram-sha-512":
+		return scram.Auth{User: cfg.Username, Pass: cfg.Password}.AsSha512Mechanism(), nil
+	}
+	return nil, fmt.Errorf("unknown sasl mechanism %q", cfg.Mechanism)
+}
+
+// recordCarrier propagates trace context in record headers.
+type recordCarrier struct {
+	record *kgo.Record
+}
+
+func (c recordCarrier) Get(key string) string {
+	for _, h := range c.record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c recordCarrier) Set(key, value string) {
+	for i, h := range c.record.Headers {
+		if h.Key == key {
+			c.record.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.record.Headers = append(c.record.Headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
+
+func (c recordCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.record.Headers))
+	for _, h := range c.record.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
@@ -0,0 +1,211 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"test_task/internal/config"
+	"test_task/internal/message"
+)
+
+const testTopic = "users"
+
+func TestNewProducer(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Kafka
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic},
+		},
+		{
+			name: "full configuration",
+			cfg: config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic, ClientID: "test_task", Acks: "all",
+				Compression: "zstd", Idempotent: true, Timeout: time.Second,
+				TLS:  config.KafkaTLS{Enabled: true, InsecureSkipVerify: true},
+				SASL: config.KafkaSASL{Mechanism: "SCRAM-SHA-512", Username: "user", Password: "password"}},
+		},
+		{
+			name: "not idempotent leader acks",
+			cfg:  config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic, Acks: "leader", Compression: "lz4"},
+		},
+		{
+			name:        "no brokers",
+			cfg:         config.Kafka{Topic: testTopic},
+			expectedErr: "no brokers",
+		},
+		{
+			name:        "no topic",
+			cfg:         config.Kafka{Brokers: []string{"localhost:9092"}},
+			expectedErr: "no topic",
+		},
+		{
+			name:        "idempotent without all acks",
+			cfg:         config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic, Acks: "none", Idempotent: true},
+			expectedErr: "idempotent producer requires acks all",
+		},
+		{
+			name:        "unknown acks",
+			cfg:         config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic, Acks: "2"},
+			expectedErr: `unknown acks "2"`,
+		},
+		{
+			name:        "unknown compression",
+			cfg:         config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic, Compression: "brotli"},
+			expectedErr: `unknown compression "brotli"`,
+		},
+		{
+			name:        "unknown sasl mechanism",
+			cfg:         config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic, SASL: config.KafkaSASL{Mechanism: "gssapi"}},
+			expectedErr: `unknown sasl mechanism "gssapi"`,
+		},
+		{
+			name: "missing ca file",
+			cfg: config.Kafka{Brokers: []string{"localhost:9092"}, Topic: testTopic,
+				TLS: config.KafkaTLS{Enabled: true, CAFile: "testdata/missing.pem"}},
+			expectedErr: "read ca file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProducer(tt.cfg)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			p.Close()
+		})
+	}
+}
+
+func TestProducer_Push(t *testing.T) {
+	ao := assert.New(t)
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(4, testTopic))
+	require.NoError(t, err)
+	defer cluster.Close()
+
+	p, err := NewProducer(config.Kafka{Brokers: cluster.ListenAddrs(), Topic: testTopic, Idempotent: true, Compression: "snappy"})
+	require.NoError(t, err)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	keys := []string{"1", "2", "3", "1", "2", "3", "1"}
+	for i, key := range keys {
+		msgCtx := message.NewContext(ctx, message.Attributes{Key: key, Type: "Update"})
+		require.NoError(t, p.Push(msgCtx, []byte(fmt.Sprintf("%s-%d", key, i))))
+	}
+
+	records := consume(t, ctx, cluster.ListenAddrs(), len(keys))
+	partitions := map[string]int32{}
+	values := map[string][]string{}
+	for _, r := range records {
+		key := string(r.Key)
+		if partition, ok := partitions[key]; ok {
+			ao.Equal(partition, r.Partition, "records of one key must go to one partition")
+		}
+		partitions[key] = r.Partition
+		values[key] = append(values[key], string(r.Value))
+		ao.Equal([]kgo.RecordHeader{{Key: HeaderType, Value: []byte("Update")}}, r.Headers)
+	}
+	ao.Equal([]string{"1-0", "1-3", "1-6"}, values["1"])
+	ao.Equal([]string{"2-1", "2-4"}, values["2"])
+	ao.Equal([]string{"3-2", "3-5"}, values["3"])
+}
+
+func TestProducer_PushSASL(t *testing.T) {
+	for _, mechanism := range []string{"PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512"} {
+		t.Run(mechanism, func(t *testing.T) {
+			cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, testTopic),
+				kfake.EnableSASL(), kfake.Superuser(mechanism, "producer", "secret"))
+			require.NoError(t, err)
+			defer cluster.Close()
+
+			p, err := NewProducer(config.Kafka{Brokers: cluster.ListenAddrs(), Topic: testTopic, Idempotent: true,
+				SASL: config.KafkaSASL{Mechanism: strings.ToLower(mechanism), Username: "producer", Password: "secret"}})
+			require.NoError(t, err)
+			defer p.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			assert.NoError(t, p.Push(ctx, []byte("data")))
+		})
+	}
+}
+
+func TestProducer_Health(t *testing.T) {
+	ao := assert.New(t)
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, testTopic))
+	require.NoError(t, err)
+	defer cluster.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := NewProducer(config.Kafka{Brokers: cluster.ListenAddrs(), Topic: testTopic})
+	require.NoError(t, err)
+	defer p.Close()
+	ao.NoError(p.Health(ctx))
+
+	missing, err := NewProducer(config.Kafka{Brokers: cluster.ListenAddrs(), Topic: "missing"})
+	require.NoError(t, err)
+	defer missing.Close()
+	err = missing.Health(ctx)
+	ao.True(errors.Is(err, kerr.UnknownTopicOrPartition), err)
+
+	cluster.Close()
+	unavailableCtx, unavailableCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer unavailableCancel()
+	ao.Error(p.Health(unavailableCtx))
+}
+
+// TestProducer_Broker runs against a real broker, e.g. from docker-compose:
+// KAFKA_BROKERS=localhost:9094 go test ./internal/datastore/kafka/ -run Broker
+func TestProducer_Broker(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS is not set")
+	}
+	topic := os.Getenv("KAFKA_TOPIC")
+	if topic == "" {
+		topic = testTopic
+	}
+	ao := assert.New(t)
+	p, err := NewProducer(config.Kafka{Brokers: strings.Split(brokers, ","), Topic: topic, Idempotent: true, Compression: "zstd"})
+	require.NoError(t, err)
+	defer p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ao.NoError(p.Health(ctx))
+	ao.NoError(p.Push(message.NewContext(ctx, message.Attributes{Key: "42", Type: "Insert"}), []byte(`{"Type":"Insert","Key":"42"}`)))
+}
+
+func consume(t *testing.T, ctx context.Context, brokers []string, n int) []*kgo.Record {
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics(testTopic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	var records []*kgo.Record
+	for len(records) < n {
+		fetches := consumer.PollFetches(ctx)
+		require.NoError(t, ctx.Err())
+		require.Empty(t, fetches.Errors())
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore"
+	"test_task/internal/datastore/postgres/model"
+	"test_task/internal/entity"
+)
+
+type APIKeyRepository struct {
+	pgClient *gorm.DB
+}
+
+func NewAPIKeyRepository(pgClient *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{pgClient: pgClient}
+}
+
+func (a *APIKeyRepository) Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	modelKey := model.MapEntityAPIKeyToModelAPIKey(key)
+	err := conn(ctx, a.pgClient).Create(&modelKey).Error
+	return model.MapModelAPIKeyToEntityAPIKey(modelKey), err
+}
+
+func (a *APIKeyRepository) List(ctx context.Context) ([]entity.APIKey, error) {
+	res := make([]model.APIKey, 0)
+	err := conn(ctx, a.pgClient).Order("created_at DESC").Find(&res).Error
+	return model.MapModelAPIKeysToEntityAPIKeys(res), err
+}
+
+func (a *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	var res model.APIKey
+	err := conn(ctx, a.pgClient).Where("prefix = ?", prefix).Take(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.APIKey{}, datastore.ErrNotFound
+	}
+	return model.MapModelAPIKeyToEntityAPIKey(res), err
+}
+
+// Revoke marks key as revoked. Returns datastore.ErrNotFound if there is no unrevoked key with id.
+func (a *APIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return datastore.ErrNotFound
+	}
+	res := conn(ctx, a.pgClient).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return datastore.ErrNotFound
+	}
+	return res.Error
+}
+
+func (a *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return conn(ctx, a.pgClient).Model(&model.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
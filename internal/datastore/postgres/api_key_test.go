@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+)
+
+func TestAPIKeyRepository_Create(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "api_keys" ("name","prefix","key_hash","scopes","expires_at","last_used_at","revoked_at","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`)).
+		WithArgs("billing", "0a1b2c3d", "hash", "users:read,users:write", nil, nil, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testUserID))
+	mock.ExpectCommit()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewAPIKeyRepository(gormDB)
+	res, err := repo.Create(context.Background(), entity.APIKey{
+		Name:   "billing",
+		Prefix: "0a1b2c3d",
+		Hash:   "hash",
+		Scopes: []string{entity.ScopeUsersRead, entity.ScopeUsersWrite},
+	})
+	ao.NoError(err)
+	ao.Equal(testUserID, res.ID)
+	ao.Equal([]string{entity.ScopeUsersRead, entity.ScopeUsersWrite}, res.Scopes)
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_GetByPrefix(t *testing.T) {
+	type testCase struct {
+		name        string
+		mockSetup   func(sqlmock.Sqlmock)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "successful retrieval",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_keys" WHERE prefix = $1 LIMIT $2`)).
+					WithArgs("0a1b2c3d", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "prefix", "scopes"}).AddRow(testUserID, "0a1b2c3d", "users:read"))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_keys"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedErr: datastore.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			ao.NoError(err)
+			defer db.Close()
+
+			tc.mockSetup(mock)
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{
+				Conn: db,
+			}), &gorm.Config{})
+			ao.NoError(err)
+
+			repo := NewAPIKeyRepository(gormDB)
+			res, err := repo.GetByPrefix(context.Background(), "0a1b2c3d")
+			if tc.expectedErr != nil {
+				ao.ErrorIs(err, tc.expectedErr)
+			} else {
+				ao.NoError(err)
+				ao.Equal(entity.APIKey{ID: testUserID, Prefix: "0a1b2c3d", Scopes: []string{entity.ScopeUsersRead}}, res)
+			}
+
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPIKeyRepository_Revoke(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	type testCase struct {
+		name        string
+		id          string
+		mockSetup   func(sqlmock.Sqlmock)
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "successful revoke",
+			id:   testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys" SET "revoked_at"=$1 WHERE id = $2 AND revoked_at IS NULL`)).
+					WithArgs(at, testUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "already revoked or unknown",
+			id:   testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			expectedErr: datastore.ErrNotFound,
+		},
+		{
+			name: "invalid id",
+			id:   "1",
+			// invalid ID isn't sent to the database
+			mockSetup:   func(mock sqlmock.Sqlmock) {},
+			expectedErr: datastore.ErrNotFound,
+		},
+		{
+			name: "database error",
+			id:   testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys"`)).
+					WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			ao.NoError(err)
+			defer db.Close()
+
+			tc.mockSetup(mock)
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{
+				Conn: db,
+			}), &gorm.Config{})
+			ao.NoError(err)
+
+			repo := NewAPIKeyRepository(gormDB)
+			err = repo.Revoke(context.Background(), tc.id, at)
+			if tc.expectedErr != nil {
+				ao.EqualError(err, tc.expectedErr.Error())
+			} else {
+				ao.NoError(err)
+			}
+
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"gorm.io/gorm"
+)
+
+// operationCallback creates gorm callback for the operation(create, query, update, delete, row, raw).
+type operationCallback func(operation string) func(db *gorm.DB)
+
+// registerAround registers callbacks before and after every gorm operation.
+func registerAround(db *gorm.DB, plugin string, before, after operationCallback) error {
+	cb := db.Callback()
+	type register func(name string, fn func(*gorm.DB)) error
+	operations := []struct {
+		name          string
+		before, after register
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, op := range operations {
+		if err := op.before(plugin+":before_"+op.name, before(op.name)); err != nil {
+			return err
+		}
+		if err := op.after(plugin+":after_"+op.name, after(op.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
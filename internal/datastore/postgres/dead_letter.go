@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore"
+	"test_task/internal/datastore/postgres/model"
+	"test_task/internal/entity"
+	"test_task/internal/pagination"
+)
+
+type DeadLetterRepository struct {
+	pgClient *gorm.DB
+}
+
+func NewDeadLetterRepository(pgClient *gorm.DB) *DeadLetterRepository {
+	return &DeadLetterRepository{pgClient: pgClient}
+}
+
+func (d *DeadLetterRepository) Add(ctx context.Context, letter entity.DeadLetter) (entity.DeadLetter, error) {
+	modelLetter := model.MapEntityDeadLetterToModelDeadLetter(letter)
+	err := conn(ctx, d.pgClient).Create(&modelLetter).Error
+	return model.MapModelDeadLetterToEntityDeadLetter(modelLetter), err
+}
+
+// List returns page of dead letters, the oldest first.
+func (d *DeadLetterRepository) List(ctx context.Context, page pagination.Pagination) ([]entity.DeadLetter, int64, error) {
+	var (
+		res   = make([]model.DeadLetter, 0)
+		total int64
+	)
+	err := conn(ctx, d.pgClient).Model(&model.DeadLetter{}).Count(&total).
+		Order("created_at, id").
+		Limit(page.Size).
+		Offset(pagination.CalculateOffset(page.Number, page.Size)).
+		Find(&res).Error
+	return model.MapModelDeadLettersToEntityDeadLetters(res), total, err
+}
+
+func (d *DeadLetterRepository) Get(ctx context.Context, id string) (entity.DeadLetter, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entity.DeadLetter{}, datastore.ErrNotFound
+	}
+	var res model.DeadLetter
+	err := conn(ctx, d.pgClient).Where("id = ?", id).Take(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.DeadLetter{}, datastore.ErrNotFound
+	}
+	return model.MapModelDeadLetterToEntityDeadLetter(res), err
+}
+
+// Delete removes dead letter. Returns datastore.ErrNotFound if there is no dead letter with id.
+func (d *DeadLetterRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return datastore.ErrNotFound
+	}
+	res := conn(ctx, d.pgClient).Delete(&model.DeadLetter{}, "id = ?", id)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return datastore.ErrNotFound
+	}
+	return res.Error
+}
+
+// Purge removes all dead letters.
+func (d *DeadLetterRepository) Purge(ctx context.Context) (int64, error) {
+	res := conn(ctx, d.pgClient).Where("1 = 1").Delete(&model.DeadLetter{})
+	return res.RowsAffected, res.Error
+}
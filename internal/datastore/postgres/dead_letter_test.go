@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/pagination"
+)
+
+func newDeadLetterRepository(t *testing.T) (*DeadLetterRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	return NewDeadLetterRepository(gormDB), mock
+}
+
+func TestDeadLetterRepository_Add(t *testing.T) {
+	ao := assert.New(t)
+	repo, mock := newDeadLetterRepository(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "dead_letters" ("key","type","payload","attempts","created_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs("42", "Update", []byte(`{}`), `[{"at":"2024-05-01T12:00:00Z","error":"push: timeout"}]`, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testUserID))
+	mock.ExpectCommit()
+
+	res, err := repo.Add(context.Background(), entity.DeadLetter{
+		Key:       "42",
+		Type:      "Update",
+		Payload:   []byte(`{}`),
+		Attempts:  []entity.DeliveryAttempt{{At: now, Error: "push: timeout"}},
+		CreatedAt: now,
+	})
+	ao.NoError(err)
+	ao.Equal(testUserID, res.ID)
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterRepository_List(t *testing.T) {
+	ao := assert.New(t)
+	repo, mock := newDeadLetterRepository(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "dead_letters"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "dead_letters" ORDER BY created_at, id LIMIT $1 OFFSET $2`)).
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "key", "type", "payload", "attempts", "created_at"}).
+			AddRow(testUserID, "42", "Delete", []byte(`{}`), []byte(`[{"at":"2024-05-01T12:00:00Z","error":"push: timeout"}]`), now))
+
+	res, total, err := repo.List(context.Background(), pagination.Pagination{Number: 2, Size: 2})
+	ao.NoError(err)
+	ao.Equal(int64(3), total)
+	ao.Equal([]entity.DeadLetter{{
+		ID:        testUserID,
+		Key:       "42",
+		Type:      "Delete",
+		Payload:   []byte(`{}`),
+		Attempts:  []entity.DeliveryAttempt{{At: now, Error: "push: timeout"}},
+		CreatedAt: now,
+	}}, res)
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterRepository_Get(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		mockSetup   func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "found",
+			id:   testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "dead_letters" WHERE id = $1 LIMIT $2`)).
+					WithArgs(testUserID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "attempts"}).AddRow(testUserID, []byte(`[]`)))
+			},
+		},
+		{
+			name: "not found",
+			id:   testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "dead_letters"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedErr: datastore.ErrNotFound,
+		},
+		{
+			name:        "invalid id",
+			id:          "1",
+			mockSetup:   func(mock sqlmock.Sqlmock) {},
+			expectedErr: datastore.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			repo, mock := newDeadLetterRepository(t)
+			tt.mockSetup(mock)
+
+			res, err := repo.Get(context.Background(), tt.id)
+			ao.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				ao.Equal(testUserID, res.ID)
+			}
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeadLetterRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		expectedErr  error
+	}{
+		{
+			name:         "deleted",
+			rowsAffected: 1,
+		},
+		{
+			name:        "not found",
+			expectedErr: datastore.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			repo, mock := newDeadLetterRepository(t)
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "dead_letters" WHERE id = $1`)).
+				WithArgs(testUserID).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			mock.ExpectCommit()
+
+			ao.ErrorIs(repo.Delete(context.Background(), testUserID), tt.expectedErr)
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeadLetterRepository_Purge(t *testing.T) {
+	ao := assert.New(t)
+	repo, mock := newDeadLetterRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "dead_letters" WHERE 1 = 1`)).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Purge(context.Background())
+	ao.NoError(err)
+	ao.Equal(int64(5), deleted)
+	ao.NoError(mock.ExpectationsWereMet())
+}
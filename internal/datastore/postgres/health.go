@@ -19,5 +19,5 @@ func (h *Health) Health(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	return db.Ping()
+	return db.PingContext(ctx)
 }
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+)
+
+const (
+	DefaultReconnectBackoff    = time.Second
+	DefaultMaxReconnectBackoff = 30 * time.Second
+)
+
+// ErrNotListening is returned by Listener.Health while the channel isn't listened.
+var ErrNotListening = errors.New("not listening")
+
+// Handler handles payload of notification received by Listener.
+type Handler func(ctx context.Context, payload []byte)
+
+// listenConn is a dedicated connection, since LISTEN is bound to a session.
+type listenConn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// Listener subscribes to the channel with LISTEN and dispatches notifications to handlers.
+// Notifications sent while connection is lost are not received.
+type Listener struct {
+	dsn      string
+	cfg      config.NotificationListener
+	connect  func(ctx context.Context, dsn string) (listenConn, error)
+	handlers []Handler
+	logger   logger.Logger
+
+	mu        sync.RWMutex
+	listening bool
+	// connErr is the reason of the last failed connect or disconnect
+	connErr error
+}
+
+// NewListener creates listener of cfg.Channel, zero backoffs are replaced with defaults.
+func NewListener(dsn string, cfg config.NotificationListener, l logger.Logger) *Listener {
+	if cfg.ReconnectBackoff <= 0 {
+		cfg.ReconnectBackoff = DefaultReconnectBackoff
+	}
+	if cfg.MaxReconnectBackoff < cfg.ReconnectBackoff {
+		cfg.MaxReconnectBackoff = max(DefaultMaxReconnectBackoff, cfg.ReconnectBackoff)
+	}
+	return &Listener{
+		dsn: dsn,
+		cfg: cfg,
+		connect: func(ctx context.Context, dsn string) (listenConn, error) {
+			return pgx.Connect(ctx, dsn)
+		},
+		logger: l,
+	}
+}
+
+// Handle adds handler, it must be called before Start. Handlers are called sequentially in order of notifications.
+func (l *Listener) Handle(h Handler) {
+	l.handlers = append(l.handlers, h)
+}
+
+// Start listens and reconnects with backoff until ctx is done.
+func (l *Listener) Start(ctx context.Context) {
+	log := l.logger.WithFields(logger.Fields{logger.FieldOperation: "postgres listen", "channel": l.cfg.Channel})
+	backoff := l.cfg.ReconnectBackoff
+	for {
+		conn, err := l.listen(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.setListening(false, err)
+			log.WithFields(logger.Fields{"retry_in": backoff.String()}).Error(err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, l.cfg.MaxReconnectBackoff)
+			continue
+		}
+		backoff = l.cfg.ReconnectBackoff
+		l.setListening(true, nil)
+		log.Info("postgres channel is listened")
+
+		err = l.receive(ctx, conn)
+		closeCtx, closeCtxCancel := context.WithTimeout(context.Background(), time.Second)
+		_ = conn.Close(closeCtx)
+		closeCtxCancel()
+		if ctx.Err() != nil {
+			l.setListening(false, nil)
+			return
+		}
+		l.setListening(false, err)
+		log.Warn(err)
+	}
+}
+
+// listen opens connection and subscribes to the channel.
+func (l *Listener) listen(ctx context.Context) (listenConn, error) {
+	conn, err := l.connect(ctx, l.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.cfg.Channel}.Sanitize()); err != nil {
+		_ = conn.Close(ctx)
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	return conn, nil
+}
+
+// receive dispatches notifications until connection fails or ctx is done.
+func (l *Listener) receive(ctx context.Context, conn listenConn) error {
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		l.dispatch(ctx, []byte(n.Payload))
+	}
+}
+
+func (l *Listener) dispatch(ctx context.Context, payload []byte) {
+	for _, h := range l.handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					l.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "postgres listen", "channel": l.cfg.Channel}).
+						Error(fmt.Errorf("handler panic: %v", r))
+				}
+			}()
+			h(ctx, payload)
+		}()
+	}
+}
+
+func (l *Listener) setListening(listening bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listening, l.connErr = listening, err
+}
+
+// Health reports whether the channel is listened.
+func (l *Listener) Health(_ context.Context) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.listening {
+		return nil
+	}
+	if l.connErr != nil {
+		return fmt.Errorf("%w: %w", ErrNotListening, l.connErr)
+	}
+	return ErrNotListening
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+)
+
+type fakeListenConn struct {
+	notifications chan string
+	execErr       error
+
+	mu     sync.Mutex
+	execs  []string
+	closed bool
+}
+
+func newFakeListenConn() *fakeListenConn {
+	return &fakeListenConn{notifications: make(chan string)}
+}
+
+func (c *fakeListenConn) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.execs = append(c.execs, sql)
+	return pgconn.CommandTag{}, c.execErr
+}
+
+// WaitForNotification returns notifications sent to the channel, connection fails when the channel is closed.
+func (c *fakeListenConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case payload, ok := <-c.notifications:
+		if !ok {
+			return nil, errors.New("conn closed")
+		}
+		return &pgconn.Notification{Channel: "users", Payload: payload}, nil
+	}
+}
+
+func (c *fakeListenConn) Close(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeListenConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func TestListener_Start(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info("postgres channel is listened").Times(2)
+	mockLogger.EXPECT().Warn(fmt.Errorf("wait for notification: %w", errors.New("conn closed")))
+	mockLogger.EXPECT().Error(fmt.Errorf("handler panic: %v", "boom"))
+
+	conns := []*fakeListenConn{newFakeListenConn(), newFakeListenConn()}
+	l := NewListener("dsn", config.NotificationListener{Channel: "users"}, mockLogger)
+	connects := 0
+	l.connect = func(_ context.Context, dsn string) (listenConn, error) {
+		ao.Equal("dsn", dsn)
+		conn := conns[connects]
+		connects++
+		return conn, nil
+	}
+	handled := make(chan string)
+	l.Handle(func(_ context.Context, payload []byte) {
+		if string(payload) == "panic" {
+			panic("boom")
+		}
+	})
+	l.Handle(func(_ context.Context, payload []byte) {
+		handled <- string(payload)
+	})
+	ao.ErrorIs(l.Health(context.Background()), ErrNotListening)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		l.Start(ctx)
+		close(stopped)
+	}()
+
+	var received []string
+	for _, payload := range []string{"a", "panic"} {
+		conns[0].notifications <- payload
+		received = append(received, <-handled)
+	}
+	ao.NoError(l.Health(ctx))
+
+	// lost connection is restored
+	close(conns[0].notifications)
+	conns[1].notifications <- "b"
+	received = append(received, <-handled)
+	ao.Equal([]string{"a", "panic", "b"}, received)
+	ao.True(conns[0].isClosed())
+	ao.Equal([]string{`LISTEN "users"`}, conns[1].execs)
+
+	cancel()
+	<-stopped
+	ao.True(conns[1].isClosed())
+	ao.Equal(ErrNotListening, l.Health(context.Background()))
+}
+
+func TestListener_Reconnect(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockLogger := logger.NewMockLogger(ctrl)
+	l := NewListener("dsn", config.NotificationListener{Channel: "users", ReconnectBackoff: time.Millisecond, MaxReconnectBackoff: 2 * time.Millisecond}, mockLogger)
+	conn := newFakeListenConn()
+	conn.execErr = errors.New("permission denied")
+	connErr := errors.New("connection refused")
+	connects := 0
+	l.connect = func(context.Context, string) (listenConn, error) {
+		connects++
+		if connects == 1 {
+			return conn, nil
+		}
+		return nil, connErr
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockLogger.EXPECT().WithFields(logger.Fields{logger.FieldOperation: "postgres listen", "channel": "users"}).Return(mockLogger)
+	gomock.InOrder(
+		mockLogger.EXPECT().WithFields(logger.Fields{"retry_in": "1ms"}).Return(mockLogger),
+		mockLogger.EXPECT().WithFields(logger.Fields{"retry_in": "2ms"}).Return(mockLogger),
+		mockLogger.EXPECT().WithFields(logger.Fields{"retry_in": "2ms"}).DoAndReturn(func(logger.Fields) logger.Logger {
+			cancel()
+			return mockLogger
+		}),
+	)
+	mockLogger.EXPECT().Error(fmt.Errorf("listen: %w", conn.execErr))
+	mockLogger.EXPECT().Error(fmt.Errorf("connect: %w", connErr)).Times(2)
+
+	l.Start(ctx)
+	ao.Equal(3, connects)
+	ao.True(conn.isClosed())
+	err := l.Health(ctx)
+	ao.ErrorIs(err, ErrNotListening)
+	ao.ErrorIs(err, connErr)
+}
+
+func TestNewListener(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.NotificationListener
+		expected config.NotificationListener
+	}{
+		{
+			name:     "defaults",
+			cfg:      config.NotificationListener{Channel: "users"},
+			expected: config.NotificationListener{Channel: "users", ReconnectBackoff: DefaultReconnectBackoff, MaxReconnectBackoff: DefaultMaxReconnectBackoff},
+		},
+		{
+			name:     "max backoff is not less than backoff",
+			cfg:      config.NotificationListener{Channel: "users", ReconnectBackoff: time.Minute},
+			expected: config.NotificationListener{Channel: "users", ReconnectBackoff: time.Minute, MaxReconnectBackoff: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewListener("dsn", tt.cfg, nil)
+			require.NotNil(t, l.connect)
+			assert.Equal(t, tt.expected, l.cfg)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore/postgres/model"
+	"test_task/internal/entity"
+)
+
+// registerFailureStmt increments failures counter. Counter starts over if the previous failure is out of window or lockout has expired.
+const registerFailureStmt = `INSERT INTO login_attempts (key, failures, last_failure_at) VALUES (@key, 1, @at)
+ON CONFLICT (key) DO UPDATE SET
+    failures = CASE WHEN login_attempts.last_failure_at < @windowStart OR login_attempts.locked_until <= @at
+        THEN 1 ELSE login_attempts.failures + 1 END,
+    locked_until = CASE WHEN login_attempts.locked_until <= @at THEN NULL ELSE login_attempts.locked_until END,
+    last_failure_at = EXCLUDED.last_failure_at
+RETURNING key, failures, last_failure_at, locked_until`
+
+type LoginAttemptRepository struct {
+	pgClient *gorm.DB
+}
+
+func NewLoginAttemptRepository(pgClient *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{pgClient: pgClient}
+}
+
+func (l *LoginAttemptRepository) Get(ctx context.Context, keys ...string) ([]entity.LoginAttempt, error) {
+	res := make([]model.LoginAttempt, 0, len(keys))
+	err := conn(ctx, l.pgClient).Where("key IN ?", keys).Find(&res).Error
+	return model.MapModelLoginAttemptsToEntityLoginAttempts(res), err
+}
+
+func (l *LoginAttemptRepository) RegisterFailure(ctx context.Context, key string, at time.Time, window time.Duration) (entity.LoginAttempt, error) {
+	var res model.LoginAttempt
+	err := conn(ctx, l.pgClient).Raw(registerFailureStmt, map[string]interface{}{
+		"key":         key,
+		"at":          at,
+		"windowStart": at.Add(-window),
+	}).Scan(&res).Error
+	return model.MapModelLoginAttemptToEntityLoginAttempt(res), err
+}
+
+func (l *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	return conn(ctx, l.pgClient).Model(&model.LoginAttempt{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (l *LoginAttemptRepository) Reset(ctx context.Context, keys ...string) error {
+	return conn(ctx, l.pgClient).Delete(&model.LoginAttempt{}, "key IN ?", keys).Error
+}
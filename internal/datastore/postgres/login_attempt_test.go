@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"test_task/internal/entity"
+)
+
+func TestLoginAttemptRepository_Get(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	type testCase struct {
+		name        string
+		mockSetup   func(sqlmock.Sqlmock)
+		expectedRes []entity.LoginAttempt
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "successful retrieval",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_attempts" WHERE key IN ($1,$2)`)).
+					WithArgs("account:jdoe", "ip:10.0.0.1").
+					WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}).
+						AddRow("account:jdoe", 2, at, nil))
+			},
+			expectedRes: []entity.LoginAttempt{{Key: "account:jdoe", Failures: 2, LastFailureAt: at}},
+		},
+		{
+			name: "database error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_attempts"`)).
+					WillReturnError(errors.New("db error"))
+			},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			ao.NoError(err)
+			defer db.Close()
+
+			tc.mockSetup(mock)
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{
+				Conn: db,
+			}), &gorm.Config{})
+			ao.NoError(err)
+
+			repo := NewLoginAttemptRepository(gormDB)
+			res, err := repo.Get(context.Background(), "account:jdoe", "ip:10.0.0.1")
+			if tc.expectedErr != nil {
+				ao.EqualError(err, tc.expectedErr.Error())
+			} else {
+				ao.NoError(err)
+				ao.Equal(tc.expectedRes, res)
+			}
+
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoginAttemptRepository_RegisterFailure(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $2)`)).
+		WithArgs("account:jdoe", at, at.Add(-time.Minute), at, at).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}).
+			AddRow("account:jdoe", 3, at, nil))
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewLoginAttemptRepository(gormDB)
+	res, err := repo.RegisterFailure(context.Background(), "account:jdoe", at, time.Minute)
+	ao.NoError(err)
+	ao.Equal(entity.LoginAttempt{Key: "account:jdoe", Failures: 3, LastFailureAt: at}, res)
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptRepository_Lock(t *testing.T) {
+	until := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "login_attempts" SET "locked_until"=$1 WHERE key = $2`)).
+		WithArgs(until, "account:jdoe").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewLoginAttemptRepository(gormDB)
+	ao.NoError(repo.Lock(context.Background(), "account:jdoe", until))
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptRepository_Reset(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_attempts" WHERE key IN ($1,$2)`)).
+		WithArgs("account:jdoe", "ip:10.0.0.1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewLoginAttemptRepository(gormDB)
+	ao.NoError(repo.Reset(context.Background(), "account:jdoe", "ip:10.0.0.1"))
+	ao.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "query_metrics:start"
+
+// QueryObserver records database queries.
+type QueryObserver interface {
+	ObserveQuery(operation, table string, d time.Duration, err error)
+}
+
+// QueryMetrics is gorm plugin which measures query duration by operation.
+type QueryMetrics struct {
+	observer QueryObserver
+}
+
+func NewQueryMetrics(observer QueryObserver) *QueryMetrics {
+	return &QueryMetrics{observer: observer}
+}
+
+func (*QueryMetrics) Name() string {
+	return "query_metrics"
+}
+
+// Initialize registers callbacks around every gorm operation.
+func (q *QueryMetrics) Initialize(db *gorm.DB) error {
+	return registerAround(db, q.Name(), q.before, q.after)
+}
+
+func (q *QueryMetrics) before(string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		db.InstanceSet(queryStartKey, time.Now())
+	}
+}
+
+func (q *QueryMetrics) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, _ := v.(time.Time)
+		q.observer.ObserveQuery(operation, db.Statement.Table, time.Since(start), queryError(db))
+	}
+}
+
+// queryError returns query error, not found isn't a failure of the query.
+func queryError(db *gorm.DB) error {
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return db.Error
+}
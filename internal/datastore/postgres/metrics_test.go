@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore"
+)
+
+type observedQuery struct {
+	operation string
+	table     string
+	err       error
+}
+
+type fakeQueryObserver struct {
+	mu      sync.Mutex
+	queries []observedQuery
+}
+
+func (f *fakeQueryObserver) ObserveQuery(operation, table string, _ time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, observedQuery{operation: operation, table: table, err: err})
+}
+
+func TestQueryMetrics(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 LIMIT $2`)).
+		WithArgs(testUserID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE id = $1`)).
+		WithArgs(testUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	observer := &fakeQueryObserver{}
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+	ao.NoError(gormDB.Use(NewQueryMetrics(observer)))
+
+	repo := NewUserRepository(gormDB)
+	_, err = repo.GetByID(context.Background(), testUserID)
+	ao.ErrorIs(err, datastore.ErrNotFound)
+	ao.NoError(repo.Delete(context.Background(), testUserID))
+
+	ao.Equal([]observedQuery{
+		{operation: "query", table: "users"},
+		{operation: "delete", table: "users"},
+	}, observer.queries)
+	ao.NoError(mock.ExpectationsWereMet())
+}
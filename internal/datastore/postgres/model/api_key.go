@@ -0,0 +1,65 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test_task/internal/entity"
+)
+
+const scopesSeparator = ","
+
+type APIKey struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid()"`
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
+func MapEntityAPIKeyToModelAPIKey(key entity.APIKey) APIKey {
+	return APIKey{
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.Hash,
+		Scopes:    strings.Join(key.Scopes, scopesSeparator),
+		ExpiresAt: nullTime(key.ExpiresAt),
+	}
+}
+
+func MapModelAPIKeyToEntityAPIKey(key APIKey) entity.APIKey {
+	var scopes []string
+	if key.Scopes != "" {
+		scopes = strings.Split(key.Scopes, scopesSeparator)
+	}
+	return entity.APIKey{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Hash:       key.KeyHash,
+		Scopes:     scopes,
+		ExpiresAt:  key.ExpiresAt.Time,
+		LastUsedAt: key.LastUsedAt.Time,
+		RevokedAt:  key.RevokedAt.Time,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func MapModelAPIKeysToEntityAPIKeys(keys []APIKey) []entity.APIKey {
+	res := make([]entity.APIKey, 0, len(keys))
+	for _, v := range keys {
+		res = append(res, MapModelAPIKeyToEntityAPIKey(v))
+	}
+	return res
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"test_task/internal/entity"
+)
+
+type DeadLetter struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid()"`
+	Key       string
+	Type      string
+	Payload   []byte
+	Attempts  []DeliveryAttempt `gorm:"serializer:json"`
+	CreatedAt time.Time
+}
+
+// DeliveryAttempt is stored in jsonb array of dead letter attempts.
+type DeliveryAttempt struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error"`
+}
+
+func MapEntityDeadLetterToModelDeadLetter(letter entity.DeadLetter) DeadLetter {
+	attempts := make([]DeliveryAttempt, 0, len(letter.Attempts))
+	for _, v := range letter.Attempts {
+		attempts = append(attempts, DeliveryAttempt{At: v.At, Error: v.Error})
+	}
+	return DeadLetter{
+		Key:       letter.Key,
+		Type:      letter.Type,
+		Payload:   letter.Payload,
+		Attempts:  attempts,
+		CreatedAt: letter.CreatedAt,
+	}
+}
+
+func MapModelDeadLetterToEntityDeadLetter(letter DeadLetter) entity.DeadLetter {
+	attempts := make([]entity.DeliveryAttempt, 0, len(letter.Attempts))
+	for _, v := range letter.Attempts {
+		attempts = append(attempts, entity.DeliveryAttempt{At: v.At, Error: v.Error})
+	}
+	return entity.DeadLetter{
+		ID:        letter.ID.String(),
+		Key:       letter.Key,
+		Type:      letter.Type,
+		Payload:   letter.Payload,
+		Attempts:  attempts,
+		CreatedAt: letter.CreatedAt,
+	}
+}
+
+func MapModelDeadLettersToEntityDeadLetters(letters []DeadLetter) []entity.DeadLetter {
+	res := make([]entity.DeadLetter, 0, len(letters))
+	for _, v := range letters {
+		res = append(res, MapModelDeadLetterToEntityDeadLetter(v))
+	}
+	return res
+}
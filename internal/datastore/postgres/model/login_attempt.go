@@ -0,0 +1,32 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"test_task/internal/entity"
+)
+
+type LoginAttempt struct {
+	Key           string `gorm:"primaryKey"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+func MapModelLoginAttemptToEntityLoginAttempt(attempt LoginAttempt) entity.LoginAttempt {
+	return entity.LoginAttempt{
+		Key:           attempt.Key,
+		Failures:      attempt.Failures,
+		LastFailureAt: attempt.LastFailureAt,
+		LockedUntil:   attempt.LockedUntil.Time,
+	}
+}
+
+func MapModelLoginAttemptsToEntityLoginAttempts(attempts []LoginAttempt) []entity.LoginAttempt {
+	res := make([]entity.LoginAttempt, 0, len(attempts))
+	for _, v := range attempts {
+		res = append(res, MapModelLoginAttemptToEntityLoginAttempt(v))
+	}
+	return res
+}
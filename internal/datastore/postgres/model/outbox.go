@@ -0,0 +1,59 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"test_task/internal/entity"
+)
+
+type OutboxMessage struct {
+	ID             int64 `gorm:"primaryKey"`
+	Key            string
+	Type           string
+	Payload        []byte
+	Attempts       int
+	LastError      string
+	DeliveredSinks []string `gorm:"serializer:json"`
+	NextAttemptAt  time.Time
+	LockedUntil    sql.NullTime
+	SentAt         sql.NullTime
+	CreatedAt      time.Time
+}
+
+// TableName overrides gorm pluralized table name.
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+func MapEntityOutboxMessageToModelOutboxMessage(message entity.OutboxMessage) OutboxMessage {
+	return OutboxMessage{
+		ID:             message.ID,
+		Key:            message.Key,
+		Type:           message.Type,
+		Payload:        message.Payload,
+		Attempts:       message.Attempts,
+		DeliveredSinks: message.DeliveredSinks,
+		CreatedAt:      message.CreatedAt,
+	}
+}
+
+func MapModelOutboxMessageToEntityOutboxMessage(message OutboxMessage) entity.OutboxMessage {
+	return entity.OutboxMessage{
+		ID:             message.ID,
+		Key:            message.Key,
+		Type:           message.Type,
+		Payload:        message.Payload,
+		Attempts:       message.Attempts,
+		DeliveredSinks: message.DeliveredSinks,
+		CreatedAt:      message.CreatedAt,
+	}
+}
+
+func MapModelOutboxMessagesToEntityOutboxMessages(messages []OutboxMessage) []entity.OutboxMessage {
+	res := make([]entity.OutboxMessage, 0, len(messages))
+	for _, v := range messages {
+		res = append(res, MapModelOutboxMessageToEntityOutboxMessage(v))
+	}
+	return res
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PasswordHistory struct {
+	ID           int64 `gorm:"primaryKey"`
+	UserID       uuid.UUID
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+// TableName overrides gorm pluralized table name.
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// MaxNotifyPayload is the limit of NOTIFY payload in bytes.
+const MaxNotifyPayload = 7999
+
+// Notifier publishes notifications with pg_notify. Notification pushed in a transaction is delivered on commit.
+type Notifier struct {
+	pgClient *gorm.DB
+	channel  string
+}
+
+func NewNotifier(pgClient *gorm.DB, channel string) *Notifier {
+	return &Notifier{pgClient: pgClient, channel: channel}
+}
+
+func (n *Notifier) Push(ctx context.Context, data []byte) error {
+	if len(data) > MaxNotifyPayload {
+		return fmt.Errorf("payload of %d bytes exceeds %d bytes", len(data), MaxNotifyPayload)
+	}
+	return conn(ctx, n.pgClient).Exec("SELECT pg_notify(?, ?)", n.channel, string(data)).Error
+}
+
+func (n *Notifier) Health(ctx context.Context) error {
+	return NewHealth(n.pgClient).Health(ctx)
+}
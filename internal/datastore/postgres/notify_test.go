@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestNotifier_Push(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		inTx        bool
+		mockSetup   func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			data: `{"Type":"Insert"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_notify($1, $2)`)).WithArgs("users", `{"Type":"Insert"}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "joins transaction",
+			data: `{"Type":"Delete"}`,
+			inTx: true,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_notify($1, $2)`)).WithArgs("users", `{"Type":"Delete"}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "db error",
+			data: `{"Type":"Insert"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_notify($1, $2)`)).WillReturnError(errors.New("db error"))
+			},
+			expectedErr: errors.New("db error"),
+		},
+		{
+			name:        "payload is too large",
+			data:        strings.Repeat("a", MaxNotifyPayload+1),
+			mockSetup:   func(mock sqlmock.Sqlmock) {},
+			expectedErr: errors.New("payload of 8000 bytes exceeds 7999 bytes"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			tt.mockSetup(mock)
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+			require.NoError(t, err)
+
+			n := NewNotifier(gormDB, "users")
+			if tt.inTx {
+				err = NewTransactor(gormDB).InTransaction(context.Background(), func(ctx context.Context) error {
+					return n.Push(ctx, []byte(tt.data))
+				})
+			} else {
+				err = n.Push(context.Background(), []byte(tt.data))
+			}
+			if tt.expectedErr != nil {
+				ao.EqualError(err, tt.expectedErr.Error())
+			} else {
+				ao.NoError(err)
+			}
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
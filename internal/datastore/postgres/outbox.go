@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore/postgres/model"
+	"test_task/internal/entity"
+)
+
+// claimStmt locks due messages for lease, so concurrent relays don't publish the same message.
+// Message is claimed only if there are no earlier unsent messages with the same key, it keeps per key order.
+const claimStmt = `UPDATE outbox SET locked_until = @lockedUntil
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.sent_at IS NULL AND o.next_attempt_at <= @now AND (o.locked_until IS NULL OR o.locked_until <= @now)
+        AND NOT EXISTS (SELECT 1 FROM outbox p WHERE p.key = o.key AND p.sent_at IS NULL AND p.id < o.id)
+    ORDER BY o.id
+    LIMIT @limit
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, key, type, payload, attempts, delivered_sinks, created_at`
+
+type OutboxRepository struct {
+	pgClient *gorm.DB
+}
+
+func NewOutboxRepository(pgClient *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{pgClient: pgClient}
+}
+
+// Add writes message, it takes part in the transaction stored in ctx.
+func (o *OutboxRepository) Add(ctx context.Context, message entity.OutboxMessage) error {
+	modelMessage := model.MapEntityOutboxMessageToModelOutboxMessage(message)
+	modelMessage.NextAttemptAt = modelMessage.CreatedAt
+	if modelMessage.DeliveredSinks == nil {
+		modelMessage.DeliveredSinks = []string{}
+	}
+	return conn(ctx, o.pgClient).Create(&modelMessage).Error
+}
+
+// Claim locks up to limit messages due at now until now+lease, the oldest first.
+func (o *OutboxRepository) Claim(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]entity.OutboxMessage, error) {
+	res := make([]model.OutboxMessage, 0, limit)
+	err := conn(ctx, o.pgClient).Raw(claimStmt, map[string]interface{}{
+		"now":         now,
+		"lockedUntil": now.Add(lease),
+		"limit":       limit,
+	}).Scan(&res).Error
+	return model.MapModelOutboxMessagesToEntityOutboxMessages(res), err
+}
+
+func (o *OutboxRepository) MarkSent(ctx context.Context, id int64, at time.Time) error {
+	return conn(ctx, o.pgClient).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"sent_at": at, "locked_until": nil}).Error
+}
+
+// MarkFailed releases message and schedules the next attempt, deliveredSinks aren't pushed on it.
+func (o *OutboxRepository) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string,
+	deliveredSinks []string) error {
+	if deliveredSinks == nil {
+		deliveredSinks = []string{}
+	}
+	delivered, err := json.Marshal(deliveredSinks)
+	if err != nil {
+		return fmt.Errorf("marshal delivered sinks: %w", err)
+	}
+	return conn(ctx, o.pgClient).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+			"delivered_sinks": string(delivered),
+			"locked_until":    nil,
+		}).Error
+}
+
+// MarkDeadLettered completes message moved to dead letters after the last failed attempt, so it no longer blocks
+// the following messages with the same key. It is removed with sent messages.
+func (o *OutboxRepository) MarkDeadLettered(ctx context.Context, id int64, attempts int, at time.Time, lastError string) error {
+	return conn(ctx, o.pgClient).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":     attempts,
+			"last_error":   lastError,
+			"sent_at":      at,
+			"locked_until": nil,
+		}).Error
+}
+
+// DeleteSent removes messages sent before.
+func (o *OutboxRepository) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	res := conn(ctx, o.pgClient).Delete(&model.OutboxMessage{}, "sent_at < ?", before)
+	return res.RowsAffected, res.Error
+}
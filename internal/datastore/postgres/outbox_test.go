@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"test_task/internal/entity"
+)
+
+func newOutboxRepository(t *testing.T) (*OutboxRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	return NewOutboxRepository(gormDB), mock
+}
+
+func TestOutboxRepository_Add(t *testing.T) {
+	ao := assert.New(t)
+	repo, mock := newOutboxRepository(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox" ("key","type","payload","attempts","last_error","delivered_sinks","next_attempt_at","locked_until","sent_at","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING "id"`)).
+		WithArgs("1", "Insert", []byte(`{}`), 0, "", "[]", now, nil, nil, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	ao.NoError(repo.Add(context.Background(), entity.OutboxMessage{Key: "1", Type: "Insert", Payload: []byte(`{}`), CreatedAt: now}))
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Claim(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		mockSetup   func(mock sqlmock.Sqlmock)
+		expectedRes []entity.OutboxMessage
+		expectedErr error
+	}{
+		{
+			name: "success",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`UPDATE outbox SET locked_until = $1`)).
+					WithArgs(now.Add(time.Minute), now, now, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "type", "payload", "attempts", "delivered_sinks", "created_at"}).
+						AddRow(1, "1", "Insert", []byte(`{}`), 0, []byte(`[]`), now).
+						AddRow(2, "2", "Delete", []byte(`{}`), 3, []byte(`["kafka"]`), now))
+			},
+			expectedRes: []entity.OutboxMessage{
+				{ID: 1, Key: "1", Type: "Insert", Payload: []byte(`{}`), DeliveredSinks: []string{}, CreatedAt: now},
+				{ID: 2, Key: "2", Type: "Delete", Payload: []byte(`{}`), Attempts: 3, DeliveredSinks: []string{"kafka"}, CreatedAt: now},
+			},
+		},
+		{
+			name: "database error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`UPDATE outbox SET locked_until = $1`)).WillReturnError(errors.New("db error"))
+			},
+			expectedRes: []entity.OutboxMessage{},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			repo, mock := newOutboxRepository(t)
+			tt.mockSetup(mock)
+
+			res, err := repo.Claim(context.Background(), 10, now, time.Minute)
+			if tt.expectedErr != nil {
+				ao.EqualError(err, tt.expectedErr.Error())
+			} else {
+				ao.NoError(err)
+			}
+			ao.Equal(tt.expectedRes, res)
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOutboxRepository_Mark(t *testing.T) {
+	ao := assert.New(t)
+	repo, mock := newOutboxRepository(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox" SET "locked_until"=$1,"sent_at"=$2 WHERE id = $3`)).
+		WithArgs(nil, now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox" SET "attempts"=$1,"delivered_sinks"=$2,"last_error"=$3,"locked_until"=$4,"next_attempt_at"=$5 WHERE id = $6`)).
+		WithArgs(2, `["kafka"]`, "push: timeout", nil, now.Add(time.Minute), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox" SET "attempts"=$1,"last_error"=$2,"locked_until"=$3,"sent_at"=$4 WHERE id = $5`)).
+		WithArgs(10, "push: timeout", nil, now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "outbox" WHERE sent_at < $1`)).
+		WithArgs(now.Add(-time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectCommit()
+
+	ao.NoError(repo.MarkSent(context.Background(), 1, now))
+	ao.NoError(repo.MarkFailed(context.Background(), 1, 2, now.Add(time.Minute), "push: timeout", []string{"kafka"}))
+	ao.NoError(repo.MarkDeadLettered(context.Background(), 1, 10, now, "push: timeout"))
+	deleted, err := repo.DeleteSent(context.Background(), now.Add(-time.Hour))
+	ao.NoError(err)
+	ao.Equal(int64(5), deleted)
+	ao.NoError(mock.ExpectationsWereMet())
+}
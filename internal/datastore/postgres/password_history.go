@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"test_task/internal/datastore/postgres/model"
+)
+
+type PasswordHistoryRepository struct {
+	pgClient *gorm.DB
+}
+
+func NewPasswordHistoryRepository(pgClient *gorm.DB) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{pgClient: pgClient}
+}
+
+// GetRecent returns up to limit password hashes of user, the newest first.
+func (p *PasswordHistoryRepository) GetRecent(ctx context.Context, userID string, limit int) ([]string, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user ID is not uuid compatible: %w", err)
+	}
+	res := make([]string, 0, limit)
+	err = conn(ctx, p.pgClient).Model(&model.PasswordHistory{}).
+		Where("user_id = ?", id).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("password_hash", &res).Error
+	return res, err
+}
+
+func (p *PasswordHistoryRepository) Add(ctx context.Context, userID string, passwordHash string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("user ID is not uuid compatible: %w", err)
+	}
+	return conn(ctx, p.pgClient).Create(&model.PasswordHistory{UserID: id, PasswordHash: passwordHash}).Error
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const testUserID = "3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85"
+
+func TestPasswordHistoryRepository_GetRecent(t *testing.T) {
+	type testCase struct {
+		name        string
+		userID      string
+		mockSetup   func(sqlmock.Sqlmock)
+		expectedRes []string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name:   "successful retrieval",
+			userID: testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "password_hash" FROM "password_history" WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2`)).
+					WithArgs(testUserID, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"password_hash"}).AddRow("hash2").AddRow("hash1"))
+			},
+			expectedRes: []string{"hash2", "hash1"},
+		},
+		{
+			name:        "invalid user ID",
+			userID:      "invalid-uuid",
+			mockSetup:   func(mock sqlmock.Sqlmock) {},
+			expectedErr: errors.New("user ID is not uuid compatible: invalid UUID length: 12"),
+		},
+		{
+			name:   "database error",
+			userID: testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "password_hash" FROM "password_history"`)).
+					WillReturnError(errors.New("db error"))
+			},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			ao.NoError(err)
+			defer db.Close()
+
+			tc.mockSetup(mock)
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{
+				Conn: db,
+			}), &gorm.Config{})
+			ao.NoError(err)
+
+			repo := NewPasswordHistoryRepository(gormDB)
+			res, err := repo.GetRecent(context.Background(), tc.userID, 2)
+			if tc.expectedErr != nil {
+				ao.EqualError(err, tc.expectedErr.Error())
+			} else {
+				ao.NoError(err)
+				ao.Equal(tc.expectedRes, res)
+			}
+
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPasswordHistoryRepository_Add(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "password_history" ("user_id","password_hash","created_at") VALUES ($1,$2,$3) RETURNING "id"`)).
+		WithArgs(testUserID, "hash", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewPasswordHistoryRepository(gormDB)
+	ao.NoError(repo.Add(context.Background(), testUserID, "hash"))
+	ao.NoError(mock.ExpectationsWereMet())
+}
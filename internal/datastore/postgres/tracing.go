@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"test_task/internal/tracing"
+)
+
+const (
+	tracerName   = "test_task/internal/datastore/postgres"
+	querySpanKey = "query_tracing:span"
+)
+
+// QueryTracing is gorm plugin which creates span for every query.
+// Statement is recorded with placeholders, without values.
+type QueryTracing struct{}
+
+func NewQueryTracing() *QueryTracing {
+	return &QueryTracing{}
+}
+
+func (*QueryTracing) Name() string {
+	return "query_tracing"
+}
+
+// Initialize registers callbacks around every gorm operation.
+func (q *QueryTracing) Initialize(db *gorm.DB) error {
+	return registerAround(db, q.Name(), q.before, q.after)
+}
+
+func (q *QueryTracing) before(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := tracing.Tracer(tracerName).Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(operation)),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+func (q *QueryTracing) after(string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(querySpanKey)
+		if !ok {
+			return
+		}
+		span, ok := v.(trace.Span)
+		if !ok {
+			return
+		}
+		span.SetAttributes(
+			semconv.DBCollectionName(db.Statement.Table),
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+		tracing.End(span, queryError(db))
+	}
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txContextKey struct{}
+
+// Transactor runs functions in a transaction, repositories join the transaction stored in ctx.
+type Transactor struct {
+	pgClient *gorm.DB
+}
+
+func NewTransactor(pgClient *gorm.DB) *Transactor {
+	return &Transactor{pgClient: pgClient}
+}
+
+// InTransaction runs fn in a transaction, it is committed if fn returns nil.
+// Nested call joins the outer transaction.
+func (t *Transactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return t.pgClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// conn returns transaction stored in ctx or pgClient, bound to ctx.
+func conn(ctx context.Context, pgClient *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return pgClient.WithContext(ctx)
+}
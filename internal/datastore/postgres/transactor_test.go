@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"test_task/internal/entity"
+)
+
+func TestTransactor_InTransaction(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		mockSetup   func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "change and outbox message are committed together",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE id = $1`)).WithArgs(testUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "outbox failure rolls change back",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE id = $1`)).WithArgs(testUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox"`)).WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			tt.mockSetup(mock)
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+			require.NoError(t, err)
+
+			users, outbox := NewUserRepository(gormDB), NewOutboxRepository(gormDB)
+			err = NewTransactor(gormDB).InTransaction(context.Background(), func(ctx context.Context) error {
+				if err := users.Delete(ctx, testUserID); err != nil {
+					return err
+				}
+				// nested call joins the transaction
+				return NewTransactor(gormDB).InTransaction(ctx, func(ctx context.Context) error {
+					return outbox.Add(ctx, entity.OutboxMessage{Key: testUserID, Type: "Delete", Payload: []byte(`{}`), CreatedAt: now})
+				})
+			})
+			if tt.expectedErr != nil {
+				ao.EqualError(err, tt.expectedErr.Error())
+			} else {
+				ao.NoError(err)
+			}
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
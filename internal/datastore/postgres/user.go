@@ -2,10 +2,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"test_task/internal/datastore"
 	"test_task/internal/datastore/postgres/model"
 	"test_task/internal/entity"
 	"test_task/internal/pagination"
@@ -25,7 +29,7 @@ func (u *UserRepository) Create(ctx context.Context, user entity.User) (entity.U
 		return entity.User{}, fmt.Errorf("MapEntityUserToModelUser: %w", err)
 	}
 
-	err = u.pgClient.WithContext(ctx).Create(&modelUser).Error
+	err = conn(ctx, u.pgClient).Create(&modelUser).Error
 	return model.MapModelUserToEntityUser(modelUser), err
 }
 
@@ -34,7 +38,7 @@ func (u *UserRepository) Update(ctx context.Context, user entity.User) (entity.U
 	if err != nil {
 		return entity.User{}, fmt.Errorf("MapEntityUserToModelUser: %w", err)
 	}
-	err = u.pgClient.WithContext(ctx).Model(&model.User{}).
+	err = conn(ctx, u.pgClient).Model(&model.User{}).
 		Where("id = ?", modelUser.ID).
 		Select("FirstName", "LastName", "Nickname", "Password", "Email", "Country").
 		Updates(&modelUser).Error
@@ -42,7 +46,7 @@ func (u *UserRepository) Update(ctx context.Context, user entity.User) (entity.U
 }
 
 func (u *UserRepository) Delete(ctx context.Context, id string) error {
-	return u.pgClient.WithContext(ctx).Unscoped().Delete(&model.User{}, "id = ?", id).Error
+	return conn(ctx, u.pgClient).Unscoped().Delete(&model.User{}, "id = ?", id).Error
 }
 
 func (u *UserRepository) GetList(ctx context.Context, query entity.UserFilter) ([]entity.User, int64, error) {
@@ -50,7 +54,7 @@ func (u *UserRepository) GetList(ctx context.Context, query entity.UserFilter) (
 		res   = make([]model.User, 0)
 		total int64
 	)
-	err := u.pgClient.WithContext(ctx).Model(&model.User{}).Scopes(
+	err := conn(ctx, u.pgClient).Model(&model.User{}).Scopes(
 		StringEqFilterScope("id", query.ID),
 		StringEqFilterScope("first_name", query.FirstName),
 		StringEqFilterScope("last_name", query.LastName),
@@ -64,3 +68,41 @@ func (u *UserRepository) GetList(ctx context.Context, query entity.UserFilter) (
 
 	return model.MapModelUsersToEntityUsers(res), total, err
 }
+
+// GetByLogin finds user by email or nickname.
+func (u *UserRepository) GetByLogin(ctx context.Context, login string) (entity.User, error) {
+	var res model.User
+	err := conn(ctx, u.pgClient).Where("email = ? OR nickname = ?", login, login).Take(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.User{}, datastore.ErrNotFound
+	}
+	return model.MapModelUserToEntityUser(res), err
+}
+
+// GetByID finds user by ID. Returns datastore.ErrNotFound if there is no user with id.
+func (u *UserRepository) GetByID(ctx context.Context, id string) (entity.User, error) {
+	return u.getByID(conn(ctx, u.pgClient), id)
+}
+
+// GetByIDForUpdate finds user by ID like GetByID and locks it until the end of the transaction.
+func (u *UserRepository) GetByIDForUpdate(ctx context.Context, id string) (entity.User, error) {
+	return u.getByID(conn(ctx, u.pgClient).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (u *UserRepository) getByID(db *gorm.DB, id string) (entity.User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return entity.User{}, datastore.ErrNotFound
+	}
+	var res model.User
+	err := db.Where("id = ?", id).Take(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.User{}, datastore.ErrNotFound
+	}
+	return model.MapModelUserToEntityUser(res), err
+}
+
+func (u *UserRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	return conn(ctx, u.pgClient).Model(&model.User{}).
+		Where("id = ?", id).
+		Update("password", password).Error
+}
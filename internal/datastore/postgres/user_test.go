@@ -11,6 +11,7 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"test_task/internal/datastore"
 	"test_task/internal/entity"
 	"test_task/internal/pagination"
 )
@@ -340,3 +341,130 @@ func TestUserRepository_GetList(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRepository_GetByLogin(t *testing.T) {
+	type testCase struct {
+		name        string
+		mockSetup   func(sqlmock.Sqlmock)
+		expectedRes entity.User
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "successful retrieval",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE email = $1 OR nickname = $2 LIMIT $3`)).
+					WithArgs("jdoe", "jdoe", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "password"}).
+						AddRow("3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85", "jdoe", "password123"))
+			},
+			expectedRes: entity.User{ID: "3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85", Nickname: "jdoe", Password: "password123"},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedErr: datastore.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ao := assert.New(t)
+			db, mock, err := sqlmock.New()
+			ao.NoError(err)
+			defer db.Close()
+
+			tc.mockSetup(mock)
+
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{
+				Conn: db,
+			}), &gorm.Config{})
+			ao.NoError(err)
+
+			repo := NewUserRepository(gormDB)
+			res, err := repo.GetByLogin(context.Background(), "jdoe")
+			if tc.expectedErr != nil {
+				ao.ErrorIs(err, tc.expectedErr)
+			} else {
+				ao.NoError(err)
+				ao.Equal(tc.expectedRes, res)
+			}
+
+			ao.NoError(mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserRepository_GetByID(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 LIMIT $2`)).
+		WithArgs("3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewUserRepository(gormDB)
+	_, err = repo.GetByID(context.Background(), "3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85")
+	ao.ErrorIs(err, datastore.ErrNotFound)
+	// invalid ID isn't sent to the database
+	_, err = repo.GetByID(context.Background(), "1")
+	ao.ErrorIs(err, datastore.ErrNotFound)
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetByIDForUpdate(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1 LIMIT $2 FOR UPDATE`)).
+		WithArgs("3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nickname"}).AddRow("3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85", "jdoe"))
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewUserRepository(gormDB)
+	user, err := repo.GetByIDForUpdate(context.Background(), "3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85")
+	ao.NoError(err)
+	ao.Equal("jdoe", user.Nickname)
+	_, err = repo.GetByIDForUpdate(context.Background(), "1")
+	ao.ErrorIs(err, datastore.ErrNotFound)
+	ao.NoError(mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	ao := assert.New(t)
+	db, mock, err := sqlmock.New()
+	ao.NoError(err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"=$1,"updated_at"=$2 WHERE id = $3`)).
+		WithArgs("New-pass1", sqlmock.AnyArg(), "3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	ao.NoError(err)
+
+	repo := NewUserRepository(gormDB)
+	ao.NoError(repo.UpdatePassword(context.Background(), "3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85", "New-pass1"))
+	ao.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,305 @@
+// Package rabbitmq implements notification publisher for RabbitMQ.
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"test_task/internal/config"
+	"test_task/internal/logger"
+	"test_task/internal/message"
+	"test_task/internal/tracing"
+)
+
+const (
+	DefaultExchangeType        = amqp.ExchangeTopic
+	DefaultRoutingKeyPrefix    = "user"
+	DefaultTimeout             = 10 * time.Second
+	DefaultReconnectBackoff    = time.Second
+	DefaultMaxReconnectBackoff = 30 * time.Second
+
+	// HeaderKey message header with notification key(user ID).
+	HeaderKey = "key"
+)
+
+var (
+	// ErrNotConnected is returned while connection to the broker is not established.
+	ErrNotConnected = errors.New("not connected")
+	// ErrUnroutable is returned when no queue is bound to the exchange for the routing key of the message.
+	ErrUnroutable = errors.New("message is unroutable")
+)
+
+// Publisher publishes notifications to the exchange with publisher confirms. Connection is maintained by Start.
+type Publisher struct {
+	cfg    config.RabbitMQ
+	dial   func(url string) (*amqp.Connection, error)
+	logger logger.Logger
+
+	mu      sync.RWMutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	returns *returns
+	// connErr is the reason of the last failed connect or disconnect
+	connErr error
+}
+
+// NewPublisher creates publisher, zero durations and empty exchange type and routing key prefix are replaced with defaults.
+func NewPublisher(cfg config.RabbitMQ, l logger.Logger) (*Publisher, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("no url")
+	}
+	if cfg.Exchange == "" {
+		return nil, errors.New("no exchange")
+	}
+	if cfg.ExchangeType == "" {
+		cfg.ExchangeType = DefaultExchangeType
+	}
+	if cfg.RoutingKeyPrefix == "" {
+		cfg.RoutingKeyPrefix = DefaultRoutingKeyPrefix
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.ReconnectBackoff <= 0 {
+		cfg.ReconnectBackoff = DefaultReconnectBackoff
+	}
+	if cfg.MaxReconnectBackoff < cfg.ReconnectBackoff {
+		cfg.MaxReconnectBackoff = max(DefaultMaxReconnectBackoff, cfg.ReconnectBackoff)
+	}
+	return &Publisher{cfg: cfg, dial: amqp.Dial, logger: l}, nil
+}
+
+// Start connects to the broker and reconnects with backoff until ctx is done, then closes connection.
+func (p *Publisher) Start(ctx context.Context) {
+	l := p.logger.WithFields(logger.Fields{logger.FieldOperation: "rabbitmq connect"})
+	backoff := p.cfg.ReconnectBackoff
+	for {
+		connClosed, channelClosed, err := p.connect()
+		if err != nil {
+			p.disconnect(err)
+			l.WithFields(logger.Fields{"retry_in": backoff.String()}).Error(err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, p.cfg.MaxReconnectBackoff)
+			continue
+		}
+		backoff = p.cfg.ReconnectBackoff
+		l.Info("rabbitmq connection established")
+
+		select {
+		case <-ctx.Done():
+			p.disconnect(nil)
+			return
+		case amqpErr := <-connClosed:
+			err = closeError("connection", amqpErr)
+		case amqpErr := <-channelClosed:
+			err = closeError("channel", amqpErr)
+		}
+		p.disconnect(err)
+		l.Warn(err)
+	}
+}
+
+// connect opens connection and confirm channel and declares the exchange.
+func (p *Publisher) connect() (chan *amqp.Error, chan *amqp.Error, error) {
+	conn, err := p.dial(p.cfg.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dial: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("open channel: %w", err)
+	}
+	if err = channel.ExchangeDeclare(p.cfg.Exchange, p.cfg.ExchangeType, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("declare exchange: %w", err)
+	}
+	if err = channel.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("enable confirms: %w", err)
+	}
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	channelClosed := channel.NotifyClose(make(chan *amqp.Error, 1))
+	returns := newReturns(channel.NotifyReturn(make(chan amqp.Return)))
+
+	p.mu.Lock()
+	p.conn, p.channel, p.returns, p.connErr = conn, channel, returns, nil
+	p.mu.Unlock()
+	return connClosed, channelClosed, nil
+}
+
+// disconnect closes current connection, reason is reported by Push and Health until the next connect.
+func (p *Publisher) disconnect(reason error) {
+	p.mu.Lock()
+	conn := p.conn
+	p.conn, p.channel, p.returns, p.connErr = nil, nil, nil, reason
+	p.mu.Unlock()
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// Push publishes data routed by notification type from ctx and waits for broker confirm.
+// Message is mandatory, ErrUnroutable is returned if the broker has no queue for it.
+func (p *Publisher) Push(ctx context.Context, data []byte) error {
+	channel, returns, err := p.state()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	attrs := message.FromContext(ctx)
+	msg := amqp.Publishing{
+		// notification is CloudEvent in structured mode
+		ContentType:  "application/cloudevents+json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Type:         attrs.Type,
+		MessageId:    uuid.NewString(), // identifies returned message
+		Headers:      amqp.Table{},
+		Body:         data,
+	}
+	if attrs.Key != "" {
+		msg.Headers[HeaderKey] = attrs.Key
+	}
+	tracing.Propagator.Inject(ctx, headerCarrier(msg.Headers))
+
+	key := routingKey(p.cfg.RoutingKeyPrefix, attrs.Type)
+	confirm, err := channel.PublishWithDeferredConfirmWithContext(ctx, p.cfg.Exchange, key, true, false, msg)
+	if err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("wait confirm: %w", err)
+	}
+	if !acked {
+		return errors.New("message is not confirmed by broker")
+	}
+	returned, err := returns.returned(ctx, msg.MessageId)
+	if err != nil {
+		return fmt.Errorf("check return: %w", err)
+	}
+	if returned {
+		return fmt.Errorf("%w: routing key %s", ErrUnroutable, key)
+	}
+	return nil
+}
+
+// Health reports whether connection to the broker is established.
+func (p *Publisher) Health(_ context.Context) error {
+	_, _, err := p.state()
+	return err
+}
+
+// state returns current channel and its returns or error with the reason of disconnect.
+func (p *Publisher) state() (*amqp.Channel, *returns, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, p.returns, nil
+	}
+	if p.connErr != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrNotConnected, p.connErr)
+	}
+	return nil, nil, ErrNotConnected
+}
+
+// returns collects IDs of messages returned by the broker on one channel. Broker sends return of unroutable message
+// before its confirm and the channel hands it over before it handles the confirm, so query made after confirm sees it.
+type returns struct {
+	queries chan returnQuery
+	// done is closed when the channel is closed
+	done chan struct{}
+}
+
+// returnQuery asks whether message was returned, reply is buffered.
+type returnQuery struct {
+	messageID string
+	reply     chan bool
+}
+
+func newReturns(returned <-chan amqp.Return) *returns {
+	r := &returns{queries: make(chan returnQuery), done: make(chan struct{})}
+	go r.run(returned)
+	return r
+}
+
+func (r *returns) run(returned <-chan amqp.Return) {
+	defer close(r.done)
+	// returned message which isn't queried(push timed out) is kept until the channel is closed
+	ids := make(map[string]struct{})
+	for {
+		select {
+		case ret, ok := <-returned:
+			if !ok {
+				return
+			}
+			ids[ret.MessageId] = struct{}{}
+		case q := <-r.queries:
+			_, ok := ids[q.messageID]
+			delete(ids, q.messageID)
+			q.reply <- ok
+		}
+	}
+}
+
+// returned reports whether message was returned, it is called after the message is confirmed.
+func (r *returns) returned(ctx context.Context, messageID string) (bool, error) {
+	q := returnQuery{messageID: messageID, reply: make(chan bool, 1)}
+	select {
+	case r.queries <- q:
+		return <-q.reply, nil
+	case <-r.done:
+		return false, errors.New("channel closed")
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// routingKey builds routing key from prefix and operation type, e.g. user.insert.
+func routingKey(prefix, operationType string) string {
+	if operationType == "" {
+		return prefix
+	}
+	return prefix + "." + strings.ToLower(operationType)
+}
+
+func closeError(what string, amqpErr *amqp.Error) error {
+	if amqpErr == nil {
+		return fmt.Errorf("%s closed", what)
+	}
+	return fmt.Errorf("%s closed: %w", what, amqpErr)
+}
+
+// headerCarrier propagates trace context in message headers.
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
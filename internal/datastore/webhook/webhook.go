@@ -0,0 +1,97 @@
+// Package webhook implements notification sink sending notifications with HTTP POST.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+
+	"test_task/internal/config"
+	"test_task/internal/message"
+	"test_task/internal/requestid"
+	"test_task/internal/tracing"
+)
+
+const (
+	DefaultTimeout = 5 * time.Second
+
+	// HeaderKey request header with notification key(user ID).
+	HeaderKey = "X-Notification-Key"
+	// HeaderType request header with notification operation type.
+	HeaderType = "X-Notification-Type"
+	// ContentType of request, notification is CloudEvent in structured mode.
+	ContentType = "application/cloudevents+json"
+)
+
+// Client posts notifications to the configured URL.
+type Client struct {
+	cfg    config.HTTPSink
+	client *http.Client
+}
+
+// NewClient creates webhook client, zero timeout is replaced with default.
+func NewClient(cfg config.HTTPSink) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("no url")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Client{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+// Push posts data, any status except 2xx is failure.
+func (c *Client) Push(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range c.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", ContentType)
+	attrs := message.FromContext(ctx)
+	if attrs.Key != "" {
+		req.Header.Set(HeaderKey, attrs.Key)
+	}
+	if attrs.Type != "" {
+		req.Header.Set(HeaderType, attrs.Type)
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return c.do(req)
+}
+
+// Health requests health URL, webhook without it is always healthy.
+func (c *Client) Health(ctx context.Context) error {
+	if c.cfg.HealthURL == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.HealthURL, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	return c.do(req)
+}
+
+func (c *Client) do(req *http.Request) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// body is drained to reuse connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
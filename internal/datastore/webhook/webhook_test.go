@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/config"
+	"test_task/internal/message"
+	"test_task/internal/requestid"
+)
+
+func TestNewClient(t *testing.T) {
+	ao := assert.New(t)
+	_, err := NewClient(config.HTTPSink{})
+	ao.EqualError(err, "no url")
+
+	c, err := NewClient(config.HTTPSink{URL: "http://localhost"})
+	require.NoError(t, err)
+	ao.Equal(DefaultTimeout, c.client.Timeout)
+}
+
+func TestClient_Push(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		expectedErr string
+	}{
+		{
+			name:   "accepted",
+			status: http.StatusAccepted,
+		},
+		{
+			name:        "server error",
+			status:      http.StatusServiceUnavailable,
+			expectedErr: "unexpected status 503",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			var received *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			c, err := NewClient(config.HTTPSink{URL: server.URL + "/events", Headers: map[string]string{"Authorization": "Bearer token"}})
+			require.NoError(t, err)
+			ctx := message.NewContext(requestid.NewContext(context.Background(), "abc"), message.Attributes{Key: "42", Type: "Update"})
+			err = c.Push(ctx, []byte(`{"Type":"Update"}`))
+			if tt.expectedErr != "" {
+				ao.EqualError(err, tt.expectedErr)
+			} else {
+				ao.NoError(err)
+			}
+
+			require.NotNil(t, received)
+			ao.Equal(http.MethodPost, received.Method)
+			ao.Equal("/events", received.URL.Path)
+			ao.Equal(`{"Type":"Update"}`, string(body))
+			ao.Equal(ContentType, received.Header.Get("Content-Type"))
+			ao.Equal("Bearer token", received.Header.Get("Authorization"))
+			ao.Equal("42", received.Header.Get(HeaderKey))
+			ao.Equal("Update", received.Header.Get(HeaderType))
+			ao.Equal("abc", received.Header.Get(requestid.Header))
+		})
+	}
+}
+
+func TestClient_PushTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c, err := NewClient(config.HTTPSink{URL: server.URL, Timeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Error(t, c.Push(context.Background(), []byte(`{}`)))
+}
+
+func TestClient_Health(t *testing.T) {
+	ao := assert.New(t)
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ao.Equal(http.MethodGet, r.Method)
+		if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(config.HTTPSink{URL: server.URL})
+	require.NoError(t, err)
+	ao.NoError(c.Health(context.Background()))
+
+	c, err = NewClient(config.HTTPSink{URL: server.URL, HealthURL: server.URL + "/health"})
+	require.NoError(t, err)
+	ao.NoError(c.Health(context.Background()))
+	healthy = false
+	ao.EqualError(c.Health(context.Background()), "unexpected status 500")
+}
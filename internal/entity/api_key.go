@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+)
+
+// APIKey service-to-service credential. Only hash of the key is stored, Prefix is used for the key identification.
+type APIKey struct {
+	ID         string
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+	RevokedAt  time.Time
+}
+
+// Active reports whether key is neither revoked nor expired at the moment.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt.IsZero() && (k.ExpiresAt.IsZero() || k.ExpiresAt.After(now))
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Type   PrincipalType
+	ID     string
+	Scopes []string
+}
+
+// HasScope reports whether principal is granted scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, v := range p.Scopes {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns principal name for logs, "api_key:<prefix>" for API keys.
+func (p Principal) String() string {
+	if p.Type == PrincipalAdmin {
+		return string(p.Type)
+	}
+	return string(p.Type) + ":" + p.ID
+}
+
+type PrincipalType string
+
+const (
+	PrincipalAdmin  PrincipalType = "admin"
+	PrincipalAPIKey PrincipalType = "api_key"
+)
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+)
+
+// DeadLetter is notification which wasn't delivered after all attempts.
+type DeadLetter struct {
+	ID       string
+	Key      string
+	Type     string
+	Payload  []byte
+	Attempts []DeliveryAttempt
+	// CreatedAt is time when the notification was dead-lettered.
+	CreatedAt time.Time
+}
+
+// DeliveryAttempt failed attempt to deliver notification.
+type DeliveryAttempt struct {
+	At    time.Time
+	Error string
+}
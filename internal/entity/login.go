@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+)
+
+// LoginAttempt failed login counter for one key(account or client IP).
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Credentials login input. Login is a user email or nickname.
+type Credentials struct {
+	Login    string
+	Password string
+	ClientIP string
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+)
+
+// OutboxMessage is notification written in the transaction of the change and published later.
+// Key identifies notification subject, messages with the same key are published in order.
+// DeliveredSinks are names of sinks which accepted the message, it isn't pushed to them again on retry.
+type OutboxMessage struct {
+	ID             int64
+	Key            string
+	Type           string
+	Payload        []byte
+	Attempts       int
+	DeliveredSinks []string
+	CreatedAt      time.Time
+}
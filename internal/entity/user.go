@@ -1,9 +1,14 @@
 package entity
 
 import (
+	"fmt"
+
 	"test_task/internal/pagination"
 )
 
+// redactedPassword replaces password when user is formatted, it is the same as logger.Redacted.
+const redactedPassword = "[REDACTED]"
+
 type User struct {
 	ID        string
 	FirstName string
@@ -14,6 +19,16 @@ type User struct {
 	Country   string
 }
 
+// Format formats user with masked password, so it doesn't leak through %v of user wrapped into error messages.
+func (u User) Format(f fmt.State, verb rune) {
+	type user User
+	masked := user(u)
+	if masked.Password != "" {
+		masked.Password = redactedPassword
+	}
+	fmt.Fprintf(f, fmt.FormatString(f, verb), masked)
+}
+
 // UserFilter business layer filter struct.
 // I used separate structure intentionally. might be the case when UserFilter != User(email domain for example).
 type UserFilter struct {
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval is used for checks without own polling interval.
+	DefaultInterval = 10 * time.Second
+	// DefaultHistorySize number of results kept per component.
+	DefaultHistorySize = 5
+)
+
+// StatusUnknown component has not been checked yet.
+const StatusUnknown Status = "unknown"
+
+// Result of single run of a check.
+type Result struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Status    Status    `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// CollectorConfig configures Collector.
+type CollectorConfig struct {
+	// FailureThreshold consecutive failures after which healthy component is marked down.
+	FailureThreshold int
+	HistorySize      int
+}
+
+// Collector polls checks in background and caches their state.
+// Single failure does not flap status: healthy component is marked down after FailureThreshold consecutive failures,
+// one success marks it up again. Component that has never been up is marked down on the first failure.
+type Collector struct {
+	cfg    CollectorConfig
+	checks []Check
+	now    func() time.Time
+
+	mu         sync.RWMutex
+	components []componentState
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type componentState struct {
+	component Component
+	failures  int
+	history   []Result
+}
+
+// NewCollector creates new instance of Collector.
+func NewCollector(checks []Check, cfg CollectorConfig) *Collector {
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HistorySize < 1 {
+		cfg.HistorySize = DefaultHistorySize
+	}
+	components := make([]componentState, len(checks))
+	for i, c := range checks {
+		components[i].component = Component{Name: c.Name, Status: StatusUnknown, Critical: c.Critical}
+	}
+	return &Collector{cfg: cfg, checks: checks, now: time.Now, components: components}
+}
+
+// Start polls every check on its interval until Stop is called or ctx is done. The first poll is immediate.
+func (c *Collector) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	for i := range c.checks {
+		c.wg.Add(1)
+		go func(i int) {
+			defer c.wg.Done()
+			c.poll(ctx, i)
+		}(i)
+	}
+}
+
+// Stop stops polling and waits for running checks.
+func (c *Collector) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
+
+// Report returns cached state of all components.
+func (c *Collector) Report() Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	components := make([]Component, len(c.components))
+	for i, s := range c.components {
+		components[i] = s.component
+		components[i].History = append([]Result(nil), s.history...)
+	}
+	return NewReport(components)
+}
+
+func (c *Collector) poll(ctx context.Context, i int) {
+	interval := c.checks[i].Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.collect(ctx, i)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect runs check i and updates its state.
+func (c *Collector) collect(ctx context.Context, i int) {
+	checked := run(ctx, c.checks[i])
+	if ctx.Err() != nil {
+		// result of interrupted check says nothing about the component
+		return
+	}
+	result := Result{CheckedAt: c.now(), Status: checked.Status, LatencyMS: checked.LatencyMS, Error: checked.Error}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &c.components[i]
+	s.history = append(s.history, result)
+	if len(s.history) > c.cfg.HistorySize {
+		s.history = s.history[len(s.history)-c.cfg.HistorySize:]
+	}
+
+	s.component.LatencyMS = result.LatencyMS
+	s.component.Error = result.Error
+	s.component.CheckedAt = result.CheckedAt
+	if result.Status == StatusUp {
+		s.failures = 0
+		s.component.Status = StatusUp
+	} else {
+		s.failures++
+		if s.failures >= c.cfg.FailureThreshold || s.component.Status == StatusUnknown {
+			s.component.Status = StatusDown
+		}
+	}
+	s.component.ConsecutiveFailures = s.failures
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_collect(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	checker := NewMockChecker(ctrl)
+	errDown := errors.New("connection refused")
+	gomock.InOrder(
+		checker.EXPECT().Health(gomock.Any()).Return(nil),
+		checker.EXPECT().Health(gomock.Any()).Return(errDown),
+		checker.EXPECT().Health(gomock.Any()).Return(errDown),
+		checker.EXPECT().Health(gomock.Any()).Return(errDown),
+		checker.EXPECT().Health(gomock.Any()).Return(nil),
+	)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector([]Check{{Name: "postgres", Checker: checker, Critical: true}}, CollectorConfig{FailureThreshold: 3, HistorySize: 2})
+	c.now = func() time.Time { return now }
+
+	ao.Equal(StatusUnknown, c.Report().Components[0].Status)
+	ao.Equal(StatusDown, c.Report().Status)
+
+	expected := []struct {
+		service   Status
+		component Status
+		failures  int
+	}{
+		{StatusUp, StatusUp, 0},
+		// failures below threshold do not flap status
+		{StatusUp, StatusUp, 1},
+		{StatusUp, StatusUp, 2},
+		{StatusDown, StatusDown, 3},
+		{StatusUp, StatusUp, 0},
+	}
+	for i, e := range expected {
+		c.collect(context.Background(), 0)
+		report := c.Report()
+		ao.Equal(e.service, report.Status, "poll %d", i)
+		ao.Equal(e.component, report.Components[0].Status, "poll %d", i)
+		ao.Equal(e.failures, report.Components[0].ConsecutiveFailures, "poll %d", i)
+	}
+
+	history := c.Report().Components[0].History
+	ao.Equal([]Result{
+		{CheckedAt: now, Status: StatusDown, Error: "connection refused", LatencyMS: history[0].LatencyMS},
+		{CheckedAt: now, Status: StatusUp, LatencyMS: history[1].LatencyMS},
+	}, history)
+}
+
+func TestCollector_firstFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	checker := NewMockChecker(ctrl)
+	checker.EXPECT().Health(gomock.Any()).Return(errors.New("connection refused"))
+
+	c := NewCollector([]Check{{Name: "notification", Checker: checker}}, CollectorConfig{FailureThreshold: 3})
+	c.collect(context.Background(), 0)
+
+	report := c.Report()
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusDown, report.Components[0].Status)
+}
+
+func TestCollector_StartStop(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	checker := NewMockChecker(ctrl)
+	polled := make(chan struct{}, 10)
+	checker.EXPECT().Health(gomock.Any()).DoAndReturn(func(context.Context) error {
+		polled <- struct{}{}
+		return nil
+	}).MinTimes(2)
+
+	c := NewCollector([]Check{{Name: "postgres", Checker: checker, Critical: true, Interval: time.Millisecond}}, CollectorConfig{})
+	c.Start(context.Background())
+	<-polled
+	<-polled
+	c.Stop()
+
+	ao.Equal(StatusUp, c.Report().Status)
+	// nothing is polled after Stop
+	polls := len(polled)
+	time.Sleep(5 * time.Millisecond)
+	ao.Equal(polls, len(polled))
+}
@@ -0,0 +1,151 @@
+// Package health implements aggregation of component health checks.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=health.go --destination=health_mock.go --package=health
+
+// DefaultTimeout is used for checks without own timeout.
+const DefaultTimeout = time.Second
+
+// Status of component or whole service.
+type Status string
+
+const (
+	StatusUp Status = "up"
+	// StatusDegraded non-critical component is down, service still serves requests.
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// ErrTimeout is reported when checker has not finished within timeout.
+var ErrTimeout = errors.New("health check timed out")
+
+// Checker checks component health.
+type Checker interface {
+	Health(ctx context.Context) error
+}
+
+// Check is named Checker. Failure of Critical check makes service down, failure of other checks makes it degraded.
+// Interval is used by Collector.
+type Check struct {
+	Name     string
+	Checker  Checker
+	Critical bool
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+// Component is result of single check.
+type Component struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	// ConsecutiveFailures and History are filled by Collector.
+	ConsecutiveFailures int      `json:"consecutive_failures,omitempty"`
+	History             []Result `json:"history,omitempty"`
+}
+
+// Report is result of all checks.
+type Report struct {
+	Status     Status      `json:"status"`
+	Components []Component `json:"components,omitempty"`
+}
+
+// ComponentStatus is Component without details.
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+}
+
+// Summary is Report without details(errors, latency, history), it is safe to serve publicly.
+type Summary struct {
+	Status     Status            `json:"status"`
+	Components []ComponentStatus `json:"components,omitempty"`
+}
+
+// Summary returns status of the service and every component.
+func (r Report) Summary() Summary {
+	s := Summary{Status: r.Status}
+	for _, c := range r.Components {
+		s.Components = append(s.Components, ComponentStatus{Name: c.Name, Status: c.Status})
+	}
+	return s
+}
+
+// Run executes checks concurrently, every check is limited by its timeout.
+func Run(ctx context.Context, checks []Check) Report {
+	components := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			components[i] = run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	return NewReport(components)
+}
+
+// NewReport aggregates components status.
+func NewReport(components []Component) Report {
+	report := Report{Status: StatusUp, Components: components}
+	for _, c := range components {
+		if c.Status == StatusUp {
+			continue
+		}
+		if c.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func run(ctx context.Context, c Check) Component {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	// checker that ignores context must not hold the report
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Checker.Health(ctx)
+	}()
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ErrTimeout
+		if errors.Is(ctx.Err(), context.Canceled) {
+			err = ctx.Err()
+		}
+	}
+
+	component := Component{
+		Name:      c.Name,
+		Status:    StatusUp,
+		Critical:  c.Critical,
+		CheckedAt: start,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockSetup      func(critical *MockChecker, optional *MockChecker)
+		expectedStatus Status
+		expectedErrors []string
+	}{
+		{
+			name: "all checks pass",
+			mockSetup: func(critical *MockChecker, optional *MockChecker) {
+				critical.EXPECT().Health(gomock.Any()).Return(nil)
+				optional.EXPECT().Health(gomock.Any()).Return(nil)
+			},
+			expectedStatus: StatusUp,
+			expectedErrors: []string{"", ""},
+		},
+		{
+			name: "critical check fails",
+			mockSetup: func(critical *MockChecker, optional *MockChecker) {
+				critical.EXPECT().Health(gomock.Any()).Return(errors.New("connection refused"))
+				optional.EXPECT().Health(gomock.Any()).Return(nil)
+			},
+			expectedStatus: StatusDown,
+			expectedErrors: []string{"connection refused", ""},
+		},
+		{
+			name: "non-critical check fails",
+			mockSetup: func(critical *MockChecker, optional *MockChecker) {
+				critical.EXPECT().Health(gomock.Any()).Return(nil)
+				optional.EXPECT().Health(gomock.Any()).Return(errors.New("broker unavailable"))
+			},
+			expectedStatus: StatusDegraded,
+			expectedErrors: []string{"", "broker unavailable"},
+		},
+		{
+			name: "check ignoring context times out",
+			mockSetup: func(critical *MockChecker, optional *MockChecker) {
+				critical.EXPECT().Health(gomock.Any()).DoAndReturn(func(context.Context) error {
+					time.Sleep(100 * time.Millisecond)
+					return nil
+				})
+				optional.EXPECT().Health(gomock.Any()).Return(nil)
+			},
+			expectedStatus: StatusDown,
+			expectedErrors: []string{ErrTimeout.Error(), ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			critical := NewMockChecker(ctrl)
+			optional := NewMockChecker(ctrl)
+			tt.mockSetup(critical, optional)
+
+			report := Run(context.Background(), []Check{
+				{Name: "postgres", Checker: critical, Critical: true, Timeout: 10 * time.Millisecond},
+				{Name: "notification", Checker: optional},
+			})
+
+			ao.Equal(tt.expectedStatus, report.Status)
+			ao.Len(report.Components, 2)
+			ao.Equal("postgres", report.Components[0].Name)
+			ao.True(report.Components[0].Critical)
+			ao.Equal("notification", report.Components[1].Name)
+			ao.False(report.Components[1].Critical)
+			for i, expected := range tt.expectedErrors {
+				ao.Equal(expected, report.Components[i].Error)
+				if expected == "" {
+					ao.Equal(StatusUp, report.Components[i].Status)
+				} else {
+					ao.Equal(StatusDown, report.Components[i].Status)
+				}
+			}
+			ao.Less(report.Components[0].LatencyMS, float64(100))
+		})
+	}
+}
+
+func TestRun_Empty(t *testing.T) {
+	assert.Equal(t, Report{Status: StatusUp, Components: []Component{}}, Run(context.Background(), nil))
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Level log level.
+type Level int
+
+// Levels from the most to the least verbose.
+const (
+	TraceLevel Level = iota
+	DebugLevel
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	FatalLevel
+	PanicLevel
+)
+
+var levelNames = map[Level]string{
+	TraceLevel: "trace",
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+	FatalLevel: "fatal",
+	PanicLevel: "panic",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel parses level name, empty name means info.
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(name)
+	switch name {
+	case "":
+		return InfoLevel, nil
+	case "warning":
+		return WarnLevel, nil
+	}
+	for l, v := range levelNames {
+		if v == name {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// slogLevel maps level to slog level, levels missing in slog are placed around existing ones.
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case TraceLevel:
+		return slog.LevelDebug - 4
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError + 8
+	}
+}
+
+// LevelVar is logger level which can be changed at runtime.
+type LevelVar struct {
+	mu    sync.Mutex
+	level Level
+	apply func(Level)
+}
+
+// NewLevelVar creates new instance of LevelVar.
+func NewLevelVar(level Level) *LevelVar {
+	return &LevelVar{level: level}
+}
+
+// Level returns current level.
+func (v *LevelVar) Level() Level {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.level
+}
+
+// Set changes level of logger created with v.
+func (v *LevelVar) Set(level Level) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.level = level
+	if v.apply != nil {
+		v.apply(level)
+	}
+}
+
+// bind applies current and every following level with apply.
+func (v *LevelVar) bind(apply func(Level)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.apply = apply
+	apply(v.level)
+}
@@ -1,11 +1,52 @@
 // Package logger implements general logger.
 package logger
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"test_task/internal/config"
+	"test_task/internal/requestid"
+)
+
 //go:generate go run github.com/golang/mock/mockgen --source=logger.go --destination=logger_mock.go --package=logger
 
+// Common field names.
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+	FieldOperation = "operation"
+	FieldUserID    = "user_id"
+)
+
+// Backends.
+const (
+	BackendLogrus = "logrus"
+	BackendSlog   = "slog"
+)
+
+// Formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Fields structured log fields.
+type Fields map[string]interface{}
+
 // Logger describes general logging methods.
 type Logger interface {
 	LogWriter
+	// WithFields returns logger which adds fields to every log line.
+	WithFields(fields Fields) Logger
+	// WithContext returns logger which adds request scoped values(request ID) to every log line.
+	WithContext(ctx context.Context) Logger
 }
 
 // LogWriter describes final log-write methods.
@@ -31,3 +72,63 @@ type LogWriter interface {
 	Panic(args ...interface{})
 	Panicf(format string, args ...interface{})
 }
+
+// New creates logger with configured backend, level, format and output.
+// Secrets and PII are masked in every log line, secrets are literal values to mask in addition to configured ones.
+// Returned LevelVar changes level of the logger at runtime, returned func closes file output, it's called once logger isn't used.
+func New(cfg config.Log, secrets ...string) (Logger, *LevelVar, func() error, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cfg.Format != "" && cfg.Format != FormatText && cfg.Format != FormatJSON {
+		return nil, nil, nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	var backend func(out io.Writer, level *LevelVar, format string) Logger
+	switch cfg.Backend {
+	case "", BackendLogrus:
+		backend = newLogrus
+	case BackendSlog:
+		backend = newSlog
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown log backend %q", cfg.Backend)
+	}
+	redactor, err := NewRedactor(cfg.Redaction, secrets...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	out, closeOutput, err := output(cfg.Output)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	levelVar := NewLevelVar(level)
+	return NewRedacting(backend(out, levelVar, cfg.Format), redactor), levelVar, closeOutput, nil
+}
+
+// output opens configured output, standard streams aren't closed.
+func output(name string) (io.Writer, func() error, error) {
+	switch strings.ToLower(name) {
+	case "", "stdout":
+		return os.Stdout, func() error { return nil }, nil
+	case "stderr":
+		return os.Stderr, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open log output: %w", err)
+	}
+	return f, f.Close, nil
+}
+
+// contextFields returns request scoped fields stored in ctx: request ID, trace and span IDs.
+func contextFields(ctx context.Context) Fields {
+	fields := Fields{}
+	if id := requestid.FromContext(ctx); id != "" {
+		fields[FieldRequestID] = id
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields[FieldTraceID] = sc.TraceID().String()
+		fields[FieldSpanID] = sc.SpanID().String()
+	}
+	return fields
+}
@@ -5,6 +5,7 @@
 package logger
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -264,46 +265,32 @@ func (mr *MockLoggerMockRecorder) Warnf(format interface{}, args ...interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockLogger)(nil).Warnf), varargs...)
 }
 
-// WithError mocks base method.
-func (m *MockLogger) WithError(err error) Logger {
+// WithContext mocks base method.
+func (m *MockLogger) WithContext(ctx context.Context) Logger {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithError", err)
+	ret := m.ctrl.Call(m, "WithContext", ctx)
 	ret0, _ := ret[0].(Logger)
 	return ret0
 }
 
-// WithError indicates an expected call of WithError.
-func (mr *MockLoggerMockRecorder) WithError(err interface{}) *gomock.Call {
+// WithContext indicates an expected call of WithContext.
+func (mr *MockLoggerMockRecorder) WithContext(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithError", reflect.TypeOf((*MockLogger)(nil).WithError), err)
-}
-
-// WithField mocks base method.
-func (m *MockLogger) WithField(key string, value interface{}) Logger {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithField", key, value)
-	ret0, _ := ret[0].(Logger)
-	return ret0
-}
-
-// WithField indicates an expected call of WithField.
-func (mr *MockLoggerMockRecorder) WithField(key, value interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithField", reflect.TypeOf((*MockLogger)(nil).WithField), key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockLogger)(nil).WithContext), ctx)
 }
 
 // WithFields mocks base method.
-func (m *MockLogger) WithFields(arg0 map[string]interface{}) Logger {
+func (m *MockLogger) WithFields(fields Fields) Logger {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithFields", arg0)
+	ret := m.ctrl.Call(m, "WithFields", fields)
 	ret0, _ := ret[0].(Logger)
 	return ret0
 }
 
 // WithFields indicates an expected call of WithFields.
-func (mr *MockLoggerMockRecorder) WithFields(arg0 interface{}) *gomock.Call {
+func (mr *MockLoggerMockRecorder) WithFields(fields interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithFields", reflect.TypeOf((*MockLogger)(nil).WithFields), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithFields", reflect.TypeOf((*MockLogger)(nil).WithFields), fields)
 }
 
 // MockLogWriter is a mock of LogWriter interface.
@@ -559,68 +546,3 @@ func (mr *MockLogWriterMockRecorder) Warnf(format interface{}, args ...interface
 	varargs := append([]interface{}{format}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockLogWriter)(nil).Warnf), varargs...)
 }
-
-// MockLogBuilder is a mock of LogBuilder interface.
-type MockLogBuilder struct {
-	ctrl     *gomock.Controller
-	recorder *MockLogBuilderMockRecorder
-}
-
-// MockLogBuilderMockRecorder is the mock recorder for MockLogBuilder.
-type MockLogBuilderMockRecorder struct {
-	mock *MockLogBuilder
-}
-
-// NewMockLogBuilder creates a new mock instance.
-func NewMockLogBuilder(ctrl *gomock.Controller) *MockLogBuilder {
-	mock := &MockLogBuilder{ctrl: ctrl}
-	mock.recorder = &MockLogBuilderMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockLogBuilder) EXPECT() *MockLogBuilderMockRecorder {
-	return m.recorder
-}
-
-// WithError mocks base method.
-func (m *MockLogBuilder) WithError(err error) Logger {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithError", err)
-	ret0, _ := ret[0].(Logger)
-	return ret0
-}
-
-// WithError indicates an expected call of WithError.
-func (mr *MockLogBuilderMockRecorder) WithError(err interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithError", reflect.TypeOf((*MockLogBuilder)(nil).WithError), err)
-}
-
-// WithField mocks base method.
-func (m *MockLogBuilder) WithField(key string, value interface{}) Logger {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithField", key, value)
-	ret0, _ := ret[0].(Logger)
-	return ret0
-}
-
-// WithField indicates an expected call of WithField.
-func (mr *MockLogBuilderMockRecorder) WithField(key, value interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithField", reflect.TypeOf((*MockLogBuilder)(nil).WithField), key, value)
-}
-
-// WithFields mocks base method.
-func (m *MockLogBuilder) WithFields(arg0 map[string]interface{}) Logger {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WithFields", arg0)
-	ret0, _ := ret[0].(Logger)
-	return ret0
-}
-
-// WithFields indicates an expected call of WithFields.
-func (mr *MockLogBuilderMockRecorder) WithFields(arg0 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithFields", reflect.TypeOf((*MockLogBuilder)(nil).WithFields), arg0)
-}
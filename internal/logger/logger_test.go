@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"test_task/internal/config"
+	"test_task/internal/requestid"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.Log
+		expectErr bool
+	}{
+		{name: "defaults", cfg: config.Log{}},
+		{name: "slog json", cfg: config.Log{Backend: BackendSlog, Level: "debug", Format: FormatJSON, Output: "stderr"}},
+		{name: "unknown backend", cfg: config.Log{Backend: "zap"}, expectErr: true},
+		{name: "unknown level", cfg: config.Log{Level: "verbose"}, expectErr: true},
+		{name: "unknown format", cfg: config.Log{Format: "xml"}, expectErr: true},
+		{name: "bad output", cfg: config.Log{Output: filepath.Join(t.TempDir(), "missing", "app.log")}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _, closeOutput, err := New(tt.cfg)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, l)
+			assert.NoError(t, closeOutput())
+		})
+	}
+}
+
+func TestNew_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, _, closeOutput, err := New(config.Log{Backend: BackendSlog, Format: FormatJSON, Output: path})
+	require.NoError(t, err)
+
+	l.WithFields(Fields{FieldUserID: "42"}).Info("hello")
+	require.NoError(t, closeOutput())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	line := decode(t, data)
+	assert.Equal(t, "hello", line["msg"])
+	assert.Equal(t, "42", line[FieldUserID])
+}
+
+func TestBackends(t *testing.T) {
+	backends := map[string]func(buf *bytes.Buffer) Logger{
+		BackendLogrus: func(buf *bytes.Buffer) Logger {
+			return newLogrus(buf, NewLevelVar(DebugLevel), FormatJSON)
+		},
+		BackendSlog: func(buf *bytes.Buffer) Logger {
+			return newSlog(buf, NewLevelVar(DebugLevel), FormatJSON)
+		},
+	}
+
+	for name, create := range backends {
+		t.Run(name, func(t *testing.T) {
+			ao := assert.New(t)
+			buf := &bytes.Buffer{}
+			l := create(buf)
+
+			ctx := trace.ContextWithSpanContext(requestid.NewContext(context.Background(), "abc"), trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{2},
+			}))
+			l.WithContext(ctx).
+				WithFields(Fields{FieldOperation: "user create", FieldUserID: "42"}).
+				Error(errors.New("boom"))
+			line := decode(t, buf.Bytes())
+			ao.Equal("boom", line["msg"])
+			ao.Equal("abc", line[FieldRequestID])
+			ao.Equal("01000000000000000000000000000000", line[FieldTraceID])
+			ao.Equal("0200000000000000", line[FieldSpanID])
+			ao.Equal("user create", line[FieldOperation])
+			ao.Equal("42", line[FieldUserID])
+			ao.Contains([]string{"error", "ERROR"}, line["level"])
+
+			buf.Reset()
+			l.Tracef("hidden %d", 1)
+			ao.Empty(buf.String())
+
+			l.WithContext(context.Background()).Warnf("user %d", 1)
+			line = decode(t, buf.Bytes())
+			ao.Equal("user 1", line["msg"])
+			ao.NotContains(line, FieldRequestID)
+		})
+	}
+}
+
+func TestSlogLevelNames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	newSlog(buf, NewLevelVar(TraceLevel), FormatJSON).Trace("hello")
+	assert.Equal(t, "TRACE", decode(t, buf.Bytes())["level"])
+}
+
+func TestLevelVar(t *testing.T) {
+	for _, backend := range []string{BackendLogrus, BackendSlog} {
+		t.Run(backend, func(t *testing.T) {
+			ao := assert.New(t)
+			path := filepath.Join(t.TempDir(), "app.log")
+			l, level, closeOutput, err := New(config.Log{Backend: backend, Level: "warn", Format: FormatJSON, Output: path})
+			require.NoError(t, err)
+			defer closeOutput()
+			ao.Equal(WarnLevel, level.Level())
+
+			l.Debug("hidden")
+			level.Set(DebugLevel)
+			ao.Equal(DebugLevel, level.Level())
+			l.Debug("visible")
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			ao.NotContains(string(data), "hidden")
+			ao.Equal("visible", decode(t, data)["msg"])
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	ao := assert.New(t)
+	for l, name := range levelNames {
+		parsed, err := ParseLevel(name)
+		ao.NoError(err)
+		ao.Equal(l, parsed)
+	}
+	parsed, err := ParseLevel("WARNING")
+	ao.NoError(err)
+	ao.Equal(WarnLevel, parsed)
+	ao.Equal(slog.LevelWarn, parsed.slogLevel())
+	ao.Equal(logrus.WarnLevel, logrusLevels[parsed])
+}
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	res := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(data), &res), string(data))
+	return res
+}
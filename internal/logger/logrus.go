@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"context"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logrusLogger adapts logrus.Entry to Logger.
+type logrusLogger struct {
+	*logrus.Entry
+}
+
+func newLogrus(out io.Writer, level *LevelVar, format string) Logger {
+	l := logrus.New()
+	l.SetOutput(out)
+	level.bind(func(level Level) {
+		l.SetLevel(logrusLevels[level])
+	})
+	if format == FormatJSON {
+		l.SetFormatter(&logrus.JSONFormatter{})
+	}
+	return NewLogrus(l)
+}
+
+// NewLogrus wraps logrus logger.
+func NewLogrus(l *logrus.Logger) Logger {
+	return logrusLogger{Entry: logrus.NewEntry(l)}
+}
+
+var logrusLevels = map[Level]logrus.Level{
+	TraceLevel: logrus.TraceLevel,
+	DebugLevel: logrus.DebugLevel,
+	InfoLevel:  logrus.InfoLevel,
+	WarnLevel:  logrus.WarnLevel,
+	ErrorLevel: logrus.ErrorLevel,
+	FatalLevel: logrus.FatalLevel,
+	PanicLevel: logrus.PanicLevel,
+}
+
+func (l logrusLogger) WithFields(fields Fields) Logger {
+	return logrusLogger{Entry: l.Entry.WithFields(logrus.Fields(fields))}
+}
+
+func (l logrusLogger) WithContext(ctx context.Context) Logger {
+	entry := l.Entry.WithContext(ctx)
+	if fields := contextFields(ctx); len(fields) > 0 {
+		entry = entry.WithFields(logrus.Fields(fields))
+	}
+	return logrusLogger{Entry: entry}
+}
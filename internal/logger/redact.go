@@ -0,0 +1,301 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"test_task/internal/config"
+)
+
+// Redacted replaces masked values.
+const Redacted = "[REDACTED]"
+
+// redactValueGroup name of the pattern group to mask, whole match is masked if pattern has no such group.
+const redactValueGroup = "value"
+
+// DefaultRedactFields field names(and their parts) which are always masked, matching ignores case, "_" and "-".
+var DefaultRedactFields = []string{"password", "passwd", "secret", "token", "email", "authorization", "dsn"}
+
+// DefaultRedactPatterns patterns which are always masked in messages and string values.
+var DefaultRedactPatterns = []string{
+	// emails.
+	`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	// key=value and key: value secrets, DSN password.
+	`(?i)(?:password|passwd|pwd|secret|token)\s*[=:]\s*(?P<value>'[^']*'|"[^"]*"|[^\s,;&]+)`,
+	// URL userinfo password.
+	`://[^:/@\s]+:(?P<value>[^@\s]+)@`,
+	// authorization header credentials.
+	`(?i)(?:bearer|apikey|basic)\s+(?P<value>[A-Za-z0-9._~+/=\-]{8,})`,
+	// API keys.
+	`\btt_[0-9a-f]+_[0-9a-f]+\b`,
+}
+
+// minSecretLength is length of the shortest secret masked as literal, shorter one would mask parts of unrelated words.
+const minSecretLength = 6
+
+// commonSecrets are placeholder and default values which are ordinary words too, they aren't masked as literals.
+// Such secret is still masked by patterns in key=value pairs and URLs.
+var commonSecrets = map[string]struct{}{
+	"password": {}, "passwd": {}, "secret": {}, "changeme": {}, "change-me": {},
+	"postgres": {}, "admin": {}, "guest": {}, "root": {}, "test": {},
+}
+
+var dsnPasswordPattern = regexp.MustCompile(`(?:^|\s)password\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redactor masks secrets and PII in log fields and messages.
+type Redactor struct {
+	fields   []string
+	patterns []*regexp.Regexp
+	secrets  []string
+}
+
+// NewRedactor creates redactor with default and configured fields and patterns.
+// Secrets are literal values(passwords, tokens) masked wherever they appear, too short and common ones are ignored.
+func NewRedactor(cfg config.Redaction, secrets ...string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, v := range append(append([]string{}, DefaultRedactFields...), cfg.Fields...) {
+		if v = normalizeField(v); v != "" {
+			r.fields = append(r.fields, v)
+		}
+	}
+	for _, v := range append(append([]string{}, DefaultRedactPatterns...), cfg.Patterns...) {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", v, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, v := range secrets {
+		if _, common := commonSecrets[strings.ToLower(v)]; len(v) >= minSecretLength && !common {
+			r.secrets = append(r.secrets, v)
+		}
+	}
+	return r, nil
+}
+
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// sensitiveField reports whether values of the field must be masked.
+func (r *Redactor) sensitiveField(name string) bool {
+	name = normalizeField(name)
+	for _, v := range r.fields {
+		if strings.Contains(name, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks secrets and patterns in s.
+func (r *Redactor) String(s string) string {
+	for _, v := range r.secrets {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	for _, re := range r.patterns {
+		s = redactPattern(re, s)
+	}
+	return s
+}
+
+func redactPattern(re *regexp.Regexp, s string) string {
+	group := re.SubexpIndex(redactValueGroup)
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Redacted)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Fields returns copy of fields with masked values.
+func (r *Redactor) Fields(fields Fields) Fields {
+	res := make(Fields, len(fields))
+	for k, v := range fields {
+		if r.sensitiveField(k) {
+			res[k] = Redacted
+			continue
+		}
+		res[k] = r.Value(v)
+	}
+	return res
+}
+
+// Value masks v. Structures(structs, maps, slices) are converted to JSON-like form with masked sensitive fields.
+func (r *Redactor) Value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.String(t)
+	case error:
+		return r.String(t.Error())
+	case fmt.Stringer:
+		return r.String(t.String())
+	case []byte:
+		return r.String(string(t))
+	}
+	if !composite(reflect.ValueOf(v)) {
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Redacted
+	}
+	var res interface{}
+	if err = json.Unmarshal(data, &res); err != nil {
+		return Redacted
+	}
+	return r.structure(res)
+}
+
+func composite(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// structure masks decoded JSON value.
+func (r *Redactor) structure(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if r.sensitiveField(k) {
+				t[k] = Redacted
+				continue
+			}
+			t[k] = r.structure(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = r.structure(val)
+		}
+		return t
+	case string:
+		return r.String(t)
+	default:
+		return t
+	}
+}
+
+// args masks message arguments.
+func (r *Redactor) args(args []interface{}) []interface{} {
+	res := make([]interface{}, len(args))
+	for i, v := range args {
+		res[i] = r.Value(v)
+	}
+	return res
+}
+
+// DSNPassword returns password from Postgres DSN in URL or key=value form.
+func DSNPassword(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		p, _ := u.User.Password()
+		return p
+	}
+	m := dsnPasswordPattern.FindStringSubmatch(dsn)
+	if m == nil {
+		return ""
+	}
+	p := m[1]
+	if strings.HasPrefix(p, "'") {
+		p = strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(p[1 : len(p)-1])
+	}
+	return p
+}
+
+// redactingLogger masks secrets and PII before passing log lines to the underlying logger.
+type redactingLogger struct {
+	logger   Logger
+	redactor *Redactor
+}
+
+// NewRedacting wraps l, every field and message is masked by r.
+func NewRedacting(l Logger, r *Redactor) Logger {
+	return redactingLogger{logger: l, redactor: r}
+}
+
+func (l redactingLogger) WithFields(fields Fields) Logger {
+	return redactingLogger{logger: l.logger.WithFields(l.redactor.Fields(fields)), redactor: l.redactor}
+}
+
+func (l redactingLogger) WithContext(ctx context.Context) Logger {
+	return redactingLogger{logger: l.logger.WithContext(ctx), redactor: l.redactor}
+}
+
+func (l redactingLogger) message(args []interface{}) string {
+	return l.redactor.String(fmt.Sprint(l.redactor.args(args)...))
+}
+
+func (l redactingLogger) messagef(format string, args []interface{}) string {
+	return l.redactor.String(fmt.Sprintf(format, l.redactor.args(args)...))
+}
+
+func (l redactingLogger) Error(args ...interface{}) { l.logger.Error(l.message(args)) }
+func (l redactingLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(l.messagef(format, args))
+}
+
+func (l redactingLogger) Fatal(args ...interface{}) { l.logger.Fatal(l.message(args)) }
+func (l redactingLogger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatal(l.messagef(format, args))
+}
+
+func (l redactingLogger) Info(args ...interface{}) { l.logger.Info(l.message(args)) }
+func (l redactingLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(l.messagef(format, args))
+}
+
+func (l redactingLogger) Warn(args ...interface{}) { l.logger.Warn(l.message(args)) }
+func (l redactingLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(l.messagef(format, args))
+}
+
+func (l redactingLogger) Debug(args ...interface{}) { l.logger.Debug(l.message(args)) }
+func (l redactingLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(l.messagef(format, args))
+}
+
+func (l redactingLogger) Trace(args ...interface{}) { l.logger.Trace(l.message(args)) }
+func (l redactingLogger) Tracef(format string, args ...interface{}) {
+	l.logger.Trace(l.messagef(format, args))
+}
+
+func (l redactingLogger) Panic(args ...interface{}) { l.logger.Panic(l.message(args)) }
+func (l redactingLogger) Panicf(format string, args ...interface{}) {
+	l.logger.Panic(l.messagef(format, args))
+}
+
+// PrintfFunc adapts log method to Printf interface of third-party loggers(gorm).
+type PrintfFunc func(format string, args ...interface{})
+
+func (f PrintfFunc) Printf(format string, args ...interface{}) {
+	f(format, args...)
+}
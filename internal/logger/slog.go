@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// slogLogger adapts slog.Logger to Logger.
+type slogLogger struct {
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+func newSlog(out io.Writer, level *LevelVar, format string) Logger {
+	slogLevel := &slog.LevelVar{}
+	level.bind(func(level Level) {
+		slogLevel.Set(level.slogLevel())
+	})
+	opts := &slog.HandlerOptions{Level: slogLevel, ReplaceAttr: replaceSlogLevel}
+	var h slog.Handler = slog.NewTextHandler(out, opts)
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(out, opts)
+	}
+	return NewSlog(slog.New(h))
+}
+
+// NewSlog wraps slog logger.
+func NewSlog(l *slog.Logger) Logger {
+	return slogLogger{logger: l, ctx: context.Background()}
+}
+
+// replaceSlogLevel names levels missing in slog.
+func replaceSlogLevel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey {
+		return a
+	}
+	switch a.Value.Any() {
+	case TraceLevel.slogLevel():
+		a.Value = slog.StringValue("TRACE")
+	case FatalLevel.slogLevel():
+		a.Value = slog.StringValue("FATAL")
+	case PanicLevel.slogLevel():
+		a.Value = slog.StringValue("PANIC")
+	}
+	return a
+}
+
+func (l slogLogger) WithFields(fields Fields) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	args := make([]any, 0, len(fields)*2)
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		args = append(args, k, v)
+	}
+	return slogLogger{logger: l.logger.With(args...), ctx: l.ctx}
+}
+
+func (l slogLogger) WithContext(ctx context.Context) Logger {
+	res := slogLogger{logger: l.logger, ctx: ctx}
+	return res.WithFields(contextFields(ctx))
+}
+
+func (l slogLogger) log(level Level, msg string) {
+	l.logger.Log(l.ctx, level.slogLevel(), msg)
+}
+
+func (l slogLogger) Error(args ...interface{}) { l.log(ErrorLevel, fmt.Sprint(args...)) }
+func (l slogLogger) Errorf(format string, args ...interface{}) {
+	l.log(ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Fatal(args ...interface{}) {
+	l.log(FatalLevel, fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (l slogLogger) Fatalf(format string, args ...interface{}) {
+	l.log(FatalLevel, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (l slogLogger) Info(args ...interface{}) { l.log(InfoLevel, fmt.Sprint(args...)) }
+func (l slogLogger) Infof(format string, args ...interface{}) {
+	l.log(InfoLevel, fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Warn(args ...interface{}) { l.log(WarnLevel, fmt.Sprint(args...)) }
+func (l slogLogger) Warnf(format string, args ...interface{}) {
+	l.log(WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Debug(args ...interface{}) { l.log(DebugLevel, fmt.Sprint(args...)) }
+func (l slogLogger) Debugf(format string, args ...interface{}) {
+	l.log(DebugLevel, fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Trace(args ...interface{}) { l.log(TraceLevel, fmt.Sprint(args...)) }
+func (l slogLogger) Tracef(format string, args ...interface{}) {
+	l.log(TraceLevel, fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Panic(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	l.log(PanicLevel, msg)
+	panic(msg)
+}
+
+func (l slogLogger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(PanicLevel, msg)
+	panic(msg)
+}
@@ -0,0 +1,28 @@
+// Package message implements propagation of published notification attributes through context.Context,
+// so sinks can route and partition opaque payloads.
+package message
+
+import (
+	"context"
+)
+
+// Attributes of the published notification.
+type Attributes struct {
+	// Key identifies notification subject(user ID), sinks keep order of notifications with the same key.
+	Key string
+	// Type is notification operation type.
+	Type string
+}
+
+type contextKey struct{}
+
+// NewContext returns copy of ctx with notification attributes.
+func NewContext(ctx context.Context, attrs Attributes) context.Context {
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+// FromContext returns notification attributes or zero Attributes.
+func FromContext(ctx context.Context) Attributes {
+	attrs, _ := ctx.Value(contextKey{}).(Attributes)
+	return attrs
+}
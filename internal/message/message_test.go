@@ -0,0 +1,15 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	ao := assert.New(t)
+	ao.Equal(Attributes{}, FromContext(context.Background()))
+	attrs := Attributes{Key: "42", Type: "Insert"}
+	ao.Equal(attrs, FromContext(NewContext(context.Background(), attrs)))
+}
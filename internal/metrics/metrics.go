@@ -0,0 +1,199 @@
+// Package metrics implements Prometheus metrics of the service.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"test_task/internal/notificator"
+)
+
+const namespace = "test_task"
+
+// Push results.
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+// Metrics holds service metrics and their registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	dbQueryDuration     *prometheus.HistogramVec
+	notificationPushes  *prometheus.CounterVec
+	notificationBuffer  *prometheus.CounterVec
+	healthCheckUp       *prometheus.GaugeVec
+	healthCheckDuration *prometheus.HistogramVec
+}
+
+// New creates metrics with own registry, Go runtime and process metrics included.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of handled HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Database query duration by operation(create, query, update, delete, row, raw).",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table", "status"}),
+		notificationPushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "notification",
+			Name:      "pushes_total",
+			Help:      "Number of notifications pushed to the sink by sink and result.",
+		}, []string{"sink", "result"}),
+		notificationBuffer: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "notification",
+			Name:      "buffer_pushes_total",
+			Help:      "Number of notifications pushed to the buffer by outcome(accepted, blocked, rejected, dropped_newest, dropped_oldest, spilled).",
+		}, []string{"outcome"}),
+		healthCheckUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "health",
+			Name:      "check_up",
+			Help:      "Result of the last health check, 1 is healthy.",
+		}, []string{"checker"}),
+		healthCheckDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "health",
+			Name:      "check_duration_seconds",
+			Help:      "Health check duration.",
+			Buckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5},
+		}, []string{"checker"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.dbQueryDuration,
+		m.notificationPushes,
+		m.notificationBuffer,
+		m.healthCheckUp,
+		m.healthCheckDuration,
+	)
+	return m
+}
+
+// Handler serves metrics in Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest records handled HTTP request.
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+// ObserveQuery records database query.
+func (m *Metrics) ObserveQuery(operation, table string, d time.Duration, err error) {
+	status := resultSuccess
+	if err != nil {
+		status = resultFailure
+	}
+	m.dbQueryDuration.WithLabelValues(operation, table, status).Observe(d.Seconds())
+}
+
+// Queue describes notification buffer.
+type Queue interface {
+	Len() int
+	Cap() int
+}
+
+// RegisterNotificationQueue exposes length and capacity of the notification buffer.
+func (m *Metrics) RegisterNotificationQueue(q Queue) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "notification",
+			Name:      "buffer_length",
+			Help:      "Number of notifications waiting in the buffer and partitions of workers.",
+		}, func() float64 { return float64(q.Len()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "notification",
+			Name:      "buffer_capacity",
+			Help:      "Capacity of the notification buffer.",
+		}, func() float64 { return float64(q.Cap()) }),
+	)
+}
+
+// ObserveNotificationBuffer records outcome of push to the notification buffer.
+func (m *Metrics) ObserveNotificationBuffer(outcome string) {
+	m.notificationBuffer.WithLabelValues(outcome).Inc()
+}
+
+// InstrumentNotificator counts successful and failed pushes of the named sink.
+func (m *Metrics) InstrumentNotificator(name string, n notificator.RepositoryNotificator) notificator.RepositoryNotificator {
+	return &instrumentedNotificator{name: name, next: n, pushes: m.notificationPushes}
+}
+
+type instrumentedNotificator struct {
+	name   string
+	next   notificator.RepositoryNotificator
+	pushes *prometheus.CounterVec
+}
+
+func (n *instrumentedNotificator) Push(ctx context.Context, data []byte) error {
+	err := n.next.Push(ctx, data)
+	if err != nil {
+		n.pushes.WithLabelValues(n.name, resultFailure).Inc()
+		return err
+	}
+	n.pushes.WithLabelValues(n.name, resultSuccess).Inc()
+	return nil
+}
+
+// HealthChecker checks component health.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// InstrumentHealthChecker records result and duration of every check.
+func (m *Metrics) InstrumentHealthChecker(name string, c HealthChecker) HealthChecker {
+	return &instrumentedHealthChecker{name: name, next: c, metrics: m}
+}
+
+type instrumentedHealthChecker struct {
+	name    string
+	next    HealthChecker
+	metrics *Metrics
+}
+
+func (c *instrumentedHealthChecker) Health(ctx context.Context) error {
+	start := time.Now()
+	err := c.next.Health(ctx)
+	c.metrics.healthCheckDuration.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+	up := 1.0
+	if err != nil {
+		up = 0
+	}
+	c.metrics.healthCheckUp.WithLabelValues(c.name).Set(up)
+	return err
+}
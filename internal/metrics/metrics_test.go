@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/notificator"
+)
+
+type fakeQueue struct{}
+
+func (fakeQueue) Len() int { return 3 }
+func (fakeQueue) Cap() int { return 10 }
+
+type fakeHealthChecker struct {
+	err error
+}
+
+func (f fakeHealthChecker) Health(context.Context) error {
+	return f.err
+}
+
+func TestMetrics(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	m := New()
+
+	m.ObserveHTTPRequest(http.MethodGet, "/api/v1/users", http.StatusOK, 20*time.Millisecond)
+	m.ObserveHTTPRequest(http.MethodGet, "/api/v1/users", http.StatusOK, 30*time.Millisecond)
+	m.ObserveQuery("query", "users", 2*time.Millisecond, nil)
+	m.ObserveQuery("create", "users", time.Millisecond, errors.New("duplicate"))
+	m.RegisterNotificationQueue(fakeQueue{})
+	m.ObserveNotificationBuffer(notificator.OutcomeAccepted)
+	m.ObserveNotificationBuffer(notificator.OutcomeAccepted)
+	m.ObserveNotificationBuffer(notificator.OutcomeDroppedOldest)
+
+	sink := notificator.NewMockRepositoryNotificator(ctrl)
+	sink.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil)
+	sink.EXPECT().Push(gomock.Any(), gomock.Any()).Return(errors.New("push error"))
+	instrumented := m.InstrumentNotificator("kafka", sink)
+	ao.NoError(instrumented.Push(context.Background(), []byte("{}")))
+	ao.Error(instrumented.Push(context.Background(), []byte("{}")))
+
+	ao.NoError(m.InstrumentHealthChecker("postgres", fakeHealthChecker{}).Health(context.Background()))
+	ao.Error(m.InstrumentHealthChecker("kafka", fakeHealthChecker{err: errors.New("down")}).Health(context.Background()))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	ao.Equal(http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		`test_task_http_requests_total{method="GET",route="/api/v1/users",status="200"} 2`,
+		`test_task_http_request_duration_seconds_count{method="GET",route="/api/v1/users",status="200"} 2`,
+		`test_task_db_query_duration_seconds_count{operation="query",status="success",table="users"} 1`,
+		`test_task_db_query_duration_seconds_count{operation="create",status="failure",table="users"} 1`,
+		`test_task_notification_buffer_length 3`,
+		`test_task_notification_buffer_capacity 10`,
+		`test_task_notification_buffer_pushes_total{outcome="accepted"} 2`,
+		`test_task_notification_buffer_pushes_total{outcome="dropped_oldest"} 1`,
+		`test_task_notification_pushes_total{result="success",sink="kafka"} 1`,
+		`test_task_notification_pushes_total{result="failure",sink="kafka"} 1`,
+		`test_task_health_check_up{checker="postgres"} 1`,
+		`test_task_health_check_up{checker="kafka"} 0`,
+		`go_goroutines`,
+	} {
+		ao.Contains(string(body), line)
+	}
+}
@@ -0,0 +1,80 @@
+package notificator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=backpressure.go --destination=backpressure_mock.go --package=notificator
+
+// Backpressure is behaviour of PubSub.Push when the buffer is full.
+type Backpressure string
+
+const (
+	// BackpressureBlock waits for room until the context is done or block timeout passes.
+	BackpressureBlock Backpressure = "block"
+	// BackpressureFail rejects notification at once.
+	BackpressureFail Backpressure = "fail"
+	// BackpressureDropNewest discards pushed notification.
+	BackpressureDropNewest Backpressure = "drop_newest"
+	// BackpressureDropOldest discards the oldest buffered notification to make room.
+	BackpressureDropOldest Backpressure = "drop_oldest"
+	// BackpressureSpill writes notification to the spill queue, it is pushed once the buffer is drained.
+	BackpressureSpill Backpressure = "spill"
+)
+
+// Outcomes of PubSub.Push reported to BufferObserver.
+const (
+	OutcomeAccepted      = "accepted"
+	OutcomeBlocked       = "blocked"
+	OutcomeRejected      = "rejected"
+	OutcomeDroppedNewest = "dropped_newest"
+	OutcomeDroppedOldest = "dropped_oldest"
+	OutcomeSpilled       = "spilled"
+)
+
+// ErrBufferFull is returned by PubSub.Push when notification isn't accepted.
+var ErrBufferFull = errors.New("notification buffer is full")
+
+// ParseBackpressure parses policy name, empty name is BackpressureBlock.
+func ParseBackpressure(name string) (Backpressure, error) {
+	switch b := Backpressure(name); b {
+	case "":
+		return BackpressureBlock, nil
+	case BackpressureBlock, BackpressureFail, BackpressureDropNewest, BackpressureDropOldest, BackpressureSpill:
+		return b, nil
+	}
+	return "", fmt.Errorf("unknown backpressure policy %q", name)
+}
+
+// BufferObserver records outcome of every push to the buffer.
+type BufferObserver interface {
+	ObserveNotificationBuffer(outcome string)
+}
+
+// SpillQueue keeps notifications which didn't fit the buffer in order.
+type SpillQueue interface {
+	Push(data []byte) error
+	// Peek returns the oldest notification which isn't taken, nil if there is none.
+	Peek() ([]byte, error)
+	// Take marks the peeked notification as taken, it is kept in the queue until Ack.
+	// It returns offset to acknowledge.
+	Take() (uint64, error)
+	// Ack removes taken notification after it is pushed.
+	Ack(offset uint64) error
+	// Len returns number of notifications which aren't taken.
+	Len() int
+}
+
+// DurableQueue keeps notifications on disk until they are pushed, so they survive restarts.
+type DurableQueue interface {
+	Append(data []byte) error
+	// Read waits for the next notification, it returns offset to acknowledge after push.
+	// Corrupt notification is skipped and reported as error.
+	Read(ctx context.Context) (uint64, []byte, error)
+	// Ack marks notification as pushed, it isn't read again after restart.
+	Ack(offset uint64) error
+	// Len returns number of notifications which aren't acknowledged.
+	Len() int
+}
@@ -0,0 +1,222 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: backpressure.go
+
+// Package notificator is a generated GoMock package.
+package notificator
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBufferObserver is a mock of BufferObserver interface.
+type MockBufferObserver struct {
+	ctrl     *gomock.Controller
+	recorder *MockBufferObserverMockRecorder
+}
+
+// MockBufferObserverMockRecorder is the mock recorder for MockBufferObserver.
+type MockBufferObserverMockRecorder struct {
+	mock *MockBufferObserver
+}
+
+// NewMockBufferObserver creates a new mock instance.
+func NewMockBufferObserver(ctrl *gomock.Controller) *MockBufferObserver {
+	mock := &MockBufferObserver{ctrl: ctrl}
+	mock.recorder = &MockBufferObserverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBufferObserver) EXPECT() *MockBufferObserverMockRecorder {
+	return m.recorder
+}
+
+// ObserveNotificationBuffer mocks base method.
+func (m *MockBufferObserver) ObserveNotificationBuffer(outcome string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveNotificationBuffer", outcome)
+}
+
+// ObserveNotificationBuffer indicates an expected call of ObserveNotificationBuffer.
+func (mr *MockBufferObserverMockRecorder) ObserveNotificationBuffer(outcome interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveNotificationBuffer", reflect.TypeOf((*MockBufferObserver)(nil).ObserveNotificationBuffer), outcome)
+}
+
+// MockSpillQueue is a mock of SpillQueue interface.
+type MockSpillQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockSpillQueueMockRecorder
+}
+
+// MockSpillQueueMockRecorder is the mock recorder for MockSpillQueue.
+type MockSpillQueueMockRecorder struct {
+	mock *MockSpillQueue
+}
+
+// NewMockSpillQueue creates a new mock instance.
+func NewMockSpillQueue(ctrl *gomock.Controller) *MockSpillQueue {
+	mock := &MockSpillQueue{ctrl: ctrl}
+	mock.recorder = &MockSpillQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSpillQueue) EXPECT() *MockSpillQueueMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockSpillQueue) Ack() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockSpillQueueMockRecorder) Ack() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockSpillQueue)(nil).Ack))
+}
+
+// Len mocks base method.
+func (m *MockSpillQueue) Len() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockSpillQueueMockRecorder) Len() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockSpillQueue)(nil).Len))
+}
+
+// Peek mocks base method.
+func (m *MockSpillQueue) Peek() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Peek")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Peek indicates an expected call of Peek.
+func (mr *MockSpillQueueMockRecorder) Peek() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Peek", reflect.TypeOf((*MockSpillQueue)(nil).Peek))
+}
+
+// Push mocks base method.
+func (m *MockSpillQueue) Push(data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Push", data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Push indicates an expected call of Push.
+func (mr *MockSpillQueueMockRecorder) Push(data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockSpillQueue)(nil).Push), data)
+}
+
+// Take mocks base method.
+func (m *MockSpillQueue) Take() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockSpillQueueMockRecorder) Take() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockSpillQueue)(nil).Take))
+}
+
+// MockDurableQueue is a mock of DurableQueue interface.
+type MockDurableQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockDurableQueueMockRecorder
+}
+
+// MockDurableQueueMockRecorder is the mock recorder for MockDurableQueue.
+type MockDurableQueueMockRecorder struct {
+	mock *MockDurableQueue
+}
+
+// NewMockDurableQueue creates a new mock instance.
+func NewMockDurableQueue(ctrl *gomock.Controller) *MockDurableQueue {
+	mock := &MockDurableQueue{ctrl: ctrl}
+	mock.recorder = &MockDurableQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDurableQueue) EXPECT() *MockDurableQueueMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockDurableQueue) Ack(offset uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", offset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockDurableQueueMockRecorder) Ack(offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockDurableQueue)(nil).Ack), offset)
+}
+
+// Append mocks base method.
+func (m *MockDurableQueue) Append(data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockDurableQueueMockRecorder) Append(data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockDurableQueue)(nil).Append), data)
+}
+
+// Len mocks base method.
+func (m *MockDurableQueue) Len() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockDurableQueueMockRecorder) Len() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockDurableQueue)(nil).Len))
+}
+
+// Read mocks base method.
+func (m *MockDurableQueue) Read(ctx context.Context) (uint64, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read", ctx)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockDurableQueueMockRecorder) Read(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockDurableQueue)(nil).Read), ctx)
+}
@@ -0,0 +1,110 @@
+package notificator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/pagination"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=dead_letter.go --destination=dead_letter_mock.go --package=notificator
+
+const (
+	// fieldDeadLetterID log field with dead letter ID.
+	fieldDeadLetterID = "dead_letter_id"
+
+	replayBatchSize = 100
+)
+
+// ErrReplay is returned when dead letter isn't pushed again.
+var ErrReplay = errors.New("replay failed")
+
+type DeadLetterRepository interface {
+	Add(ctx context.Context, letter entity.DeadLetter) (entity.DeadLetter, error)
+	List(ctx context.Context, page pagination.Pagination) ([]entity.DeadLetter, int64, error)
+	Get(ctx context.Context, id string) (entity.DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+	Purge(ctx context.Context) (int64, error)
+}
+
+// DeadLetters manages notifications which weren't delivered after all attempts.
+type DeadLetters struct {
+	repo        DeadLetterRepository
+	notificator RepositoryNotificator
+}
+
+// NewDeadLetters creates new instance of DeadLetters, replayed letters are pushed to notificator.
+func NewDeadLetters(repo DeadLetterRepository, notificator RepositoryNotificator) *DeadLetters {
+	return &DeadLetters{repo: repo, notificator: notificator}
+}
+
+// List returns page of dead letters, the oldest first.
+func (d *DeadLetters) List(ctx context.Context, page pagination.Pagination) ([]entity.DeadLetter, int64, error) {
+	return d.repo.List(ctx, page)
+}
+
+func (d *DeadLetters) Get(ctx context.Context, id string) (entity.DeadLetter, error) {
+	return d.repo.Get(ctx, id)
+}
+
+// Replay pushes dead letter again and removes it on success.
+func (d *DeadLetters) Replay(ctx context.Context, id string) error {
+	letter, err := d.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return d.replay(ctx, letter)
+}
+
+// ReplayAll replays dead letters the oldest first and stops on the first failure, so per key order is kept.
+// Returns number of replayed letters.
+func (d *DeadLetters) ReplayAll(ctx context.Context) (int, error) {
+	replayed := 0
+	for {
+		letters, _, err := d.repo.List(ctx, pagination.Pagination{Number: 1, Size: replayBatchSize})
+		if err != nil {
+			return replayed, err
+		}
+		for _, letter := range letters {
+			if err = d.replay(ctx, letter); err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+		if len(letters) < replayBatchSize {
+			return replayed, nil
+		}
+	}
+}
+
+func (d *DeadLetters) replay(ctx context.Context, letter entity.DeadLetter) error {
+	// dead letter written before the envelope is published as CloudEvent, dead letter ID is its event ID
+	payload, err := upgradePayload(letter.Payload, letter.ID, letter.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrReplay, err)
+	}
+	var n Notification
+	// payload is produced by PubSub, it is only used to restore request context
+	_ = json.Unmarshal(payload, &n)
+	if err = d.notificator.Push(n.context(ctx), payload); err != nil {
+		return fmt.Errorf("%w: %w", ErrReplay, err)
+	}
+	if err := d.repo.Delete(ctx, letter.ID); err != nil && !errors.Is(err, datastore.ErrNotFound) {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+// Delete removes dead letter without replay.
+func (d *DeadLetters) Delete(ctx context.Context, id string) error {
+	return d.repo.Delete(ctx, id)
+}
+
+// Purge removes all dead letters and returns their number.
+func (d *DeadLetters) Purge(ctx context.Context) (int64, error) {
+	return d.repo.Purge(ctx)
+}
@@ -0,0 +1,161 @@
+package notificator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/message"
+	"test_task/internal/pagination"
+	"test_task/internal/requestid"
+)
+
+func TestDeadLetters_Replay(t *testing.T) {
+	letter := entity.DeadLetter{
+		ID:      "1",
+		Key:     "42",
+		Type:    string(Delete),
+		Payload: []byte(`{"specversion":"1.0","id":"e1","type":"test_task.user.deleted","subject":"42","requestid":"abc"}`),
+	}
+	legacyLetter := entity.DeadLetter{
+		ID:        "2",
+		Key:       "42",
+		Type:      string(Insert),
+		Payload:   []byte(`{"Type":"Insert","Key":"42","Data":{"ID":"42","Password":"S3cret-pass!"}}`),
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name        string
+		mockSetup   func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator)
+		expectedErr string
+	}{
+		{
+			name: "replayed letter is removed",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				mockRepo.EXPECT().Get(gomock.Any(), "1").Return(letter, nil)
+				mockNotificator.EXPECT().Push(gomock.Any(), letter.Payload).DoAndReturn(func(ctx context.Context, _ []byte) error {
+					assert.Equal(t, "abc", requestid.FromContext(ctx))
+					assert.Equal(t, message.Attributes{Key: "42", Type: string(Delete)}, message.FromContext(ctx))
+					return nil
+				})
+				mockRepo.EXPECT().Delete(gomock.Any(), "1").Return(nil)
+			},
+		},
+		{
+			name: "letter removed concurrently",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				mockRepo.EXPECT().Get(gomock.Any(), "1").Return(letter, nil)
+				mockNotificator.EXPECT().Push(gomock.Any(), letter.Payload).Return(nil)
+				mockRepo.EXPECT().Delete(gomock.Any(), "1").Return(datastore.ErrNotFound)
+			},
+		},
+		{
+			name: "letter written before the envelope is converted",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				mockRepo.EXPECT().Get(gomock.Any(), "1").Return(legacyLetter, nil)
+				mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, data []byte) error {
+					assert.JSONEq(t, `{"specversion":"1.0","id":"2","source":"/test_task/users","type":"test_task.user.created",`+
+						`"subject":"42","time":"2024-05-01T12:00:00Z","datacontenttype":"application/json",`+
+						`"dataschema":"urn:test_task:schema:user-event:v1","data":{"before":null,`+
+						`"after":{"id":"42","first_name":"","last_name":"","nickname":"","email":"","country":""},"changed_fields":["password"]}}`,
+						string(data))
+					return nil
+				})
+				mockRepo.EXPECT().Delete(gomock.Any(), "2").Return(nil)
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				mockRepo.EXPECT().Get(gomock.Any(), "1").Return(entity.DeadLetter{}, datastore.ErrNotFound)
+			},
+			expectedErr: "not found",
+		},
+		{
+			name: "push error keeps letter",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				mockRepo.EXPECT().Get(gomock.Any(), "1").Return(letter, nil)
+				mockNotificator.EXPECT().Push(gomock.Any(), letter.Payload).Return(errors.New("timeout"))
+			},
+			expectedErr: "replay failed: timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := NewMockDeadLetterRepository(ctrl)
+			mockNotificator := NewMockRepositoryNotificator(ctrl)
+			tt.mockSetup(mockRepo, mockNotificator)
+
+			err := NewDeadLetters(mockRepo, mockNotificator).Replay(context.Background(), "1")
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetters_ReplayAll(t *testing.T) {
+	batch := make([]entity.DeadLetter, replayBatchSize)
+	for i := range batch {
+		batch[i] = entity.DeadLetter{ID: "full", Payload: []byte(`{}`)}
+	}
+	tests := []struct {
+		name             string
+		mockSetup        func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator)
+		expectedReplayed int
+		expectedErr      error
+	}{
+		{
+			name: "all batches are replayed",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				page := pagination.Pagination{Number: 1, Size: replayBatchSize}
+				gomock.InOrder(
+					mockRepo.EXPECT().List(gomock.Any(), page).Return(batch, int64(replayBatchSize+1), nil),
+					mockRepo.EXPECT().List(gomock.Any(), page).Return([]entity.DeadLetter{{ID: "last", Payload: []byte(`{}`)}}, int64(1), nil),
+				)
+				mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil).Times(replayBatchSize + 1)
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).Times(replayBatchSize + 1)
+			},
+			expectedReplayed: replayBatchSize + 1,
+		},
+		{
+			name: "stops on the first failure",
+			mockSetup: func(mockRepo *MockDeadLetterRepository, mockNotificator *MockRepositoryNotificator) {
+				mockRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]entity.DeadLetter{
+					{ID: "1", Payload: []byte(`{}`)},
+					{ID: "2", Payload: []byte(`{}`)},
+					{ID: "3", Payload: []byte(`{}`)},
+				}, int64(3), nil)
+				gomock.InOrder(
+					mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil),
+					mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(errors.New("timeout")),
+				)
+				mockRepo.EXPECT().Delete(gomock.Any(), "1").Return(nil)
+			},
+			expectedReplayed: 1,
+			expectedErr:      ErrReplay,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockRepo := NewMockDeadLetterRepository(ctrl)
+			mockNotificator := NewMockRepositoryNotificator(ctrl)
+			tt.mockSetup(mockRepo, mockNotificator)
+
+			replayed, err := NewDeadLetters(mockRepo, mockNotificator).ReplayAll(context.Background())
+			ao.Equal(tt.expectedReplayed, replayed)
+			ao.ErrorIs(err, tt.expectedErr)
+		})
+	}
+}
@@ -4,20 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 )
 
 type Manager struct {
 	notificators []RepositoryNotificator
 	eg           *errgroup.Group
+	newID        func() string
+	now          func() time.Time
 }
 
 func NewManager(notificators []RepositoryNotificator) *Manager {
-	return &Manager{notificators: notificators, eg: &errgroup.Group{}}
+	return &Manager{notificators: notificators, eg: &errgroup.Group{}, newID: uuid.NewString, now: time.Now}
 }
 
 func (m *Manager) Push(ctx context.Context, data Notification) error {
+	data = stamp(withContextMetadata(ctx, data), m.newID, m.now)
 	for _, v := range m.notificators {
 		m.eg.Go(func() error {
 			res, err := json.Marshal(data)
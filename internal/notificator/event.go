@@ -0,0 +1,253 @@
+package notificator
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"test_task/internal/entity"
+	"test_task/internal/requestid"
+)
+
+// CloudEvents attributes of notifications, see https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md.
+const (
+	SpecVersion = "1.0"
+	// EventSource identifies the service as producer of events.
+	EventSource = "/test_task/users"
+	// UserEventSchema identifies version of data schema, it is changed on incompatible change of UserEventData.
+	UserEventSchema = "urn:test_task:schema:user-event:v1"
+
+	dataContentType = "application/json"
+	// requestIDExtension is extension attribute with request ID, names of extensions are lowercase alphanumeric.
+	requestIDExtension = "requestid"
+)
+
+// UserEventJSONSchema is JSON Schema of user event for consumers.
+//
+//go:embed schema/user-event.v1.json
+var UserEventJSONSchema []byte
+
+// eventTypes maps operation to CloudEvents type.
+var eventTypes = map[OperationType]string{
+	Insert: "test_task.user.created",
+	Update: "test_task.user.updated",
+	Delete: "test_task.user.deleted",
+}
+
+// UserSnapshot is state of the user in event, sensitive fields(password) are stripped.
+type UserSnapshot struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Nickname  string `json:"nickname"`
+	Email     string `json:"email"`
+	Country   string `json:"country"`
+}
+
+// UserEventData is data of user event. Before is nil for created user, After is nil for deleted user.
+type UserEventData struct {
+	Before *UserSnapshot `json:"before"`
+	After  *UserSnapshot `json:"after"`
+	// ChangedFields lists fields which differ between Before and After, changed password is listed without its value.
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// NewUserEventData creates event data from states of the user, nil state is absent.
+func NewUserEventData(before, after *entity.User) UserEventData {
+	var prev, next entity.User
+	if before != nil {
+		prev = *before
+	}
+	if after != nil {
+		next = *after
+	}
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"first_name", prev.FirstName, next.FirstName},
+		{"last_name", prev.LastName, next.LastName},
+		{"nickname", prev.Nickname, next.Nickname},
+		{"password", prev.Password, next.Password},
+		{"email", prev.Email, next.Email},
+		{"country", prev.Country, next.Country},
+	}
+	data := UserEventData{Before: snapshot(before), After: snapshot(after), ChangedFields: []string{}}
+	for _, f := range fields {
+		if f.before != f.after {
+			data.ChangedFields = append(data.ChangedFields, f.name)
+		}
+	}
+	return data
+}
+
+func snapshot(user *entity.User) *UserSnapshot {
+	if user == nil {
+		return nil
+	}
+	return &UserSnapshot{
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Nickname:  user.Nickname,
+		Email:     user.Email,
+		Country:   user.Country,
+	}
+}
+
+// MarshalJSON encodes notification as CloudEvent, metadata are extension attributes.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	event := map[string]interface{}{
+		"specversion":     SpecVersion,
+		"id":              n.ID,
+		"source":          EventSource,
+		"type":            n.eventType(),
+		"time":            n.Time.UTC(),
+		"datacontenttype": dataContentType,
+		"dataschema":      UserEventSchema,
+	}
+	if n.Key != "" {
+		event["subject"] = n.Key
+	}
+	if n.Data != nil {
+		event["data"] = n.Data
+	}
+	for k, v := range n.Metadata {
+		name := extensionName(k)
+		if _, ok := event[name]; ok {
+			return nil, fmt.Errorf("metadata %q conflicts with attribute", k)
+		}
+		event[name] = v
+	}
+	return json.Marshal(event)
+}
+
+// UnmarshalJSON decodes CloudEvent. Notification written before the envelope was introduced(left in outbox,
+// dead letters or queues) is decoded too, see unmarshalLegacy. Data is decoded into value which Data points to, if any.
+func (n *Notification) UnmarshalJSON(b []byte) error {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(b, &attributes); err != nil {
+		return err
+	}
+	if _, ok := attributes["specversion"]; !ok {
+		return n.unmarshalLegacy(b)
+	}
+	for name, value := range attributes {
+		var err error
+		switch name {
+		case "specversion", "source", "datacontenttype", "dataschema":
+		case "id":
+			err = json.Unmarshal(value, &n.ID)
+		case "type":
+			var eventType string
+			err = json.Unmarshal(value, &eventType)
+			n.Type = operationType(eventType)
+		case "subject":
+			err = json.Unmarshal(value, &n.Key)
+		case "time":
+			err = json.Unmarshal(value, &n.Time)
+		case "data":
+			err = json.Unmarshal(value, &n.Data)
+		default:
+			var v string
+			// extension of other type isn't produced by the service
+			if json.Unmarshal(value, &v) != nil {
+				continue
+			}
+			if n.Metadata == nil {
+				n.Metadata = make(map[string]string)
+			}
+			n.Metadata[metadataKey(name)] = v
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// unmarshalLegacy decodes notification written before the envelope. Its data is the user with password,
+// it is replaced with UserEventData, so the password isn't published.
+func (n *Notification) unmarshalLegacy(b []byte) error {
+	var l struct {
+		Type     OperationType
+		Key      string
+		Data     json.RawMessage
+		Metadata map[string]string
+	}
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*n = Notification{Type: l.Type, Key: l.Key, Metadata: l.Metadata}
+	var user *entity.User
+	// data which isn't user is dropped
+	if json.Unmarshal(l.Data, &user) != nil || user == nil {
+		return nil
+	}
+	if l.Type == Delete {
+		n.Data = NewUserEventData(user, nil)
+	} else {
+		n.Data = NewUserEventData(nil, user)
+	}
+	return nil
+}
+
+// upgradePayload converts payload written before the envelope to CloudEvent, other payloads are returned as they are.
+// Legacy payload has neither ID nor time, id and at of the stored message are used, so redelivery keeps them.
+func upgradePayload(payload []byte, id string, at time.Time) ([]byte, error) {
+	var attributes map[string]json.RawMessage
+	if json.Unmarshal(payload, &attributes) != nil {
+		return payload, nil
+	}
+	if _, ok := attributes["specversion"]; ok {
+		return payload, nil
+	}
+	var n Notification
+	if err := n.unmarshalLegacy(payload); err != nil {
+		return nil, fmt.Errorf("legacy payload: %w", err)
+	}
+	return json.Marshal(stamp(n, func() string { return id }, func() time.Time { return at }))
+}
+
+func (n Notification) eventType() string {
+	if t, ok := eventTypes[n.Type]; ok {
+		return t
+	}
+	return string(n.Type)
+}
+
+func operationType(eventType string) OperationType {
+	for op, t := range eventTypes {
+		if t == eventType {
+			return op
+		}
+	}
+	return OperationType(eventType)
+}
+
+// extensionName returns name of extension attribute for metadata key. Trace context keys are valid names already.
+func extensionName(key string) string {
+	if key == requestid.MetadataKey {
+		return requestIDExtension
+	}
+	return key
+}
+
+func metadataKey(name string) string {
+	if name == requestIDExtension {
+		return requestid.MetadataKey
+	}
+	return name
+}
+
+// stamp fills event ID and time of notification if they are empty.
+func stamp(data Notification, newID func() string, now func() time.Time) Notification {
+	if data.ID == "" {
+		data.ID = newID()
+	}
+	if data.Time.IsZero() {
+		data.Time = now()
+	}
+	return data
+}
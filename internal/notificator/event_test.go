@@ -0,0 +1,164 @@
+package notificator
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/entity"
+	"test_task/internal/requestid"
+)
+
+func TestNewUserEventData(t *testing.T) {
+	before := entity.User{ID: "42", FirstName: "John", Nickname: "jdoe", Password: "old", Email: "jdoe@example.com", Country: "UK"}
+	after := before
+	after.FirstName, after.Password = "Johnny", "new"
+	tests := []struct {
+		name          string
+		before, after *entity.User
+		expected      UserEventData
+	}{
+		{
+			name:  "created",
+			after: &before,
+			expected: UserEventData{
+				After:         &UserSnapshot{ID: "42", FirstName: "John", Nickname: "jdoe", Email: "jdoe@example.com", Country: "UK"},
+				ChangedFields: []string{"first_name", "nickname", "password", "email", "country"},
+			},
+		},
+		{
+			name:   "updated",
+			before: &before,
+			after:  &after,
+			expected: UserEventData{
+				Before:        &UserSnapshot{ID: "42", FirstName: "John", Nickname: "jdoe", Email: "jdoe@example.com", Country: "UK"},
+				After:         &UserSnapshot{ID: "42", FirstName: "Johnny", Nickname: "jdoe", Email: "jdoe@example.com", Country: "UK"},
+				ChangedFields: []string{"first_name", "password"},
+			},
+		},
+		{
+			name:   "unchanged",
+			before: &before,
+			after:  &before,
+			expected: UserEventData{
+				Before:        &UserSnapshot{ID: "42", FirstName: "John", Nickname: "jdoe", Email: "jdoe@example.com", Country: "UK"},
+				After:         &UserSnapshot{ID: "42", FirstName: "John", Nickname: "jdoe", Email: "jdoe@example.com", Country: "UK"},
+				ChangedFields: []string{},
+			},
+		},
+		{
+			name:     "deleted unknown",
+			expected: UserEventData{ChangedFields: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewUserEventData(tt.before, tt.after))
+		})
+	}
+}
+
+func TestNotification_JSON(t *testing.T) {
+	ao := assert.New(t)
+	user := entity.User{ID: "42", Nickname: "jdoe", Password: "S3cret-pass!"}
+	n := Notification{
+		ID:       "1",
+		Time:     time.Date(2024, 5, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		Type:     Insert,
+		Key:      "42",
+		Data:     NewUserEventData(nil, &user),
+		Metadata: map[string]string{requestid.MetadataKey: "abc", "traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}
+	data, err := json.Marshal(n)
+	require.NoError(t, err)
+	ao.JSONEq(`{
+		"specversion": "1.0",
+		"id": "1",
+		"source": "/test_task/users",
+		"type": "test_task.user.created",
+		"subject": "42",
+		"time": "2024-05-01T12:00:00Z",
+		"datacontenttype": "application/json",
+		"dataschema": "urn:test_task:schema:user-event:v1",
+		"requestid": "abc",
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"data": {
+			"before": null,
+			"after": {"id": "42", "first_name": "", "last_name": "", "nickname": "jdoe", "email": "", "country": ""},
+			"changed_fields": ["nickname", "password"]
+		}
+	}`, string(data))
+	ao.NotContains(string(data), user.Password)
+
+	decoded := Notification{Data: &UserEventData{}}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	ao.Equal("1", decoded.ID)
+	ao.True(n.Time.Equal(decoded.Time))
+	ao.Equal(Insert, decoded.Type)
+	ao.Equal("42", decoded.Key)
+	ao.Equal(n.Metadata, decoded.Metadata)
+	ao.Equal(n.Data, *decoded.Data.(*UserEventData))
+
+	// notification written before the envelope, its user data is converted without password
+	var legacy Notification
+	require.NoError(t, json.Unmarshal([]byte(`{"Type":"Delete","Key":"42","Data":{"ID":"42"},"Metadata":{"request_id":"abc"}}`), &legacy))
+	ao.Equal(Notification{Type: Delete, Key: "42", Data: UserEventData{Before: &UserSnapshot{ID: "42"}, ChangedFields: []string{}},
+		Metadata: map[string]string{"request_id": "abc"}}, legacy)
+	legacy = Notification{Data: &json.RawMessage{}}
+	require.NoError(t, json.Unmarshal([]byte(`{"Type":"Insert","Key":"42","Data":{"ID":"42","Nickname":"jdoe","Password":"S3cret-pass!"}}`), &legacy))
+	ao.Equal(Notification{Type: Insert, Key: "42", Data: NewUserEventData(nil, &entity.User{ID: "42", Nickname: "jdoe", Password: "S3cret-pass!"})}, legacy)
+	require.NoError(t, json.Unmarshal([]byte(`{"Type":"Update","Key":"42","Data":"unknown"}`), &legacy))
+	ao.Equal(Notification{Type: Update, Key: "42"}, legacy)
+
+	_, err = json.Marshal(Notification{Metadata: map[string]string{"id": "1"}})
+	ao.ErrorContains(err, `metadata "id" conflicts with attribute`)
+}
+
+func TestUpgradePayload(t *testing.T) {
+	ao := assert.New(t)
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	data, err := upgradePayload([]byte(`{"Type":"Update","Key":"42","Data":{"ID":"42","Password":"S3cret-pass!"},"Metadata":{"request_id":"abc"}}`), "7", at)
+	require.NoError(t, err)
+	ao.JSONEq(`{
+		"specversion": "1.0",
+		"id": "7",
+		"source": "/test_task/users",
+		"type": "test_task.user.updated",
+		"subject": "42",
+		"time": "2024-05-01T12:00:00Z",
+		"datacontenttype": "application/json",
+		"dataschema": "urn:test_task:schema:user-event:v1",
+		"requestid": "abc",
+		"data": {
+			"before": null,
+			"after": {"id": "42", "first_name": "", "last_name": "", "nickname": "", "email": "", "country": ""},
+			"changed_fields": ["password"]
+		}
+	}`, string(data))
+
+	// CloudEvent and payload which isn't JSON object are kept
+	for _, payload := range []string{`{"specversion":"1.0","id":"1","data":{"ID":"42","Password":"x"}}`, `not json`} {
+		data, err = upgradePayload([]byte(payload), "7", at)
+		ao.NoError(err)
+		ao.Equal(payload, string(data))
+	}
+	_, err = upgradePayload([]byte(`{"Type":1}`), "7", at)
+	ao.Error(err)
+}
+
+func TestUserEventJSONSchema(t *testing.T) {
+	var schema struct {
+		ID         string `json:"$id"`
+		Properties struct {
+			DataSchema struct {
+				Const string `json:"const"`
+			} `json:"dataschema"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(UserEventJSONSchema, &schema))
+	assert.Equal(t, UserEventSchema, schema.ID)
+	assert.Equal(t, UserEventSchema, schema.Properties.DataSchema.Const)
+}
@@ -2,6 +2,17 @@ package notificator
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"test_task/internal/logger"
+	"test_task/internal/message"
+	"test_task/internal/requestid"
+	"test_task/internal/tracing"
 )
 
 //go:generate go run github.com/golang/mock/mockgen --source=notificator.go --destination=notificator_mock.go --package=notificator
@@ -14,9 +25,22 @@ const (
 	Delete OperationType = "Delete"
 )
 
+// FieldOperationType log field with notification operation type.
+const FieldOperationType = "operation_type"
+
+// fieldData log field with notification payload.
+const fieldData = "data"
+
+// Notification describes change of the entity, it is encoded as CloudEvent(see event.go).
+// Key identifies notification subject(user ID), notifications with the same key are related.
+// ID and Time are filled on push if empty.
 type Notification struct {
-	Type OperationType
-	Data interface{}
+	ID       string
+	Time     time.Time
+	Type     OperationType
+	Key      string `json:",omitempty"`
+	Data     interface{}
+	Metadata map[string]string `json:",omitempty"`
 }
 
 type Notificator interface {
@@ -26,3 +50,53 @@ type Notificator interface {
 type RepositoryNotificator interface {
 	Push(ctx context.Context, data []byte) error
 }
+
+// withContextMetadata returns copy of notification with request ID and trace context from ctx in metadata.
+func withContextMetadata(ctx context.Context, data Notification) Notification {
+	metadata := propagation.MapCarrier{}
+	if id := requestid.FromContext(ctx); id != "" {
+		metadata[requestid.MetadataKey] = id
+	}
+	tracing.Propagator.Inject(ctx, metadata)
+	if len(metadata) == 0 {
+		return data
+	}
+	for k, v := range data.Metadata {
+		if _, ok := metadata[k]; !ok {
+			metadata[k] = v
+		}
+	}
+	data.Metadata = metadata
+	return data
+}
+
+// context restores request context values stored in notification metadata and adds notification attributes for the sink.
+// Trace context isn't restored as parent, see link.
+func (n Notification) context(ctx context.Context) context.Context {
+	ctx = message.NewContext(ctx, message.Attributes{Key: n.Key, Type: string(n.Type)})
+	if id := n.Metadata[requestid.MetadataKey]; id != "" {
+		return requestid.NewContext(ctx, id)
+	}
+	return ctx
+}
+
+// link returns link to the span which pushed notification.
+func (n Notification) link(ctx context.Context) (trace.Link, bool) {
+	sc := trace.SpanContextFromContext(tracing.Propagator.Extract(ctx, propagation.MapCarrier(n.Metadata)))
+	return trace.Link{SpanContext: sc}, sc.IsValid()
+}
+
+// Handler handles notification published by any service instance.
+type Handler func(ctx context.Context, n Notification)
+
+// Decode adapts h to payloads produced by Notificator, request context of the notification is restored.
+func Decode(h Handler, l logger.Logger) func(ctx context.Context, payload []byte) {
+	return func(ctx context.Context, payload []byte) {
+		var n Notification
+		if err := json.Unmarshal(payload, &n); err != nil {
+			l.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "notification decode"}).Error(fmt.Errorf("unmarshal: %w", err))
+			return
+		}
+		h(n.context(ctx), n)
+	}
+}
@@ -0,0 +1,40 @@
+package notificator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/logger"
+	"test_task/internal/message"
+	"test_task/internal/requestid"
+)
+
+func TestDecode(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockLogger := logger.NewMockLogger(ctrl)
+
+	var handled []Notification
+	h := Decode(func(ctx context.Context, n Notification) {
+		ao.Equal("abc", requestid.FromContext(ctx))
+		ao.Equal(message.Attributes{Key: "42", Type: string(Delete)}, message.FromContext(ctx))
+		handled = append(handled, n)
+	}, mockLogger)
+
+	h(context.Background(), []byte(`{"Type":"Delete","Key":"42","Data":{"id":"42"},"Metadata":{"request_id":"abc"}}`))
+	ao.Equal([]Notification{{Type: Delete, Key: "42", Data: UserEventData{Before: &UserSnapshot{ID: "42"}, ChangedFields: []string{}},
+		Metadata: map[string]string{"request_id": "abc"}}}, handled)
+
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger)
+	mockLogger.EXPECT().WithFields(logger.Fields{logger.FieldOperation: "notification decode"}).Return(mockLogger)
+	mockLogger.EXPECT().Error(gomock.Any()).Do(func(args ...interface{}) {
+		ao.True(errors.As(args[0].(error), new(*json.SyntaxError)))
+	})
+	h(context.Background(), []byte(`not json`))
+	ao.Len(handled, 1)
+}
@@ -0,0 +1,265 @@
+package notificator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/tracing"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=outbox.go --destination=outbox_mock.go --package=notificator
+
+type OutboxRepository interface {
+	Add(ctx context.Context, message entity.OutboxMessage) error
+	Claim(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]entity.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, deliveredSinks []string) error
+	MarkDeadLettered(ctx context.Context, id int64, attempts int, at time.Time, lastError string) error
+	DeleteSent(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Outbox writes notifications to the outbox. Push must be called in the transaction of the change,
+// so the notification is stored only if the change is committed.
+type Outbox struct {
+	repo  OutboxRepository
+	newID func() string
+	now   func() time.Time
+}
+
+func NewOutbox(repo OutboxRepository) *Outbox {
+	return &Outbox{repo: repo, newID: uuid.NewString, now: time.Now}
+}
+
+// Transactional marks Outbox as notificator which is pushed in the transaction of the change.
+func (o *Outbox) Transactional() {}
+
+func (o *Outbox) Push(ctx context.Context, data Notification) error {
+	payload, err := json.Marshal(stamp(withContextMetadata(ctx, data), o.newID, o.now))
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	return o.repo.Add(ctx, entity.OutboxMessage{
+		Key:       data.Key,
+		Type:      string(data.Type),
+		Payload:   payload,
+		CreatedAt: o.now(),
+	})
+}
+
+// SinkFanout pushes notification to every sink separately, so delivery of outbox message is tracked per sink.
+type SinkFanout interface {
+	// PushExcept pushes data to sinks not named in delivered and returns names of sinks which accepted it.
+	PushExcept(ctx context.Context, data []byte, delivered []string) ([]string, error)
+}
+
+// RelayConfig configures Relay.
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is number of failed attempts after which message is moved to dead letters.
+	MaxAttempts int
+	// Lease is time for which claimed messages are hidden from other relays.
+	Lease time.Duration
+	// RetryBackoff is delay after the first failure, it doubles with every attempt up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Retention is time for which sent messages are kept, they are removed every CleanupInterval.
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+// Relay publishes outbox messages to the sinks. Message is published at least once, failed message is retried
+// with backoff only for sinks which didn't accept it and blocks the following messages with the same key.
+// After MaxAttempts failures it is moved to dead letters, so the following messages are published.
+type Relay struct {
+	repo        OutboxRepository
+	fanout      SinkFanout
+	deadLetters DeadLetterRepository
+	cfg         RelayConfig
+	logger      logger.Logger
+	now         func() time.Time
+}
+
+// NewRelay creates new instance of Relay, zero values of cfg are replaced with defaults.
+// Nil deadLetters drops messages which failed all attempts.
+func NewRelay(repo OutboxRepository, fanout SinkFanout, deadLetters DeadLetterRepository, cfg RelayConfig, l logger.Logger) *Relay {
+	defaults := []struct {
+		value    *time.Duration
+		fallback time.Duration
+	}{
+		{&cfg.PollInterval, time.Second},
+		{&cfg.Lease, 30 * time.Second},
+		{&cfg.RetryBackoff, time.Second},
+		{&cfg.MaxRetryBackoff, 5 * time.Minute},
+		{&cfg.Retention, 24 * time.Hour},
+		{&cfg.CleanupInterval, time.Hour},
+	}
+	for _, d := range defaults {
+		if *d.value <= 0 {
+			*d.value = d.fallback
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	return &Relay{repo: repo, fanout: fanout, deadLetters: deadLetters, cfg: cfg, logger: l, now: time.Now}
+}
+
+// Start publishes messages until ctx is done.
+func (r *Relay) Start(ctx context.Context) {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		}
+	}
+}
+
+// drain publishes batches while they are full, since full batch means there are more messages.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		if r.relay(ctx) < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// relay publishes one batch and returns number of claimed messages.
+func (r *Relay) relay(ctx context.Context) int {
+	messages, err := r.repo.Claim(ctx, r.cfg.BatchSize, r.now(), r.cfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "outbox claim"}).Error(err)
+		}
+		return 0
+	}
+	for _, m := range messages {
+		r.publish(ctx, m)
+	}
+	return len(messages)
+}
+
+// publish sends message in its own trace, linked to the trace of the request which made the change.
+func (r *Relay) publish(ctx context.Context, m entity.OutboxMessage) {
+	var n Notification
+	// payload is produced by Outbox, metadata is only used to restore request context
+	_ = json.Unmarshal(m.Payload, &n)
+	opts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String(FieldOperationType, m.Type),
+			attribute.String(logger.FieldUserID, m.Key),
+			attribute.Int("outbox.attempts", m.Attempts),
+		),
+	}
+	if link, ok := n.link(ctx); ok {
+		opts = append(opts, trace.WithLinks(link))
+	}
+	msgCtx, span := tracing.Tracer(tracerName).Start(n.context(ctx), "Outbox.relay", opts...)
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	l := r.logger.WithContext(msgCtx).WithFields(logger.Fields{
+		logger.FieldOperation: "outbox relay",
+		FieldOperationType:    m.Type,
+		logger.FieldUserID:    m.Key,
+	})
+	var accepted []string
+	// message written before the envelope is published as CloudEvent, outbox message ID is its event ID
+	payload, err := upgradePayload(m.Payload, strconv.FormatInt(m.ID, 10), m.CreatedAt)
+	if err == nil {
+		m.Payload = payload
+		accepted, err = r.fanout.PushExcept(msgCtx, m.Payload, m.DeliveredSinks)
+	}
+	if err != nil {
+		attempts := m.Attempts + 1
+		err = fmt.Errorf("push: %w", err)
+		l = l.WithFields(logger.Fields{"attempts": attempts})
+		l.Error(err)
+		if attempts >= r.cfg.MaxAttempts && r.deadLetter(msgCtx, l, m, attempts, err) {
+			return
+		}
+		delivered := append(slices.Clip(m.DeliveredSinks), accepted...)
+		if markErr := r.repo.MarkFailed(msgCtx, m.ID, attempts, r.now().Add(r.backoff(attempts)), err.Error(), delivered); markErr != nil {
+			l.Error(fmt.Errorf("mark failed: %w", markErr))
+		}
+		return
+	}
+	if markErr := r.repo.MarkSent(msgCtx, m.ID, r.now()); markErr != nil {
+		// message will be published again after lease
+		l.Error(fmt.Errorf("mark sent: %w", markErr))
+	}
+}
+
+// deadLetter moves message which failed the last attempt to dead letters, it reports false if message is left for retry.
+// Replayed dead letter is pushed to all sinks.
+func (r *Relay) deadLetter(ctx context.Context, l logger.Logger, m entity.OutboxMessage, attempts int, err error) bool {
+	if r.deadLetters == nil {
+		l.Warn("outbox message is dropped after all attempts")
+	} else {
+		letter, addErr := r.deadLetters.Add(ctx, entity.DeadLetter{
+			Key:       m.Key,
+			Type:      m.Type,
+			Payload:   m.Payload,
+			Attempts:  []entity.DeliveryAttempt{{At: r.now(), Error: err.Error()}},
+			CreatedAt: r.now(),
+		})
+		if addErr != nil {
+			l.Error(fmt.Errorf("dead letter: %w", addErr))
+			return false
+		}
+		l.WithFields(logger.Fields{fieldDeadLetterID: letter.ID}).Warn("outbox message is moved to dead letters")
+	}
+	if markErr := r.repo.MarkDeadLettered(ctx, m.ID, attempts, r.now(), err.Error()); markErr != nil {
+		// message will be published again after lease
+		l.Error(fmt.Errorf("mark dead lettered: %w", markErr))
+	}
+	return true
+}
+
+// backoff returns delay before attempt next to attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	return exponentialBackoff(r.cfg.RetryBackoff, r.cfg.MaxRetryBackoff, attempts)
+}
+
+// exponentialBackoff returns delay after attempts failures, it is initial after the first one and doubles up to maximum.
+func exponentialBackoff(initial, maximum time.Duration, attempts int) time.Duration {
+	d := initial
+	for i := 1; i < attempts && d < maximum; i++ {
+		d *= 2
+	}
+	return min(d, maximum)
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.repo.DeleteSent(ctx, r.now().Add(-r.cfg.Retention))
+	if err != nil {
+		r.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "outbox cleanup"}).Error(err)
+		return
+	}
+	if deleted > 0 {
+		r.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "outbox cleanup", "deleted": deleted}).Debug("sent outbox messages removed")
+	}
+}
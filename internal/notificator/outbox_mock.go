@@ -0,0 +1,161 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: outbox.go
+
+// Package notificator is a generated GoMock package.
+package notificator
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "test_task/internal/entity"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockOutboxRepository) Add(ctx context.Context, message entity.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockOutboxRepositoryMockRecorder) Add(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockOutboxRepository)(nil).Add), ctx, message)
+}
+
+// Claim mocks base method.
+func (m *MockOutboxRepository) Claim(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]entity.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, limit, now, lease)
+	ret0, _ := ret[0].([]entity.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockOutboxRepositoryMockRecorder) Claim(ctx, limit, now, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockOutboxRepository)(nil).Claim), ctx, limit, now, lease)
+}
+
+// DeleteSent mocks base method.
+func (m *MockOutboxRepository) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSent", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSent indicates an expected call of DeleteSent.
+func (mr *MockOutboxRepositoryMockRecorder) DeleteSent(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSent", reflect.TypeOf((*MockOutboxRepository)(nil).DeleteSent), ctx, before)
+}
+
+// MarkDeadLettered mocks base method.
+func (m *MockOutboxRepository) MarkDeadLettered(ctx context.Context, id int64, attempts int, at time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeadLettered", ctx, id, attempts, at, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDeadLettered indicates an expected call of MarkDeadLettered.
+func (mr *MockOutboxRepositoryMockRecorder) MarkDeadLettered(ctx, id, attempts, at, lastError interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeadLettered", reflect.TypeOf((*MockOutboxRepository)(nil).MarkDeadLettered), ctx, id, attempts, at, lastError)
+}
+
+// MarkFailed mocks base method.
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time, lastError string, deliveredSinks []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, attempts, nextAttemptAt, lastError, deliveredSinks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockOutboxRepositoryMockRecorder) MarkFailed(ctx, id, attempts, nextAttemptAt, lastError, deliveredSinks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockOutboxRepository)(nil).MarkFailed), ctx, id, attempts, nextAttemptAt, lastError, deliveredSinks)
+}
+
+// MarkSent mocks base method.
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, id int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSent", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSent indicates an expected call of MarkSent.
+func (mr *MockOutboxRepositoryMockRecorder) MarkSent(ctx, id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSent", reflect.TypeOf((*MockOutboxRepository)(nil).MarkSent), ctx, id, at)
+}
+
+// MockSinkFanout is a mock of SinkFanout interface.
+type MockSinkFanout struct {
+	ctrl     *gomock.Controller
+	recorder *MockSinkFanoutMockRecorder
+}
+
+// MockSinkFanoutMockRecorder is the mock recorder for MockSinkFanout.
+type MockSinkFanoutMockRecorder struct {
+	mock *MockSinkFanout
+}
+
+// NewMockSinkFanout creates a new mock instance.
+func NewMockSinkFanout(ctrl *gomock.Controller) *MockSinkFanout {
+	mock := &MockSinkFanout{ctrl: ctrl}
+	mock.recorder = &MockSinkFanoutMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSinkFanout) EXPECT() *MockSinkFanoutMockRecorder {
+	return m.recorder
+}
+
+// PushExcept mocks base method.
+func (m *MockSinkFanout) PushExcept(ctx context.Context, data []byte, delivered []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushExcept", ctx, data, delivered)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PushExcept indicates an expected call of PushExcept.
+func (mr *MockSinkFanoutMockRecorder) PushExcept(ctx, data, delivered interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushExcept", reflect.TypeOf((*MockSinkFanout)(nil).PushExcept), ctx, data, delivered)
+}
@@ -0,0 +1,235 @@
+package notificator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+	"test_task/internal/requestid"
+)
+
+func TestOutbox_Push(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockOutboxRepository(ctrl)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var stored entity.OutboxMessage
+	mockRepo.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, m entity.OutboxMessage) error {
+		stored = m
+		return nil
+	})
+	mockRepo.EXPECT().Add(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+
+	o := NewOutbox(mockRepo)
+	o.newID = func() string { return "event-1" }
+	o.now = func() time.Time { return now }
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	data := Notification{Type: Insert, Key: "1", Data: entity.User{ID: "1"}}
+	require.NoError(t, o.Push(ctx, data))
+	ao.EqualError(o.Push(ctx, data), "db error")
+
+	ao.Equal("1", stored.Key)
+	ao.Equal(string(Insert), stored.Type)
+	ao.Equal(now, stored.CreatedAt)
+	var payload Notification
+	require.NoError(t, json.Unmarshal(stored.Payload, &payload))
+	ao.Equal("req-1", payload.Metadata[requestid.MetadataKey])
+	ao.Equal("1", payload.Key)
+	ao.Equal("event-1", payload.ID)
+	ao.True(now.Equal(payload.Time))
+}
+
+func TestRelay_relay(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cfg := RelayConfig{BatchSize: 10, MaxAttempts: 5, Lease: time.Minute, RetryBackoff: time.Second, MaxRetryBackoff: 5 * time.Second}
+	payload, _ := json.Marshal(Notification{Type: Update, Key: "1", Metadata: map[string]string{requestid.MetadataKey: "req-1"}})
+	message := entity.OutboxMessage{ID: 7, Key: "1", Type: string(Update), Payload: payload, Attempts: 2, DeliveredSinks: []string{"kafka"}}
+	exhausted := message
+	exhausted.Attempts = 4
+	legacy := entity.OutboxMessage{ID: 8, Key: "1", Type: string(Insert), CreatedAt: now,
+		Payload: []byte(`{"Type":"Insert","Key":"1","Data":{"ID":"1","Password":"S3cret-pass!"}}`)}
+	pushErr := errors.New("sink http: broker unavailable")
+
+	tests := []struct {
+		name        string
+		deadLetters bool
+		mockSetup   func(repo *MockOutboxRepository, sink *MockSinkFanout, deadLetters *MockDeadLetterRepository, l *logger.MockLogger)
+		expected    int
+	}{
+		{
+			name: "published message is marked sent",
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, _ *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return([]entity.OutboxMessage{message}, nil)
+				sink.EXPECT().PushExcept(gomock.Any(), payload, []string{"kafka"}).DoAndReturn(func(ctx context.Context, _ []byte, _ []string) ([]string, error) {
+					assert.Equal(t, "req-1", requestid.FromContext(ctx))
+					return []string{"http"}, nil
+				})
+				repo.EXPECT().MarkSent(gomock.Any(), int64(7), now).Return(nil)
+			},
+			expected: 1,
+		},
+		{
+			name: "message written before the envelope is published as CloudEvent",
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, _ *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return([]entity.OutboxMessage{legacy}, nil)
+				sink.EXPECT().PushExcept(gomock.Any(), gomock.Any(), nil).DoAndReturn(func(_ context.Context, data []byte, _ []string) ([]string, error) {
+					assert.JSONEq(t, `{"specversion":"1.0","id":"8","source":"/test_task/users","type":"test_task.user.created",`+
+						`"subject":"1","time":"2024-05-01T12:00:00Z","datacontenttype":"application/json",`+
+						`"dataschema":"urn:test_task:schema:user-event:v1","data":{"before":null,`+
+						`"after":{"id":"1","first_name":"","last_name":"","nickname":"","email":"","country":""},"changed_fields":["password"]}}`,
+						string(data))
+					return []string{"http"}, nil
+				})
+				repo.EXPECT().MarkSent(gomock.Any(), int64(8), now).Return(nil)
+			},
+			expected: 1,
+		},
+		{
+			name: "failed message is retried with backoff",
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, _ *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return([]entity.OutboxMessage{message}, nil)
+				sink.EXPECT().PushExcept(gomock.Any(), payload, []string{"kafka"}).Return([]string{"rabbitmq"}, pushErr)
+				l.EXPECT().Error(fmt.Errorf("push: %w", pushErr))
+				// third attempt waits 4 times the initial backoff, retry skips sinks which accepted the message
+				repo.EXPECT().MarkFailed(gomock.Any(), int64(7), 3, now.Add(4*time.Second), "push: sink http: broker unavailable",
+					[]string{"kafka", "rabbitmq"}).Return(nil)
+			},
+			expected: 1,
+		},
+		{
+			name:        "message is moved to dead letters after the last attempt",
+			deadLetters: true,
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, deadLetters *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return([]entity.OutboxMessage{exhausted}, nil)
+				sink.EXPECT().PushExcept(gomock.Any(), payload, []string{"kafka"}).Return(nil, pushErr)
+				l.EXPECT().Error(fmt.Errorf("push: %w", pushErr))
+				deadLetters.EXPECT().Add(gomock.Any(), entity.DeadLetter{
+					Key:       "1",
+					Type:      string(Update),
+					Payload:   payload,
+					Attempts:  []entity.DeliveryAttempt{{At: now, Error: "push: sink http: broker unavailable"}},
+					CreatedAt: now,
+				}).Return(entity.DeadLetter{ID: "dl-1"}, nil)
+				l.EXPECT().Warn("outbox message is moved to dead letters")
+				repo.EXPECT().MarkDeadLettered(gomock.Any(), int64(7), 5, now, "push: sink http: broker unavailable").Return(nil)
+			},
+			expected: 1,
+		},
+		{
+			name:        "message is retried if dead letter isn't stored",
+			deadLetters: true,
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, deadLetters *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return([]entity.OutboxMessage{exhausted}, nil)
+				sink.EXPECT().PushExcept(gomock.Any(), payload, []string{"kafka"}).Return(nil, pushErr)
+				l.EXPECT().Error(fmt.Errorf("push: %w", pushErr))
+				deadLetters.EXPECT().Add(gomock.Any(), gomock.Any()).Return(entity.DeadLetter{}, errors.New("db error"))
+				l.EXPECT().Error(fmt.Errorf("dead letter: %w", errors.New("db error")))
+				repo.EXPECT().MarkFailed(gomock.Any(), int64(7), 5, now.Add(5*time.Second), "push: sink http: broker unavailable",
+					[]string{"kafka"}).Return(nil)
+			},
+			expected: 1,
+		},
+		{
+			name: "message is dropped after the last attempt without dead letters",
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, _ *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return([]entity.OutboxMessage{exhausted}, nil)
+				sink.EXPECT().PushExcept(gomock.Any(), payload, []string{"kafka"}).Return(nil, pushErr)
+				l.EXPECT().Error(fmt.Errorf("push: %w", pushErr))
+				l.EXPECT().Warn("outbox message is dropped after all attempts")
+				repo.EXPECT().MarkDeadLettered(gomock.Any(), int64(7), 5, now, "push: sink http: broker unavailable").Return(nil)
+			},
+			expected: 1,
+		},
+		{
+			name: "claim error",
+			mockSetup: func(repo *MockOutboxRepository, sink *MockSinkFanout, _ *MockDeadLetterRepository, l *logger.MockLogger) {
+				repo.EXPECT().Claim(gomock.Any(), 10, now, time.Minute).Return(nil, errors.New("db error"))
+				l.EXPECT().Error(errors.New("db error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := NewMockOutboxRepository(ctrl)
+			mockSink := NewMockSinkFanout(ctrl)
+			mockDeadLetters := NewMockDeadLetterRepository(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tt.mockSetup(mockRepo, mockSink, mockDeadLetters, mockLogger)
+
+			var deadLetters DeadLetterRepository
+			if tt.deadLetters {
+				deadLetters = mockDeadLetters
+			}
+			r := NewRelay(mockRepo, mockSink, deadLetters, cfg, mockLogger)
+			r.now = func() time.Time { return now }
+			assert.Equal(t, tt.expected, r.relay(context.Background()))
+		})
+	}
+}
+
+func TestRelay_backoff(t *testing.T) {
+	r := NewRelay(nil, nil, nil, RelayConfig{RetryBackoff: time.Second, MaxRetryBackoff: 10 * time.Second}, nil)
+	tests := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		4:  8 * time.Second,
+		5:  10 * time.Second,
+		60: 10 * time.Second,
+	}
+	for attempts, expected := range tests {
+		assert.Equal(t, expected, r.backoff(attempts), "attempts=%d", attempts)
+	}
+}
+
+func TestRelay_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockOutboxRepository(ctrl)
+	mockSink := NewMockSinkFanout(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Debug(gomock.Any()).AnyTimes()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	full := []entity.OutboxMessage{{ID: 1, Key: "1"}, {ID: 2, Key: "2"}}
+	gomock.InOrder(
+		// full batch is followed by the next claim without waiting
+		mockRepo.EXPECT().Claim(gomock.Any(), 2, gomock.Any(), gomock.Any()).Return(full, nil),
+		mockRepo.EXPECT().Claim(gomock.Any(), 2, gomock.Any(), gomock.Any()).Return(full[:1], nil),
+		mockRepo.EXPECT().Claim(gomock.Any(), 2, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(context.Context, int, time.Time, time.Duration) ([]entity.OutboxMessage, error) {
+				cancel()
+				return nil, context.Canceled
+			}),
+	)
+	mockSink.EXPECT().PushExcept(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(3)
+	mockRepo.EXPECT().MarkSent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	mockRepo.EXPECT().DeleteSent(gomock.Any(), gomock.Any()).Return(int64(1), nil).AnyTimes()
+
+	r := NewRelay(mockRepo, mockSink, nil, RelayConfig{PollInterval: time.Millisecond, BatchSize: 2, CleanupInterval: time.Millisecond}, mockLogger)
+	done := make(chan struct{})
+	go func() {
+		r.Start(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("relay is not stopped")
+	}
+}
@@ -4,45 +4,548 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"test_task/internal/entity"
 	"test_task/internal/logger"
+	"test_task/internal/tracing"
 )
 
+const tracerName = "test_task/internal/notificator"
+
+// partitionBufferSize is number of notifications taken from the buffer which wait for the worker of their partition.
+const partitionBufferSize = 8
+
+// PubSubConfig configures PubSub.
+type PubSubConfig struct {
+	BufferSize int
+	// Workers push notifications in parallel, notifications are partitioned between them by key.
+	Workers int
+	// MaxAttempts limits push attempts of one notification, 1 disables retries.
+	MaxAttempts int
+	// Backoff is delay after the first failure, it doubles with every attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is share of delay by which it is randomized in both directions.
+	Jitter float64
+	// AttemptTimeout limits one push attempt and write of the dead letter.
+	AttemptTimeout time.Duration
+	// Backpressure is applied when the buffer is full, BackpressureSpill requires Spill.
+	Backpressure Backpressure
+	// BlockTimeout limits wait of BackpressureBlock, zero waits until the context is done.
+	BlockTimeout time.Duration
+	Spill        SpillQueue
+	// Observer records push outcomes, it is optional.
+	Observer BufferObserver
+	// Queue replaces the buffer, backpressure isn't applied since notification is rejected only if the queue is full.
+	Queue DurableQueue
+	// DrainTimeout limits push of buffered and dispatched notifications after the consumer is stopped.
+	DrainTimeout time.Duration
+}
+
 type PubSub struct {
 	notificator RepositoryNotificator
+	deadLetters DeadLetterRepository
+	cfg         PubSubConfig
 	buffer      chan Notification
 	logger      logger.Logger
+	newID       func() string
+	now         func() time.Time
+	random      func() float64
+
+	// spillMu orders pushes while spill queue isn't empty, spilled wakes consumer waiting for the buffer
+	spillMu sync.Mutex
+	spilled chan struct{}
+
+	partitions []chan task
+	// dispatched is number of notifications taken from the buffer, but not pushed yet
+	dispatched atomic.Int64
+}
+
+// NewPubSub creates new instance of PubSub, zero values of cfg are replaced with defaults.
+// Notifications which weren't pushed after all attempts are written to deadLetters, nil deadLetters drops them.
+func NewPubSub(notificator RepositoryNotificator, deadLetters DeadLetterRepository, cfg PubSubConfig, l logger.Logger) *PubSub {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.AttemptTimeout <= 0 {
+		cfg.AttemptTimeout = 30 * time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 10 * time.Second
+	}
+	cfg.Jitter = min(max(cfg.Jitter, 0), 1)
+	if cfg.Backpressure == "" || cfg.Backpressure == BackpressureSpill && cfg.Spill == nil {
+		cfg.Backpressure = BackpressureBlock
+	}
+	p := &PubSub{
+		notificator: notificator,
+		deadLetters: deadLetters,
+		cfg:         cfg,
+		buffer:      make(chan Notification, cfg.BufferSize),
+		logger:      l,
+		newID:       uuid.NewString,
+		now:         time.Now,
+		random:      rand.Float64,
+	}
+	if cfg.Backpressure == BackpressureSpill {
+		p.spilled = make(chan struct{}, 1)
+	}
+	p.partitions = make([]chan task, cfg.Workers)
+	for i := range p.partitions {
+		p.partitions[i] = make(chan task, partitionBufferSize)
+	}
+	return p
+}
+
+// Push adds notification to the buffer, full buffer is handled according to the backpressure policy.
+// ErrBufferFull is returned if notification isn't accepted.
+func (p *PubSub) Push(ctx context.Context, data Notification) error {
+	data = stamp(withContextMetadata(ctx, data), p.newID, p.now)
+	if p.cfg.Queue != nil {
+		return p.enqueue(data)
+	}
+	if p.cfg.Backpressure == BackpressureSpill {
+		return p.pushOrSpill(data)
+	}
+	select {
+	case p.buffer <- data:
+		p.observe(OutcomeAccepted)
+		return nil
+	default:
+	}
+	switch p.cfg.Backpressure {
+	case BackpressureFail:
+		p.observe(OutcomeRejected)
+		return ErrBufferFull
+	case BackpressureDropNewest:
+		p.observe(OutcomeDroppedNewest)
+		return nil
+	case BackpressureDropOldest:
+		p.pushDroppingOldest(data)
+		return nil
+	}
+	return p.pushBlocking(ctx, data)
+}
+
+// pushBlocking waits for room in the buffer.
+func (p *PubSub) pushBlocking(ctx context.Context, data Notification) error {
+	if p.cfg.BlockTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.BlockTimeout)
+		defer cancel()
+	}
+	select {
+	case p.buffer <- data:
+		p.observe(OutcomeBlocked)
+		return nil
+	case <-ctx.Done():
+		p.observe(OutcomeRejected)
+		return fmt.Errorf("%w: %w", ErrBufferFull, ctx.Err())
+	}
+}
+
+// pushDroppingOldest discards the oldest notifications until there is room, consumer may take them concurrently.
+func (p *PubSub) pushDroppingOldest(data Notification) {
+	dropped := false
+	for {
+		select {
+		case p.buffer <- data:
+			if dropped {
+				p.observe(OutcomeDroppedOldest)
+			} else {
+				p.observe(OutcomeAccepted)
+			}
+			return
+		default:
+		}
+		select {
+		case <-p.buffer:
+			dropped = true
+		default:
+		}
+	}
 }
 
-func NewPubSub(notificator RepositoryNotificator, bufferSize int, l logger.Logger) *PubSub {
-	return &PubSub{notificator: notificator, buffer: make(chan Notification, bufferSize), logger: l}
+// pushOrSpill adds notification to the buffer if it has room and nothing is spilled, otherwise it goes to the spill queue
+// after the spilled ones, so notifications keep their order.
+func (p *PubSub) pushOrSpill(data Notification) error {
+	p.spillMu.Lock()
+	defer p.spillMu.Unlock()
+	if p.cfg.Spill.Len() == 0 {
+		select {
+		case p.buffer <- data:
+			p.observe(OutcomeAccepted)
+			return nil
+		default:
+		}
+	}
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		p.observe(OutcomeRejected)
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err = p.cfg.Spill.Push(byteData); err != nil {
+		p.observe(OutcomeRejected)
+		return fmt.Errorf("%w: spill: %w", ErrBufferFull, err)
+	}
+	p.observe(OutcomeSpilled)
+	select {
+	case p.spilled <- struct{}{}:
+	default:
+	}
+	return nil
 }
 
-func (p *PubSub) Push(_ context.Context, data Notification) error {
-	p.buffer <- data
+// enqueue appends notification to the durable queue.
+func (p *PubSub) enqueue(data Notification) error {
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		p.observe(OutcomeRejected)
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err = p.cfg.Queue.Append(byteData); err != nil {
+		p.observe(OutcomeRejected)
+		return fmt.Errorf("%w: queue: %w", ErrBufferFull, err)
+	}
+	p.observe(OutcomeAccepted)
 	return nil
 }
 
+func (p *PubSub) observe(outcome string) {
+	if p.cfg.Observer != nil {
+		p.cfg.Observer.ObserveNotificationBuffer(outcome)
+	}
+}
+
+// Len returns number of notifications waiting in the buffer and partitions of workers,
+// with durable queue it is number of notifications which aren't pushed.
+func (p *PubSub) Len() int {
+	if p.cfg.Queue != nil {
+		return p.cfg.Queue.Len()
+	}
+	return len(p.buffer) + int(p.dispatched.Load())
+}
+
+// Cap returns buffer capacity.
+func (p *PubSub) Cap() int {
+	return cap(p.buffer)
+}
+
+// Start consumes notifications until ctx is done. Notifications are partitioned between workers by key, so notifications
+// of one user are pushed in order while different users are pushed in parallel. Spilled notifications are taken once
+// the buffer is drained, they are newer than buffered ones since nothing goes to the buffer while spill queue isn't empty.
+// When ctx is done, the buffer and every partition are drained in order for DrainTimeout, notifications left after it
+// go to dead letters in order, spilled and queued ones are left for the next start. Start returns when they are drained.
 func (p *PubSub) Start(ctx context.Context) {
+	// buffered notifications are dispatched and pushed with drain context, so they keep going after ctx is done
+	// until DrainTimeout passes
+	drainCtx, cancelDrain := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelDrain()
+	stopDrainTimer := context.AfterFunc(ctx, func() {
+		time.AfterFunc(p.cfg.DrainTimeout, cancelDrain)
+	})
+	defer stopDrainTimer()
+	var wg sync.WaitGroup
+	for _, partition := range p.partitions {
+		wg.Add(1)
+		go func(partition <-chan task) {
+			defer wg.Done()
+			for t := range partition {
+				p.work(drainCtx, t)
+			}
+		}(partition)
+	}
+	defer func() {
+		if p.cfg.Queue == nil {
+			p.drainBuffer(drainCtx)
+		}
+		for _, partition := range p.partitions {
+			close(partition)
+		}
+		wg.Wait()
+	}()
+
+	if p.cfg.Queue != nil {
+		p.dequeue(ctx)
+		return
+	}
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case res := <-p.buffer:
-			byteData, err := json.Marshal(res)
-			if err != nil {
-				p.logger.Error(fmt.Errorf("marshal: %w", err))
-				break
-			}
-			err = p.notificator.Push(ctx, byteData)
-			if err != nil {
-				p.logger.Error(fmt.Errorf("error push operationType=%v message=%v: %w", res.Type, res.Data, err))
+			p.dispatchBuffered(drainCtx, res)
+			continue
+		default:
+		}
+		if p.unspill(ctx) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case res := <-p.buffer:
+			p.dispatchBuffered(drainCtx, res)
+		case <-p.spilled:
+		}
+	}
+}
+
+// task is notification dispatched to the worker, ack is called after push if it is set.
+type task struct {
+	res Notification
+	ack func()
+}
+
+// dispatch sends notification to the worker of its partition, it waits while the partition is full.
+// It returns false if ctx is done before notification is dispatched.
+func (p *PubSub) dispatch(ctx context.Context, res Notification, ack func()) bool {
+	p.dispatched.Add(1)
+	select {
+	case p.partitions[partition(res.Key, len(p.partitions))] <- task{res: res, ack: ack}:
+		return true
+	case <-ctx.Done():
+		p.dispatched.Add(-1)
+		return false
+	}
+}
+
+// dispatchBuffered dispatches notification taken from the buffer. If ctx is done first(DrainTimeout passed),
+// it is pushed at once, so it goes to dead letters like the dispatched ones instead of being lost.
+func (p *PubSub) dispatchBuffered(ctx context.Context, res Notification) {
+	if !p.dispatch(ctx, res, nil) {
+		p.push(ctx, res)
+	}
+}
+
+// drainBuffer dispatches notifications left in the buffer after the consumer is stopped.
+func (p *PubSub) drainBuffer(ctx context.Context) {
+	for {
+		select {
+		case res := <-p.buffer:
+			p.dispatchBuffered(ctx, res)
+		default:
+			return
+		}
+	}
+}
+
+func (p *PubSub) work(ctx context.Context, t task) {
+	defer p.dispatched.Add(-1)
+	if t.ack != nil && ctx.Err() != nil {
+		// it stays in the queue for the next start
+		return
+	}
+	p.push(ctx, t.res)
+	if t.ack != nil {
+		t.ack()
+	}
+}
+
+// partition returns index of the partition of key.
+func partition(key string, partitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// unspill takes the oldest spilled notification and dispatches it, it is removed from the spill queue after push.
+// It returns false if there is nothing to dispatch or ctx is done.
+func (p *PubSub) unspill(ctx context.Context) bool {
+	if p.cfg.Spill == nil {
+		return false
+	}
+	data, err := p.cfg.Spill.Peek()
+	if err != nil {
+		p.spillLogger(ctx).Error(fmt.Errorf("peek: %w", err))
+		return false
+	}
+	if data == nil {
+		return false
+	}
+	// it is taken before dispatch, since the worker may acknowledge it at once
+	offset, err := p.cfg.Spill.Take()
+	if err != nil {
+		p.spillLogger(ctx).Error(fmt.Errorf("take: %w", err))
+		return false
+	}
+	// raw data keeps payload as it was pushed
+	res := Notification{Data: &json.RawMessage{}}
+	if err = json.Unmarshal(data, &res); err != nil {
+		p.spillLogger(ctx).Error(fmt.Errorf("unmarshal: %w", err))
+		p.unspilled(ctx, offset)
+		return true
+	}
+	return p.dispatch(ctx, res, func() { p.unspilled(ctx, offset) })
+}
+
+// unspilled removes pushed notification from the spill queue.
+func (p *PubSub) unspilled(ctx context.Context, offset uint64) {
+	if err := p.cfg.Spill.Ack(offset); err != nil {
+		p.spillLogger(ctx).Error(fmt.Errorf("ack: %w", err))
+	}
+}
+
+// dequeue dispatches notifications of the durable queue until ctx is done, each one is acknowledged after push.
+func (p *PubSub) dequeue(ctx context.Context) {
+	for {
+		offset, data, err := p.cfg.Queue.Read(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			p.queueLogger(ctx).Error(fmt.Errorf("read: %w", err))
+			if !sleep(ctx, time.Second) {
+				return
 			}
+			continue
+		}
+		res := Notification{Data: &json.RawMessage{}}
+		if err = json.Unmarshal(data, &res); err != nil {
+			p.queueLogger(ctx).Error(fmt.Errorf("unmarshal: %w", err))
+			p.ack(ctx, offset)
+			continue
+		}
+		if !p.dispatch(ctx, res, func() { p.ack(ctx, offset) }) {
+			return
 		}
 	}
 }
 
+func (p *PubSub) ack(ctx context.Context, offset uint64) {
+	if err := p.cfg.Queue.Ack(offset); err != nil {
+		p.queueLogger(ctx).Error(fmt.Errorf("ack: %w", err))
+	}
+}
+
+func (p *PubSub) queueLogger(ctx context.Context) logger.Logger {
+	return p.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "notification dequeue"})
+}
+
+func (p *PubSub) spillLogger(ctx context.Context) logger.Logger {
+	return p.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "notification unspill"})
+}
+
+// push sends notification to the sink in its own trace, linked to the trace of the request which pushed it.
+func (p *PubSub) push(ctx context.Context, res Notification) {
+	opts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String(FieldOperationType, string(res.Type)),
+			attribute.String(logger.FieldUserID, res.Key),
+		),
+	}
+	if link, ok := res.link(ctx); ok {
+		opts = append(opts, trace.WithLinks(link))
+	}
+	resCtx, span := tracing.Tracer(tracerName).Start(res.context(ctx), "PubSub.push", opts...)
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	// notification spilled or queued before the envelope was introduced has no ID
+	byteData, err := json.Marshal(stamp(res, p.newID, p.now))
+	if err != nil {
+		err = fmt.Errorf("marshal: %w", err)
+		p.pushLogger(resCtx, res).Error(err)
+		return
+	}
+	var attempts []entity.DeliveryAttempt
+	for attempt := 1; ; attempt++ {
+		if err = p.attempt(resCtx, byteData); err == nil {
+			return
+		}
+		err = fmt.Errorf("push: %w", err)
+		attempts = append(attempts, entity.DeliveryAttempt{At: p.now(), Error: err.Error()})
+		if attempt >= p.cfg.MaxAttempts {
+			break
+		}
+		delay := p.delay(attempt)
+		p.pushLogger(resCtx, res).WithFields(logger.Fields{"attempt": attempt, "retry_in": delay.String()}).Warn(err)
+		if !sleep(ctx, delay) {
+			// consumer is stopped, notification isn't lost since it goes to dead letters
+			break
+		}
+	}
+	p.pushLogger(resCtx, res).WithFields(logger.Fields{fieldData: res.Data, "attempts": len(attempts)}).Error(err)
+	p.deadLetter(resCtx, res, byteData, attempts)
+}
+
+// attempt pushes data limited by attempt timeout.
+func (p *PubSub) attempt(ctx context.Context, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.AttemptTimeout)
+	defer cancel()
+	return p.notificator.Push(ctx, data)
+}
+
+// delay returns randomized delay after attempt.
+func (p *PubSub) delay(attempt int) time.Duration {
+	d := exponentialBackoff(p.cfg.Backoff, p.cfg.MaxBackoff, attempt)
+	if p.cfg.Jitter == 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + p.cfg.Jitter*(2*p.random()-1)))
+}
+
+// deadLetter stores notification with its failed attempts. It is stored even if consumer is stopped.
+func (p *PubSub) deadLetter(ctx context.Context, res Notification, data []byte, attempts []entity.DeliveryAttempt) {
+	if p.deadLetters == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.cfg.AttemptTimeout)
+	defer cancel()
+	letter, err := p.deadLetters.Add(ctx, entity.DeadLetter{
+		Key:       res.Key,
+		Type:      string(res.Type),
+		Payload:   data,
+		Attempts:  attempts,
+		CreatedAt: p.now(),
+	})
+	if err != nil {
+		p.pushLogger(ctx, res).Error(fmt.Errorf("dead letter: %w", err))
+		return
+	}
+	p.pushLogger(ctx, res).WithFields(logger.Fields{fieldDeadLetterID: letter.ID}).Warn("notification is moved to dead letters")
+}
+
+// sleep waits for d, it returns false if ctx is done earlier.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (p *PubSub) pushLogger(ctx context.Context, res Notification) logger.Logger {
+	return p.logger.WithContext(ctx).WithFields(logger.Fields{
+		logger.FieldOperation: "notification push",
+		FieldOperationType:    res.Type,
+		logger.FieldUserID:    res.Key,
+	})
+}
+
 // Stop stops consumer, gives it time to send all notifications.
 func (p *PubSub) Stop(cancelFunc context.CancelFunc, recheckTime, closeTimeout time.Duration) {
 	ticker := time.NewTicker(recheckTime)
@@ -52,7 +555,7 @@ func (p *PubSub) Stop(cancelFunc context.CancelFunc, recheckTime, closeTimeout t
 	p.logger.Info("notification consumer stop started")
 mainLoop:
 	for {
-		queueLength := len(p.buffer)
+		queueLength := p.Len()
 		if queueLength == 0 {
 			break
 		}
@@ -67,5 +570,11 @@ mainLoop:
 
 	cancelFunc()
 
+	if p.cfg.Spill != nil && p.cfg.Spill.Len() > 0 {
+		p.logger.Info(fmt.Sprintf("%v spilled notifications are left for the next start", p.cfg.Spill.Len()))
+	}
+	if p.cfg.Queue != nil && p.cfg.Queue.Len() > 0 {
+		p.logger.Info(fmt.Sprintf("%v queued notifications are left for the next start", p.cfg.Queue.Len()))
+	}
 	p.logger.Info("notification consumer stop finished")
 }
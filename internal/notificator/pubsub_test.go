@@ -1,15 +1,31 @@
 package notificator
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"test_task/internal/config"
+	"test_task/internal/entity"
 	"test_task/internal/logger"
+	"test_task/internal/message"
+	"test_task/internal/requestid"
 
 	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestPubSub_Push(t *testing.T) {
@@ -33,7 +49,7 @@ func TestPubSub_Push(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockNotificator := NewMockRepositoryNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			ps := NewPubSub(mockNotificator, 10, mockLogger)
+			ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, mockLogger)
 
 			err := ps.Push(context.Background(), tc.data)
 			if tc.expectErr {
@@ -86,8 +102,10 @@ func TestPubSub_Start(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockNotificator := NewMockRepositoryNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			ps := NewPubSub(mockNotificator, 10, mockLogger)
+			ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, mockLogger)
 
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
 			tc.setupMocks(mockNotificator, mockLogger)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -141,7 +159,7 @@ func TestPubSub_Stop(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			mockNotificator := NewMockRepositoryNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			ps := NewPubSub(mockNotificator, 10, mockLogger)
+			ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, mockLogger)
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
@@ -154,3 +172,764 @@ func TestPubSub_Stop(t *testing.T) {
 		})
 	}
 }
+
+func TestPubSub_RequestID(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, mockLogger)
+	ps.newID = func() string { return "1" }
+	ps.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	pushed := make(chan []byte, 1)
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []byte) error {
+		ao.Equal("abc", requestid.FromContext(ctx))
+		ao.Equal(message.Attributes{Key: "42", Type: string(Insert)}, message.FromContext(ctx))
+		pushed <- data
+		return errors.New("push error")
+	})
+	logged := make(chan struct{})
+	mockLogger.EXPECT().WithContext(gomock.Any()).DoAndReturn(func(ctx context.Context) logger.Logger {
+		ao.Equal("abc", requestid.FromContext(ctx))
+		return mockLogger
+	})
+	mockLogger.EXPECT().WithFields(logger.Fields{
+		logger.FieldOperation: "notification push",
+		FieldOperationType:    Insert,
+		logger.FieldUserID:    "42",
+	}).Return(mockLogger)
+	mockLogger.EXPECT().WithFields(logger.Fields{"data": "test data", "attempts": 1}).Return(mockLogger)
+	mockLogger.EXPECT().Error(gomock.Any()).Do(func(args ...interface{}) {
+		close(logged)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ps.Start(ctx)
+
+	data := Notification{Type: Insert, Key: "42", Data: "test data"}
+	ao.NoError(ps.Push(requestid.NewContext(ctx, "abc"), data))
+	ao.Nil(data.Metadata)
+
+	select {
+	case res := <-pushed:
+		ao.JSONEq(`{"specversion":"1.0","id":"1","source":"/test_task/users","type":"test_task.user.created",
+			"subject":"42","time":"2024-05-01T12:00:00Z","datacontenttype":"application/json",
+			"dataschema":"urn:test_task:schema:user-event:v1","data":"test data","requestid":"abc"}`, string(res))
+	case <-time.After(time.Second):
+		ao.Fail("notification was not pushed")
+	}
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		ao.Fail("push error was not logged")
+	}
+}
+
+func TestPubSub_PushErrorDoesNotLeakUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	buf := &syncBuffer{}
+	l := logrus.New()
+	l.SetOutput(buf)
+	redactor, err := logger.NewRedactor(config.Redaction{})
+	require.NoError(t, err)
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, logger.NewRedacting(logger.NewLogrus(l), redactor))
+
+	logged := make(chan struct{})
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(errors.New("push error"))
+	buf.onWrite = func() { close(logged) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ps.Start(ctx)
+
+	user := entity.User{ID: "42", Nickname: "jdoe", Password: "S3cret-pass!", Email: "jdoe@example.com"}
+	require.NoError(t, ps.Push(ctx, Notification{Type: Insert, Key: user.ID, Data: user}))
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("push error was not logged")
+	}
+	out := buf.String()
+	assert.Contains(t, out, "push error")
+	assert.Contains(t, out, "jdoe")
+	assert.NotContains(t, out, user.Password)
+	assert.NotContains(t, out, user.Email)
+}
+
+type syncBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	onWrite func()
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, err := b.buf.Write(p)
+	if b.onWrite != nil {
+		b.onWrite()
+		b.onWrite = nil
+	}
+	return n, err
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPubSub_TraceLink(t *testing.T) {
+	ao := assert.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, logger.NewMockLogger(ctrl))
+
+	requestCtx, requestSpan := otel.Tracer("test").Start(context.Background(), "request")
+	requestSpan.End()
+
+	pushed := make(chan []byte, 1)
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []byte) error {
+		sc := trace.SpanContextFromContext(ctx)
+		ao.True(sc.IsValid())
+		ao.NotEqual(requestSpan.SpanContext().TraceID(), sc.TraceID())
+		pushed <- data
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ps.Start(ctx)
+	ao.NoError(ps.Push(requestCtx, Notification{Type: Insert, Key: "42", Data: "test data"}))
+
+	select {
+	case data := <-pushed:
+		var n Notification
+		ao.NoError(json.Unmarshal(data, &n))
+		ao.Equal(fmt.Sprintf("00-%s-%s-01", requestSpan.SpanContext().TraceID(), requestSpan.SpanContext().SpanID()),
+			n.Metadata["traceparent"])
+	case <-time.After(time.Second):
+		ao.Fail("notification was not pushed")
+	}
+
+	ao.Eventually(func() bool { return len(recorder.Ended()) == 2 }, time.Second, 10*time.Millisecond)
+	pushSpan := recorder.Ended()[1]
+	ao.Equal("PubSub.push", pushSpan.Name())
+	ao.Equal(trace.SpanKindProducer, pushSpan.SpanKind())
+	ao.False(pushSpan.Parent().IsValid())
+	ao.Len(pushSpan.Links(), 1)
+	ao.Equal(requestSpan.SpanContext().SpanID(), pushSpan.Links()[0].SpanContext.SpanID())
+}
+
+func TestPubSub_delay(t *testing.T) {
+	ao := assert.New(t)
+	ps := NewPubSub(nil, nil, PubSubConfig{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}, nil)
+	for attempt, expected := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+	} {
+		ao.Equal(expected, ps.delay(attempt), "attempt=%d", attempt)
+	}
+
+	ps = NewPubSub(nil, nil, PubSubConfig{Backoff: 100 * time.Millisecond, Jitter: 0.2}, nil)
+	for random, expected := range map[float64]time.Duration{
+		0:   80 * time.Millisecond,
+		0.5: 100 * time.Millisecond,
+		1:   120 * time.Millisecond,
+	} {
+		ps.random = func() float64 { return random }
+		ao.Equal(expected, ps.delay(1), "random=%v", random)
+	}
+}
+
+func TestPubSub_Retry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pushErr := errors.New("broker is unavailable")
+	tests := []struct {
+		name        string
+		pushErrs    []error
+		addErr      error
+		expectedLog string
+	}{
+		{
+			name:     "succeeds after retries",
+			pushErrs: []error{pushErr, pushErr, nil},
+		},
+		{
+			name:        "exhausted attempts go to dead letters",
+			pushErrs:    []error{pushErr, pushErr, pushErr},
+			expectedLog: "notification is moved to dead letters",
+		},
+		{
+			name:        "dead letter error is logged",
+			pushErrs:    []error{pushErr, pushErr, pushErr},
+			addErr:      errors.New("disk is full"),
+			expectedLog: "dead letter: disk is full",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockNotificator := NewMockRepositoryNotificator(ctrl)
+			mockDeadLetters := NewMockDeadLetterRepository(ctrl)
+			buf := &syncBuffer{}
+			l := logrus.New()
+			l.SetOutput(buf)
+			ps := NewPubSub(mockNotificator, mockDeadLetters, PubSubConfig{
+				BufferSize:     10,
+				MaxAttempts:    3,
+				Backoff:        time.Millisecond,
+				AttemptTimeout: time.Second,
+			}, logger.NewLogrus(l))
+			ps.newID = func() string { return "1" }
+			ps.now = func() time.Time { return now }
+
+			done := make(chan struct{})
+			for i, err := range tt.pushErrs {
+				call := mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ []byte) error {
+					_, ok := ctx.Deadline()
+					ao.True(ok)
+					return err
+				})
+				if i == len(tt.pushErrs)-1 && err == nil {
+					call.Do(func(context.Context, []byte) { close(done) })
+				}
+			}
+			if tt.expectedLog != "" {
+				mockDeadLetters.EXPECT().Add(gomock.Any(), entity.DeadLetter{
+					Key:  "42",
+					Type: string(Update),
+					Payload: []byte(`{"data":"test data","datacontenttype":"application/json",` +
+						`"dataschema":"urn:test_task:schema:user-event:v1","id":"1","source":"/test_task/users",` +
+						`"specversion":"1.0","subject":"42","time":"2024-05-01T12:00:00Z","type":"test_task.user.updated"}`),
+					Attempts: []entity.DeliveryAttempt{
+						{At: now, Error: "push: broker is unavailable"},
+						{At: now, Error: "push: broker is unavailable"},
+						{At: now, Error: "push: broker is unavailable"},
+					},
+					CreatedAt: now,
+				}).Return(entity.DeadLetter{ID: "1"}, tt.addErr)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go ps.Start(ctx)
+			ao.NoError(ps.Push(ctx, Notification{Type: Update, Key: "42", Data: "test data"}))
+
+			if tt.expectedLog == "" {
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					ao.Fail("notification was not pushed")
+				}
+				return
+			}
+			ao.Eventually(func() bool {
+				return strings.Contains(buf.String(), tt.expectedLog)
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestPubSub_StopDuringRetry(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockDeadLetters := NewMockDeadLetterRepository(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	// drain timeout passes during retry
+	ps := NewPubSub(mockNotificator, mockDeadLetters, PubSubConfig{BufferSize: 10, MaxAttempts: 5, Backoff: time.Hour, DrainTimeout: 10 * time.Millisecond}, mockLogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	retrying := make(chan struct{})
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(errors.New("push error"))
+	mockLogger.EXPECT().Warn(gomock.Any()).Do(func(args ...interface{}) { close(retrying) })
+	mockLogger.EXPECT().Error(gomock.Any())
+	stored := make(chan struct{})
+	mockDeadLetters.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, letter entity.DeadLetter) (entity.DeadLetter, error) {
+		// consumer is stopped, but the letter is written
+		ao.NoError(ctx.Err())
+		ao.Len(letter.Attempts, 1)
+		close(stored)
+		return letter, nil
+	})
+	mockLogger.EXPECT().Warn("notification is moved to dead letters")
+
+	go ps.Start(ctx)
+	ao.NoError(ps.Push(ctx, Notification{Type: Insert, Key: "42"}))
+	<-retrying
+	cancel()
+	select {
+	case <-stored:
+	case <-time.After(time.Second):
+		ao.Fail("notification was not dead-lettered")
+	}
+}
+
+func TestPubSub_Backpressure(t *testing.T) {
+	// stamped notifications are buffered as they are
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	first := Notification{ID: "1", Time: at, Type: Insert, Key: "1"}
+	second := Notification{ID: "2", Time: at, Type: Insert, Key: "2"}
+	tests := []struct {
+		name             string
+		cfg              PubSubConfig
+		drain            bool
+		expectedOutcome  string
+		expectedErr      error
+		expectedBuffered Notification
+	}{
+		{
+			name:             "block until timeout",
+			cfg:              PubSubConfig{Backpressure: BackpressureBlock, BlockTimeout: 10 * time.Millisecond},
+			expectedOutcome:  OutcomeRejected,
+			expectedErr:      context.DeadlineExceeded,
+			expectedBuffered: first,
+		},
+		{
+			name:             "block until room",
+			cfg:              PubSubConfig{Backpressure: BackpressureBlock, BlockTimeout: time.Second},
+			drain:            true,
+			expectedOutcome:  OutcomeBlocked,
+			expectedBuffered: second,
+		},
+		{
+			name:             "fail",
+			cfg:              PubSubConfig{Backpressure: BackpressureFail},
+			expectedOutcome:  OutcomeRejected,
+			expectedErr:      ErrBufferFull,
+			expectedBuffered: first,
+		},
+		{
+			name:             "drop newest",
+			cfg:              PubSubConfig{Backpressure: BackpressureDropNewest},
+			expectedOutcome:  OutcomeDroppedNewest,
+			expectedBuffered: first,
+		},
+		{
+			name:             "drop oldest",
+			cfg:              PubSubConfig{Backpressure: BackpressureDropOldest},
+			expectedOutcome:  OutcomeDroppedOldest,
+			expectedBuffered: second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			mockObserver := NewMockBufferObserver(ctrl)
+			gomock.InOrder(
+				mockObserver.EXPECT().ObserveNotificationBuffer(OutcomeAccepted),
+				mockObserver.EXPECT().ObserveNotificationBuffer(tt.expectedOutcome),
+			)
+			tt.cfg.BufferSize = 1
+			tt.cfg.Observer = mockObserver
+			ps := NewPubSub(NewMockRepositoryNotificator(ctrl), nil, tt.cfg, logger.NewMockLogger(ctrl))
+
+			ao.NoError(ps.Push(context.Background(), first))
+			if tt.drain {
+				time.AfterFunc(10*time.Millisecond, func() { <-ps.buffer })
+			}
+			err := ps.Push(context.Background(), second)
+			if tt.expectedErr != nil {
+				ao.ErrorIs(err, ErrBufferFull)
+				ao.ErrorIs(err, tt.expectedErr)
+			} else {
+				ao.NoError(err)
+			}
+			ao.Equal(1, ps.Len())
+			ao.Equal(tt.expectedBuffered, <-ps.buffer)
+		})
+	}
+}
+
+// memorySpill is SpillQueue in memory.
+type memorySpill struct {
+	mu    sync.Mutex
+	lines [][]byte
+	taken int
+	err   error
+}
+
+func (s *memorySpill) Push(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.lines = append(s.lines, data)
+	return nil
+}
+
+func (s *memorySpill) Peek() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.lines) == 0 {
+		return nil, nil
+	}
+	return s.lines[0], nil
+}
+
+func (s *memorySpill) Take() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = s.lines[1:]
+	s.taken++
+	return 0, nil
+}
+
+func (s *memorySpill) Ack(uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taken--
+	return nil
+}
+
+func (s *memorySpill) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.lines)
+}
+
+func TestPubSub_Spill(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockObserver := NewMockBufferObserver(ctrl)
+	spill := &memorySpill{}
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{
+		BufferSize:   1,
+		Backpressure: BackpressureSpill,
+		Spill:        spill,
+		Observer:     mockObserver,
+	}, logger.NewMockLogger(ctrl))
+	ps.newID = func() string { return "1" }
+	ps.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	gomock.InOrder(
+		mockObserver.EXPECT().ObserveNotificationBuffer(OutcomeAccepted),
+		mockObserver.EXPECT().ObserveNotificationBuffer(OutcomeSpilled).Times(2),
+		mockObserver.EXPECT().ObserveNotificationBuffer(OutcomeRejected),
+	)
+	ctx := requestid.NewContext(context.Background(), "abc")
+	for i := 1; i <= 3; i++ {
+		ao.NoError(ps.Push(ctx, Notification{Type: Update, Key: fmt.Sprint(i), Data: struct{ B, A int }{i, i}}))
+	}
+	spill.err = errors.New("disk is full")
+	ao.ErrorIs(ps.Push(ctx, Notification{Type: Update, Key: "4"}), ErrBufferFull)
+	ao.Equal(2, spill.Len())
+
+	var pushed []string
+	done := make(chan struct{})
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []byte) error {
+		ao.Equal("abc", requestid.FromContext(ctx))
+		pushed = append(pushed, string(data))
+		if len(pushed) > 1 {
+			// spilled notification is removed after push
+			spill.mu.Lock()
+			ao.Positive(spill.taken)
+			spill.mu.Unlock()
+		}
+		if len(pushed) == 3 {
+			close(done)
+		}
+		return nil
+	}).Times(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ps.Start(ctx)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		ao.Fail("spilled notifications were not pushed")
+	}
+	// spilled notifications keep order and payload
+	event := `{"data":{"B":%[1]d,"A":%[1]d},"datacontenttype":"application/json","dataschema":"urn:test_task:schema:user-event:v1",` +
+		`"id":"1","requestid":"abc","source":"/test_task/users","specversion":"1.0","subject":"%[1]d",` +
+		`"time":"2024-05-01T12:00:00Z","type":"test_task.user.updated"}`
+	ao.Equal([]string{fmt.Sprintf(event, 1), fmt.Sprintf(event, 2), fmt.Sprintf(event, 3)}, pushed)
+	ao.Equal(0, spill.Len())
+	ao.Eventually(func() bool {
+		spill.mu.Lock()
+		defer spill.mu.Unlock()
+		return spill.taken == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestPubSub_Workers(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any()).AnyTimes()
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 100, Workers: 2}, mockLogger)
+	// keys of different partitions
+	keys := []string{"1", "2"}
+	require.NotEqual(t, partition(keys[0], 2), partition(keys[1], 2))
+
+	var mu sync.Mutex
+	pushed := map[string][]string{}
+	// the first push of every key waits for the other one, so they are pushed in parallel
+	started := make(chan struct{}, len(keys))
+	all := make(chan struct{})
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, data []byte) error {
+		var n Notification
+		require.NoError(t, json.Unmarshal(data, &n))
+		mu.Lock()
+		pushed[n.Key] = append(pushed[n.Key], n.Data.(string))
+		first := len(pushed[n.Key]) == 1
+		mu.Unlock()
+		if first {
+			started <- struct{}{}
+			if len(started) == len(keys) {
+				close(all)
+			}
+			select {
+			case <-all:
+			case <-time.After(time.Second):
+				ao.Fail("notifications were not pushed in parallel")
+			}
+		}
+		return nil
+	}).Times(10)
+
+	for i := 0; i < 5; i++ {
+		for _, key := range keys {
+			ao.NoError(ps.Push(context.Background(), Notification{Type: Update, Key: key, Data: fmt.Sprint(i)}))
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ps.Start(ctx)
+		close(stopped)
+	}()
+	ps.Stop(cancel, time.Millisecond, time.Second)
+	<-stopped
+
+	ao.Equal(0, ps.Len())
+	for _, key := range keys {
+		ao.Equal([]string{"0", "1", "2", "3", "4"}, pushed[key])
+	}
+}
+
+func TestPubSub_StopDrainsPartitions(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any()).AnyTimes()
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10, MaxAttempts: 3, DrainTimeout: time.Second}, mockLogger)
+
+	// the first push outlives stop timeout, the following ones of the partition are pushed in order after stop
+	release := make(chan struct{})
+	var pushed []string
+	record := func(ctx context.Context, data []byte) error {
+		var res Notification
+		ao.NoError(json.Unmarshal(data, &res))
+		pushed = append(pushed, res.Data.(string))
+		return ctx.Err()
+	}
+	gomock.InOrder(
+		mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []byte) error {
+			<-release
+			return record(ctx, data)
+		}),
+		mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(record).Times(2),
+	)
+
+	for i := 0; i < 3; i++ {
+		ao.NoError(ps.Push(context.Background(), Notification{Type: Update, Key: "42", Data: fmt.Sprint(i)}))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ps.Start(ctx)
+		close(stopped)
+	}()
+	ao.Eventually(func() bool { return len(ps.buffer) == 0 }, time.Second, time.Millisecond)
+	ao.Equal(3, ps.Len())
+	ps.Stop(cancel, time.Millisecond, 10*time.Millisecond)
+	close(release)
+	<-stopped
+
+	ao.Equal(0, ps.Len())
+	ao.Equal([]string{"0", "1", "2"}, pushed)
+}
+
+func TestPubSub_StopDrainsBuffer(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 10}, nil)
+
+	var pushed []string
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []byte) error {
+		var res Notification
+		ao.NoError(json.Unmarshal(data, &res))
+		pushed = append(pushed, res.Data.(string))
+		return ctx.Err()
+	}).Times(3)
+
+	for i := 0; i < 3; i++ {
+		ao.NoError(ps.Push(context.Background(), Notification{Type: Update, Key: "42", Data: fmt.Sprint(i)}))
+	}
+	// consumer is stopped before it takes the buffer, the buffer is drained anyway
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ps.Start(ctx)
+
+	ao.Equal(0, ps.Len())
+	ao.Equal([]string{"0", "1", "2"}, pushed)
+}
+
+func TestPubSub_StopDrainTimeout(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockDeadLetters := NewMockDeadLetterRepository(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().Info(gomock.Any()).AnyTimes()
+	ps := NewPubSub(mockNotificator, mockDeadLetters, PubSubConfig{BufferSize: 10, MaxAttempts: 3, DrainTimeout: 10 * time.Millisecond}, mockLogger)
+	ps.newID = func() string { return "1" }
+	ps.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	// the first push outlives drain timeout, the following ones of the partition are dead-lettered in order
+	gomock.InOrder(
+		mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ []byte) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ []byte) error {
+			return ctx.Err()
+		}).Times(2),
+	)
+	mockLogger.EXPECT().Error(gomock.Any()).Times(3)
+	mockLogger.EXPECT().Warn("notification is moved to dead letters").Times(3)
+	// retry warnings
+	mockLogger.EXPECT().Warn(gomock.Any()).AnyTimes()
+	var stored []string
+	mockDeadLetters.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, letter entity.DeadLetter) (entity.DeadLetter, error) {
+		ao.Len(letter.Attempts, 1)
+		stored = append(stored, string(letter.Payload))
+		return letter, nil
+	}).Times(3)
+
+	for i := 0; i < 3; i++ {
+		ao.NoError(ps.Push(context.Background(), Notification{Type: Update, Key: "42", Data: fmt.Sprint(i)}))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		ps.Start(ctx)
+		close(stopped)
+	}()
+	ao.Eventually(func() bool { return len(ps.buffer) == 0 }, time.Second, time.Millisecond)
+	ps.Stop(cancel, time.Millisecond, 10*time.Millisecond)
+	<-stopped
+
+	ao.Equal(0, ps.Len())
+	event := `{"data":"%d","datacontenttype":"application/json","dataschema":"urn:test_task:schema:user-event:v1",` +
+		`"id":"1","source":"/test_task/users","specversion":"1.0","subject":"42",` +
+		`"time":"2024-05-01T12:00:00Z","type":"test_task.user.updated"}`
+	ao.Equal([]string{fmt.Sprintf(event, 0), fmt.Sprintf(event, 1), fmt.Sprintf(event, 2)}, stored)
+}
+
+func TestPubSub_Queue(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	mockNotificator := NewMockRepositoryNotificator(ctrl)
+	mockQueue := NewMockDurableQueue(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	ps := NewPubSub(mockNotificator, nil, PubSubConfig{BufferSize: 1, Queue: mockQueue}, mockLogger)
+	ps.newID = func() string { return "1" }
+	ps.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	// the queue replaces the buffer
+	data := `{"data":{"B":1,"A":2},"datacontenttype":"application/json","dataschema":"urn:test_task:schema:user-event:v1",` +
+		`"id":"1","source":"/test_task/users","specversion":"1.0","subject":"42",` +
+		`"time":"2024-05-01T12:00:00Z","type":"test_task.user.created"}`
+	mockQueue.EXPECT().Append([]byte(data)).Return(nil)
+	mockQueue.EXPECT().Append(gomock.Any()).Return(errors.New("queue is full"))
+	ao.NoError(ps.Push(context.Background(), Notification{Type: Insert, Key: "42", Data: struct{ B, A int }{1, 2}}))
+	ao.ErrorIs(ps.Push(context.Background(), Notification{Type: Insert, Key: "42"}), ErrBufferFull)
+	mockQueue.EXPECT().Len().Return(3)
+	ao.Equal(3, ps.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	acked := make(chan struct{})
+	gomock.InOrder(
+		mockQueue.EXPECT().Read(gomock.Any()).Return(uint64(7), []byte(data), nil),
+		mockQueue.EXPECT().Read(gomock.Any()).Return(uint64(8), []byte(`{`), nil),
+		mockQueue.EXPECT().Read(gomock.Any()).DoAndReturn(func(ctx context.Context) (uint64, []byte, error) {
+			<-ctx.Done()
+			return 0, nil, ctx.Err()
+		}),
+	)
+	// corrupt notification is acknowledged without push
+	mockLogger.EXPECT().Error(gomock.Any())
+	mockQueue.EXPECT().Ack(uint64(8)).Return(nil)
+	mockNotificator.EXPECT().Push(gomock.Any(), []byte(data)).Return(nil)
+	mockQueue.EXPECT().Ack(uint64(7)).DoAndReturn(func(uint64) error {
+		close(acked)
+		return nil
+	})
+	stopped := make(chan struct{})
+	go func() {
+		ps.Start(ctx)
+		close(stopped)
+	}()
+	select {
+	case <-acked:
+	case <-time.After(time.Second):
+		ao.Fail("queued notification was not acknowledged")
+	}
+	cancel()
+	<-stopped
+}
+
+func TestPubSub_QueueStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockQueue := NewMockDurableQueue(ctrl)
+	ps := NewPubSub(NewMockRepositoryNotificator(ctrl), nil, PubSubConfig{Queue: mockQueue}, logger.NewMockLogger(ctrl))
+
+	// notifications dispatched after stop are left in the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ps.work(ctx, task{res: Notification{Type: Insert}, ack: func() { assert.Fail(t, "notification was acknowledged") }})
+}
+
+func TestPubSub_DispatchStop(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	ps := NewPubSub(NewMockRepositoryNotificator(ctrl), nil, PubSubConfig{}, logger.NewMockLogger(ctrl))
+
+	// partition is full and its worker isn't started
+	for i := 0; i < partitionBufferSize; i++ {
+		ao.True(ps.dispatch(context.Background(), Notification{Type: Insert}, nil))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	ao.False(ps.dispatch(ctx, Notification{Type: Insert}, func() { ao.Fail("notification was acknowledged") }))
+	ao.Equal(partitionBufferSize, ps.Len())
+}
@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"test_task/internal/notificator"
+)
+
+// Instrument wraps push of the named sink, e.g. to count results.
+type Instrument func(name string, n notificator.RepositoryNotificator) notificator.RepositoryNotificator
+
+// Fanout pushes every notification to all sinks.
+type Fanout struct {
+	sinks   []Named
+	pushers []notificator.RepositoryNotificator
+}
+
+// NewFanout creates fan-out to sinks, pushes of every sink are wrapped by instrument if it isn't nil.
+// Fan-out without sinks discards notifications.
+func NewFanout(sinks []Named, instrument Instrument) *Fanout {
+	f := &Fanout{sinks: sinks, pushers: make([]notificator.RepositoryNotificator, len(sinks))}
+	for i, s := range sinks {
+		f.pushers[i] = s.Sink
+		if instrument != nil {
+			f.pushers[i] = instrument(s.Name, s.Sink)
+		}
+	}
+	return f
+}
+
+// Push pushes data to all sinks concurrently and waits for them. Error contains failures of all failed sinks,
+// so retry of the notification duplicates it in succeeded ones.
+func (f *Fanout) Push(ctx context.Context, data []byte) error {
+	if len(f.pushers) == 1 {
+		return f.push(ctx, 0, data)
+	}
+	errs := make([]error, len(f.pushers))
+	var wg sync.WaitGroup
+	for i := range f.pushers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f.push(ctx, i, data)
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// PushExcept pushes data concurrently to sinks not named in delivered and returns names of sinks which accepted it,
+// so retry of the notification doesn't duplicate it in them. Error contains failures of all failed sinks.
+func (f *Fanout) PushExcept(ctx context.Context, data []byte, delivered []string) ([]string, error) {
+	pending := make([]int, 0, len(f.sinks))
+	for i, s := range f.sinks {
+		if !slices.Contains(delivered, s.Name) {
+			pending = append(pending, i)
+		}
+	}
+	errs := make([]error, len(pending))
+	var wg sync.WaitGroup
+	for j, i := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[j] = f.push(ctx, i, data)
+		}()
+	}
+	wg.Wait()
+	var accepted []string
+	for j, i := range pending {
+		if errs[j] == nil {
+			accepted = append(accepted, f.sinks[i].Name)
+		}
+	}
+	return accepted, errors.Join(errs...)
+}
+
+func (f *Fanout) push(ctx context.Context, i int, data []byte) error {
+	if err := f.pushers[i].Push(ctx, data); err != nil {
+		return fmt.Errorf("sink %s: %w", f.sinks[i].Name, err)
+	}
+	return nil
+}
+
+// Sinks returns sinks of the fan-out.
+func (f *Fanout) Sinks() []Named {
+	return f.sinks
+}
+
+// Start runs background work of sinks until ctx is done, then closes sinks. Close error is returned for every failed sink.
+func (f *Fanout) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, s := range f.sinks {
+		if starter, ok := s.Sink.(Starter); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				starter.Start(ctx)
+			}()
+		}
+	}
+	<-ctx.Done()
+	wg.Wait()
+
+	var errs []error
+	for _, s := range f.sinks {
+		if closer, ok := s.Sink.(Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close sink %s: %w", s.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,153 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/notificator"
+)
+
+type startingSink struct {
+	*MockSink
+	*MockStarter
+}
+
+func TestFanout_Push(t *testing.T) {
+	data := []byte(`{"Type":"Insert"}`)
+	tests := []struct {
+		name        string
+		sinks       int
+		errs        []error
+		expectedErr string
+	}{
+		{
+			name: "no sinks",
+		},
+		{
+			name:        "single sink",
+			sinks:       1,
+			errs:        []error{errors.New("timeout")},
+			expectedErr: "sink s0: timeout",
+		},
+		{
+			name:  "all sinks succeed",
+			sinks: 3,
+			errs:  []error{nil, nil, nil},
+		},
+		{
+			name:        "failed sinks are joined",
+			sinks:       3,
+			errs:        []error{errors.New("timeout"), nil, errors.New("closed")},
+			expectedErr: "sink s0: timeout\nsink s2: closed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			ctrl := gomock.NewController(t)
+			var sinks []Named
+			for i := 0; i < tt.sinks; i++ {
+				s := NewMockSink(ctrl)
+				s.EXPECT().Push(gomock.Any(), data).Return(tt.errs[i])
+				sinks = append(sinks, Named{Name: fmt.Sprintf("s%d", i), Sink: s})
+			}
+
+			err := NewFanout(sinks, nil).Push(context.Background(), data)
+			if tt.expectedErr != "" {
+				ao.EqualError(err, tt.expectedErr)
+			} else {
+				ao.NoError(err)
+			}
+		})
+	}
+}
+
+func TestFanout_PushExcept(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	data := []byte(`{"Type":"Insert"}`)
+	s0, s1, s2 := NewMockSink(ctrl), NewMockSink(ctrl), NewMockSink(ctrl)
+	// s0 has received the notification before
+	s1.EXPECT().Push(gomock.Any(), data).Return(nil)
+	s2.EXPECT().Push(gomock.Any(), data).Return(errors.New("timeout"))
+	f := NewFanout([]Named{{Name: "s0", Sink: s0}, {Name: "s1", Sink: s1}, {Name: "s2", Sink: s2}}, nil)
+
+	accepted, err := f.PushExcept(context.Background(), data, []string{"s0", "removed"})
+	ao.EqualError(err, "sink s2: timeout")
+	ao.Equal([]string{"s1"}, accepted)
+
+	accepted, err = f.PushExcept(context.Background(), data, []string{"s0", "s1", "s2"})
+	ao.NoError(err)
+	ao.Empty(accepted)
+}
+
+type countingNotificator struct {
+	name   string
+	next   notificator.RepositoryNotificator
+	mu     *sync.Mutex
+	pushes map[string]int
+}
+
+func (n *countingNotificator) Push(ctx context.Context, data []byte) error {
+	n.mu.Lock()
+	n.pushes[n.name]++
+	n.mu.Unlock()
+	return n.next.Push(ctx, data)
+}
+
+func TestFanout_PushInstrumented(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+	kafka, file := NewMockSink(ctrl), NewMockSink(ctrl)
+	kafka.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	file.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	var mu sync.Mutex
+	pushes := map[string]int{}
+	f := NewFanout([]Named{{Name: "kafka", Sink: kafka}, {Name: "file", Sink: file}},
+		func(name string, n notificator.RepositoryNotificator) notificator.RepositoryNotificator {
+			return &countingNotificator{name: name, next: n, mu: &mu, pushes: pushes}
+		})
+	ao.NoError(f.Push(context.Background(), []byte(`{}`)))
+	ao.NoError(f.Push(context.Background(), []byte(`{}`)))
+	ao.Equal(map[string]int{"kafka": 2, "file": 2}, pushes)
+	ao.Equal([]Named{{Name: "kafka", Sink: kafka}, {Name: "file", Sink: file}}, f.Sinks())
+}
+
+func TestFanout_Start(t *testing.T) {
+	ao := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	started := make(chan struct{})
+	starter := NewMockStarter(ctrl)
+	starter.EXPECT().Start(gomock.Any()).Do(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	})
+	file := NewMockCloser(ctrl)
+	http := NewMockCloser(ctrl)
+	closed := file.EXPECT().Close().Return(errors.New("sync error"))
+	http.EXPECT().Close().Return(nil).After(closed)
+
+	f := NewFanout([]Named{
+		{Name: "rabbitmq", Sink: startingSink{MockSink: NewMockSink(ctrl), MockStarter: starter}},
+		{Name: "file", Sink: closingSink{MockSink: NewMockSink(ctrl), MockCloser: file}},
+		{Name: "http", Sink: closingSink{MockSink: NewMockSink(ctrl), MockCloser: http}},
+		{Name: "stdout", Sink: NewMockSink(ctrl)},
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- f.Start(ctx)
+	}()
+	<-started
+	cancel()
+	ao.EqualError(<-stopped, "close sink file: sync error")
+}
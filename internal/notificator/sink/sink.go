@@ -0,0 +1,107 @@
+// Package sink implements registry of notification sink types and fan-out of notifications to configured sinks.
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"test_task/internal/config"
+	"test_task/internal/notificator"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=sink.go --destination=sink_mock.go --package=sink
+
+// Sink publishes notifications and reports its health.
+type Sink interface {
+	notificator.RepositoryNotificator
+	Health(ctx context.Context) error
+}
+
+// Starter is implemented by sinks which maintain connection in background, Start blocks until ctx is done.
+type Starter interface {
+	Start(ctx context.Context)
+}
+
+// Closer is implemented by sinks which release resources on shutdown.
+type Closer interface {
+	Close() error
+}
+
+// Factory creates sink of one type from its configuration.
+type Factory func(cfg config.Sink) (Sink, error)
+
+// Named is a sink with its configured name.
+type Named struct {
+	Name string
+	Sink Sink
+}
+
+// Registry holds factories by sink type.
+type Registry struct {
+	factories map[string]Factory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}}
+}
+
+// Register adds factory of sink type. It panics if the type is already registered.
+func (r *Registry) Register(sinkType string, f Factory) {
+	if _, ok := r.factories[sinkType]; ok {
+		panic(fmt.Sprintf("sink type %q is already registered", sinkType))
+	}
+	r.factories[sinkType] = f
+}
+
+// Types returns registered sink types in alphabetical order.
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Build creates configured sinks. Sinks built before a failure are closed.
+func (r *Registry) Build(cfgs []config.Sink) ([]Named, error) {
+	sinks := make([]Named, 0, len(cfgs))
+	names := map[string]bool{}
+	for _, cfg := range cfgs {
+		if cfg.Name == "" {
+			cfg.Name = cfg.Type
+		}
+		s, err := r.build(cfg, names)
+		if err != nil {
+			for _, built := range sinks {
+				if c, ok := built.Sink.(Closer); ok {
+					_ = c.Close()
+				}
+			}
+			return nil, err
+		}
+		names[cfg.Name] = true
+		sinks = append(sinks, Named{Name: cfg.Name, Sink: s})
+	}
+	return sinks, nil
+}
+
+func (r *Registry) build(cfg config.Sink, names map[string]bool) (Sink, error) {
+	if cfg.Type == "" {
+		return nil, errors.New("sink type is required")
+	}
+	if names[cfg.Name] {
+		return nil, fmt.Errorf("duplicate sink name %q", cfg.Name)
+	}
+	f, ok := r.factories[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("sink %s: unknown type %q", cfg.Name, cfg.Type)
+	}
+	s, err := f(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: %w", cfg.Name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+)
+
+type closingSink struct {
+	*MockSink
+	*MockCloser
+}
+
+func TestRegistry_Register(t *testing.T) {
+	ao := assert.New(t)
+	r := NewRegistry()
+	r.Register("kafka", func(config.Sink) (Sink, error) { return nil, nil })
+	r.Register("file", func(config.Sink) (Sink, error) { return nil, nil })
+	ao.Equal([]string{"file", "kafka"}, r.Types())
+	ao.PanicsWithValue(`sink type "kafka" is already registered`, func() {
+		r.Register("kafka", func(config.Sink) (Sink, error) { return nil, nil })
+	})
+}
+
+func TestRegistry_Build(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	fileSink := NewMockSink(ctrl)
+	closer := NewMockCloser(ctrl)
+	httpSink := closingSink{MockSink: NewMockSink(ctrl), MockCloser: closer}
+
+	tests := []struct {
+		name          string
+		cfgs          []config.Sink
+		expectedNames []string
+		expectedErr   string
+		mock          func()
+	}{
+		{
+			name: "no sinks",
+		},
+		{
+			name:          "name defaults to type",
+			cfgs:          []config.Sink{{Type: "file"}, {Name: "audit", Type: "http"}},
+			expectedNames: []string{"file", "audit"},
+		},
+		{
+			name:        "no type",
+			cfgs:        []config.Sink{{Name: "audit"}},
+			expectedErr: "sink type is required",
+		},
+		{
+			name:        "unknown type",
+			cfgs:        []config.Sink{{Name: "audit", Type: "smtp"}},
+			expectedErr: `sink audit: unknown type "smtp"`,
+		},
+		{
+			name:        "duplicate name",
+			cfgs:        []config.Sink{{Type: "http"}, {Name: "http", Type: "file"}},
+			expectedErr: `duplicate sink name "http"`,
+			mock: func() {
+				closer.EXPECT().Close().Return(nil)
+			},
+		},
+		{
+			name:        "factory error",
+			cfgs:        []config.Sink{{Type: "http"}, {Name: "kafka", Type: "failing"}},
+			expectedErr: "sink kafka: no brokers",
+			mock: func() {
+				closer.EXPECT().Close().Return(errors.New("close error"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			if tt.mock != nil {
+				tt.mock()
+			}
+			r := NewRegistry()
+			r.Register("file", func(config.Sink) (Sink, error) { return fileSink, nil })
+			r.Register("http", func(config.Sink) (Sink, error) { return httpSink, nil })
+			r.Register("failing", func(config.Sink) (Sink, error) { return nil, errors.New("no brokers") })
+
+			sinks, err := r.Build(tt.cfgs)
+			if tt.expectedErr != "" {
+				ao.EqualError(err, tt.expectedErr)
+				ao.Nil(sinks)
+				return
+			}
+			ao.NoError(err)
+			var names []string
+			for _, s := range sinks {
+				names = append(names, s.Name)
+			}
+			ao.Equal(tt.expectedNames, names)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rangePrefixLength length of SHA-1 hash prefix, used as a range file name.
+const rangePrefixLength = 5
+
+// RangeList is an offline breached passwords list in k-anonymity range format.
+// Directory contains one file per upper-case 5 hex chars SHA-1 prefix, each line of the file is "SUFFIX:COUNT".
+// Only one range file is read per lookup, whole list is never loaded to memory.
+type RangeList struct {
+	dir string
+}
+
+// NewRangeList creates new instance of RangeList.
+func NewRangeList(dir string) *RangeList {
+	return &RangeList{dir: dir}
+}
+
+// Contains looks password SHA-1 suffix up in the range file of its prefix.
+func (r *RangeList) Contains(ctx context.Context, password string) (bool, error) {
+	prefix, suffix := HashRange(password)
+	f, err := os.Open(filepath.Join(r.dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("open range file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		line, _, _ := strings.Cut(scanner.Text(), ":")
+		if strings.EqualFold(strings.TrimSpace(line), suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// HashRange returns upper-case SHA-1(format of public breached passwords lists) hex of password split to range prefix and suffix.
+func HashRange(password string) (prefix, suffix string) {
+	sum := sha1.Sum([]byte(password))
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return h[:rangePrefixLength], h[rangePrefixLength:]
+}
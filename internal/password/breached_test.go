@@ -0,0 +1,34 @@
+package password
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRange(t *testing.T) {
+	prefix, suffix := HashRange("password")
+	assert.Equal(t, "5BAA6", prefix)
+	assert.Equal(t, "1E4C9B93F3F0682250B6CF8331B7EE68FD8", suffix)
+}
+
+func TestRangeList_Contains(t *testing.T) {
+	ao := assert.New(t)
+	dir := t.TempDir()
+	ao.NoError(os.WriteFile(filepath.Join(dir, "5BAA6"),
+		[]byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"), 0o600))
+
+	l := NewRangeList(dir)
+	tests := map[string]bool{
+		"password":                 true,
+		"not-a-breached-password1": false,
+	}
+	for pass, expected := range tests {
+		res, err := l.Contains(context.Background(), pass)
+		ao.NoError(err)
+		ao.Equal(expected, res, pass)
+	}
+}
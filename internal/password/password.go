@@ -0,0 +1,136 @@
+// Package password implements password policy and breached passwords lookup.
+package password
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"test_task/internal/config"
+	"test_task/internal/entity"
+)
+
+// minIdentityLength shorter nickname or email local part is not checked, otherwise too many passwords are rejected.
+const minIdentityLength = 3
+
+// PolicyError lists all violated password policy rules.
+type PolicyError struct {
+	Reasons []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password policy violation: " + strings.Join(e.Reasons, ", ")
+}
+
+// BreachedList checks whether password is known from data breaches.
+type BreachedList interface {
+	Contains(ctx context.Context, password string) (bool, error)
+}
+
+// Validator checks password against config.PasswordPolicy.
+type Validator struct {
+	cfg      config.PasswordPolicy
+	breached BreachedList
+}
+
+// NewValidator creates new instance of Validator. Breached passwords check is skipped if breached is nil.
+func NewValidator(cfg config.PasswordPolicy, breached BreachedList) *Validator {
+	return &Validator{cfg: cfg, breached: breached}
+}
+
+// HistorySize number of previous passwords, which can't be reused.
+func (v *Validator) HistorySize() int {
+	return v.cfg.HistorySize
+}
+
+// Validate checks user password. recentHashes are Hash of previous user passwords, the newest first.
+// Returns *PolicyError if any rule is violated.
+func (v *Validator) Validate(ctx context.Context, user entity.User, recentHashes []string) error {
+	var reasons []string
+	pass := user.Password
+
+	if utf8.RuneCountInString(pass) < v.cfg.MinLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters long", v.cfg.MinLength))
+	}
+	if v.cfg.RequireUpper && !strings.ContainsFunc(pass, unicode.IsUpper) {
+		reasons = append(reasons, "must contain an upper-case letter")
+	}
+	if v.cfg.RequireLower && !strings.ContainsFunc(pass, unicode.IsLower) {
+		reasons = append(reasons, "must contain a lower-case letter")
+	}
+	if v.cfg.RequireDigit && !strings.ContainsFunc(pass, unicode.IsDigit) {
+		reasons = append(reasons, "must contain a digit")
+	}
+	if v.cfg.RequireSymbol && !strings.ContainsFunc(pass, isSymbol) {
+		reasons = append(reasons, "must contain a symbol")
+	}
+	if containsFold(pass, user.Nickname) {
+		reasons = append(reasons, "must not contain the nickname")
+	}
+	emailLocal, _, _ := strings.Cut(user.Email, "@")
+	if containsFold(pass, user.Email) || containsFold(pass, emailLocal) {
+		reasons = append(reasons, "must not contain the email")
+	}
+
+	for i, h := range recentHashes {
+		if i >= v.cfg.HistorySize {
+			break
+		}
+		if MatchHash(h, pass) {
+			reasons = append(reasons, fmt.Sprintf("must not reuse one of the last %d passwords", v.cfg.HistorySize))
+			break
+		}
+	}
+
+	if v.breached != nil {
+		breached, err := v.breached.Contains(ctx, pass)
+		if err != nil {
+			return fmt.Errorf("breached passwords lookup: %w", err)
+		}
+		if breached {
+			reasons = append(reasons, "has appeared in a data breach, choose a different one")
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &PolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// Hash returns salted bcrypt hash of password for password history, history doesn't store passwords as is.
+// Password is pre-hashed with SHA-256, because bcrypt uses only the first 72 bytes.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(preHash(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("bcrypt: %w", err)
+	}
+	return string(hash), nil
+}
+
+// MatchHash reports whether hash is Hash of password.
+func MatchHash(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), preHash(password)) == nil
+}
+
+func preHash(password string) []byte {
+	sum := sha256.Sum256([]byte(password))
+	return []byte(hex.EncodeToString(sum[:]))
+}
+
+func containsFold(s, substr string) bool {
+	if utf8.RuneCountInString(substr) < minIdentityLength {
+		return false
+	}
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func isSymbol(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
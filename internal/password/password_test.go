@@ -0,0 +1,129 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+	"test_task/internal/entity"
+)
+
+type breachedListStub struct {
+	breached map[string]bool
+	err      error
+}
+
+func (b breachedListStub) Contains(_ context.Context, password string) (bool, error) {
+	return b.breached[password], b.err
+}
+
+func TestHash(t *testing.T) {
+	ao := assert.New(t)
+	long := strings.Repeat("Correct-horse1", 10)
+	h1, err := Hash(long)
+	ao.NoError(err)
+	h2, err := Hash(long)
+	ao.NoError(err)
+	// hash is salted
+	ao.NotEqual(h1, h2)
+	ao.NotContains(h1, long)
+	ao.True(MatchHash(h1, long))
+	ao.True(MatchHash(h2, long))
+	// bcrypt alone ignores bytes after the 72nd
+	ao.False(MatchHash(h1, long+"x"))
+	ao.False(MatchHash("5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8", "password"))
+}
+
+func TestValidator_Validate(t *testing.T) {
+	cfg := config.PasswordPolicy{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		HistorySize:   2,
+	}
+	type testCase struct {
+		name          string
+		user          entity.User
+		recentHashes  []string
+		breached      BreachedList
+		expectedError error
+	}
+
+	hash := func(password string) string {
+		h, err := Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	testCases := []testCase{
+		{
+			name: "valid password",
+			user: entity.User{Nickname: "jdoe", Email: "john@example.com", Password: "Correct-horse1"},
+		},
+		{
+			name: "all character rules",
+			user: entity.User{Password: "abc"},
+			expectedError: &PolicyError{Reasons: []string{
+				"must be at least 10 characters long",
+				"must contain an upper-case letter",
+				"must contain a digit",
+				"must contain a symbol",
+			}},
+		},
+		{
+			name: "contains nickname and email",
+			user: entity.User{Nickname: "jdoe", Email: "john@example.com", Password: "JDOE-john-2024"},
+			expectedError: &PolicyError{Reasons: []string{
+				"must not contain the nickname",
+				"must not contain the email",
+			}},
+		},
+		{
+			name: "short nickname is not checked",
+			user: entity.User{Nickname: "jd", Password: "Correct-horse1-jd"},
+		},
+		{
+			name:          "reused password",
+			user:          entity.User{Password: "Correct-horse1"},
+			recentHashes:  []string{hash("Other-horse1"), hash("Correct-horse1")},
+			expectedError: &PolicyError{Reasons: []string{"must not reuse one of the last 2 passwords"}},
+		},
+		{
+			name:         "password older than history size can be reused",
+			user:         entity.User{Password: "Correct-horse1"},
+			recentHashes: []string{hash("Other-horse1"), hash("Other-horse2"), hash("Correct-horse1")},
+		},
+		{
+			name:          "breached password",
+			user:          entity.User{Password: "Correct-horse1"},
+			breached:      breachedListStub{breached: map[string]bool{"Correct-horse1": true}},
+			expectedError: &PolicyError{Reasons: []string{"has appeared in a data breach, choose a different one"}},
+		},
+		{
+			name:          "breached list error",
+			user:          entity.User{Password: "Correct-horse1"},
+			breached:      breachedListStub{err: errors.New("io error")},
+			expectedError: errors.New("breached passwords lookup: io error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ao := assert.New(t)
+			v := NewValidator(cfg, tc.breached)
+			err := v.Validate(context.Background(), tc.user, tc.recentHashes)
+			if tc.expectedError != nil {
+				ao.EqualError(err, tc.expectedError.Error())
+			} else {
+				ao.NoError(err)
+			}
+		})
+	}
+}
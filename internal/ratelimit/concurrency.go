@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Priority of request for ConcurrencyLimiter, low priority requests are shed first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityHigh
+)
+
+// ConcurrencyConfig AIMD limiter parameters, zero or out of range values are replaced with defaults.
+type ConcurrencyConfig struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// LatencyThreshold slower request is treated as overload signal.
+	LatencyThreshold time.Duration
+	// Backoff multiplier of the limit on overload, (0;1).
+	Backoff float64
+	// LowPriorityShare part of the limit available for low priority requests, (0;1].
+	LowPriorityShare float64
+}
+
+// ConcurrencyLimiter limits number of in-flight requests.
+// Limit adapts with AIMD: it grows by one per limit of fast requests and is multiplied by Backoff on overload.
+type ConcurrencyLimiter struct {
+	mu       sync.Mutex
+	cfg      ConcurrencyConfig
+	limit    float64
+	inflight int
+}
+
+// NewConcurrencyLimiter creates new instance of ConcurrencyLimiter.
+// Limits are kept in order MinLimit <= InitialLimit <= MaxLimit.
+func NewConcurrencyLimiter(cfg ConcurrencyConfig) *ConcurrencyLimiter {
+	if cfg.MinLimit <= 0 {
+		cfg.MinLimit = 1
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 1000
+	}
+	cfg.MaxLimit = max(cfg.MaxLimit, cfg.MinLimit)
+	if cfg.InitialLimit <= 0 {
+		cfg.InitialLimit = 50
+	}
+	cfg.InitialLimit = min(max(cfg.InitialLimit, cfg.MinLimit), cfg.MaxLimit)
+	if cfg.LatencyThreshold <= 0 {
+		cfg.LatencyThreshold = time.Second
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = 0.9
+	}
+	if cfg.LowPriorityShare <= 0 || cfg.LowPriorityShare > 1 {
+		cfg.LowPriorityShare = 1
+	}
+	return &ConcurrencyLimiter{cfg: cfg, limit: float64(cfg.InitialLimit)}
+}
+
+// Acquire takes a slot. If ok, release must be called once the request is finished,
+// overloaded reports failure caused by overload(timeout, 5xx).
+func (c *ConcurrencyLimiter) Acquire(p Priority) (release func(latency time.Duration, overloaded bool), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limit := int(c.limit)
+	if p == PriorityLow {
+		limit = max(int(c.limit*c.cfg.LowPriorityShare), 1)
+	}
+	if c.inflight >= limit {
+		return nil, false
+	}
+	c.inflight++
+
+	var once sync.Once
+	return func(latency time.Duration, overloaded bool) {
+		once.Do(func() {
+			c.release(latency, overloaded)
+		})
+	}, true
+}
+
+// Limit current limit.
+func (c *ConcurrencyLimiter) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.limit)
+}
+
+// Inflight number of acquired slots.
+func (c *ConcurrencyLimiter) Inflight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inflight
+}
+
+func (c *ConcurrencyLimiter) release(latency time.Duration, overloaded bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inflight := c.inflight
+	c.inflight--
+	if overloaded || latency > c.cfg.LatencyThreshold {
+		c.limit = math.Max(float64(c.cfg.MinLimit), c.limit*c.cfg.Backoff)
+		return
+	}
+	// limit grows only if it is actually used, otherwise idle service would grow it infinitely.
+	if float64(inflight)*2 >= c.limit {
+		c.limit = math.Min(float64(c.cfg.MaxLimit), c.limit+1/c.limit)
+	}
+}
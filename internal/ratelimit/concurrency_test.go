@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter_Acquire(t *testing.T) {
+	ao := assert.New(t)
+	c := NewConcurrencyLimiter(ConcurrencyConfig{
+		InitialLimit:     4,
+		MinLimit:         1,
+		MaxLimit:         10,
+		LatencyThreshold: time.Second,
+		Backoff:          0.5,
+		LowPriorityShare: 0.5,
+	})
+
+	releaseLow, ok := c.Acquire(PriorityLow)
+	ao.True(ok)
+	_, ok = c.Acquire(PriorityLow)
+	ao.True(ok)
+	// low priority share is exhausted, high priority still has room.
+	_, ok = c.Acquire(PriorityLow)
+	ao.False(ok)
+	releaseHigh, ok := c.Acquire(PriorityHigh)
+	ao.True(ok)
+	_, ok = c.Acquire(PriorityHigh)
+	ao.True(ok)
+	_, ok = c.Acquire(PriorityHigh)
+	ao.False(ok)
+	ao.Equal(4, c.Inflight())
+
+	// fast request under load increases limit additively, repeated release is ignored.
+	releaseHigh(time.Millisecond, false)
+	releaseHigh(time.Millisecond, false)
+	ao.Equal(3, c.Inflight())
+	ao.Equal(4, c.Limit())
+	ao.InDelta(4.25, c.limit, 0.001)
+
+	// slow request decreases limit multiplicatively.
+	releaseLow(2*time.Second, false)
+	ao.Equal(2, c.Limit())
+	ao.Equal(2, c.Inflight())
+}
+
+func TestConcurrencyLimiter_bounds(t *testing.T) {
+	ao := assert.New(t)
+	c := NewConcurrencyLimiter(ConcurrencyConfig{
+		InitialLimit:     2,
+		MinLimit:         2,
+		MaxLimit:         3,
+		LatencyThreshold: time.Second,
+		Backoff:          0.5,
+		LowPriorityShare: 0.1,
+	})
+
+	release, ok := c.Acquire(PriorityHigh)
+	ao.True(ok)
+	release(0, true)
+	ao.Equal(2, c.Limit())
+
+	for i := 0; i < 100; i++ {
+		r1, _ := c.Acquire(PriorityHigh)
+		r2, _ := c.Acquire(PriorityHigh)
+		r1(0, false)
+		r2(0, false)
+	}
+	ao.Equal(3, c.Limit())
+
+	// low priority always has at least one slot.
+	release, ok = c.Acquire(PriorityLow)
+	ao.True(ok)
+	release(0, false)
+}
+
+func TestNewConcurrencyLimiter_defaults(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ConcurrencyConfig
+		want ConcurrencyConfig
+	}{
+		{
+			name: "zero config",
+			want: ConcurrencyConfig{
+				InitialLimit:     50,
+				MinLimit:         1,
+				MaxLimit:         1000,
+				LatencyThreshold: time.Second,
+				Backoff:          0.9,
+				LowPriorityShare: 1,
+			},
+		},
+		{
+			name: "out of range",
+			cfg: ConcurrencyConfig{
+				InitialLimit:     100,
+				MinLimit:         20,
+				MaxLimit:         10,
+				LatencyThreshold: -time.Second,
+				Backoff:          1.5,
+				LowPriorityShare: -0.5,
+			},
+			want: ConcurrencyConfig{
+				InitialLimit:     20,
+				MinLimit:         20,
+				MaxLimit:         20,
+				LatencyThreshold: time.Second,
+				Backoff:          0.9,
+				LowPriorityShare: 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ao := assert.New(t)
+			c := NewConcurrencyLimiter(tt.cfg)
+			ao.Equal(tt.want, c.cfg)
+			ao.Equal(tt.want.InitialLimit, c.Limit())
+		})
+	}
+}
+
+func TestConcurrencyLimiter_zeroConfig(t *testing.T) {
+	ao := assert.New(t)
+	c := NewConcurrencyLimiter(ConcurrencyConfig{})
+
+	// low priority requests may use the whole limit.
+	for i := 0; i < 50; i++ {
+		_, ok := c.Acquire(PriorityLow)
+		ao.True(ok)
+	}
+	_, ok := c.Acquire(PriorityHigh)
+	ao.False(ok)
+
+	// limit never falls below MinLimit on overload.
+	c = NewConcurrencyLimiter(ConcurrencyConfig{})
+	for i := 0; i < 100; i++ {
+		release, ok := c.Acquire(PriorityHigh)
+		ao.True(ok)
+		release(0, true)
+	}
+	ao.Equal(1, c.Limit())
+}
@@ -0,0 +1,101 @@
+// Package ratelimit implements token bucket rate limiting and adaptive concurrency limiting.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval how often idle buckets are removed from MemoryStore.
+const sweepInterval = time.Minute
+
+// Limit token bucket parameters: Rate tokens are added per second up to Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result of one token take.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter time till the next token, zero if Allowed.
+	RetryAfter time.Duration
+	// Reset time till the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps buckets state. MemoryStore is enough for one instance, shared store(Redis, etc.) is needed for limits across instances.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// refill time to fill empty bucket.
+	refill time.Duration
+}
+
+// MemoryStore in-memory Store.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates new instance of MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(limit.Burst),
+			last:   now,
+			refill: secondsToDuration(float64(limit.Burst) / limit.Rate),
+		}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	var res Result
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return res, nil
+}
+
+// sweep removes buckets, which are full again. Full bucket is the same as absent one.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for k, v := range m.buckets {
+		if now.Sub(v.last) > v.refill {
+			delete(m.buckets, k)
+		}
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	ao := assert.New(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := s.Take(context.Background(), "a", limit)
+		ao.NoError(err)
+		ao.True(res.Allowed)
+		ao.Equal(i, res.Remaining)
+	}
+
+	res, err := s.Take(context.Background(), "a", limit)
+	ao.NoError(err)
+	ao.Equal(Result{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond}, res)
+
+	// other key has its own bucket.
+	res, err = s.Take(context.Background(), "b", limit)
+	ao.NoError(err)
+	ao.True(res.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	res, err = s.Take(context.Background(), "a", limit)
+	ao.NoError(err)
+	ao.True(res.Allowed)
+	ao.Equal(0, res.Remaining)
+}
+
+func TestMemoryStore_sweep(t *testing.T) {
+	ao := assert.New(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	_, _ = s.Take(context.Background(), "fast", Limit{Rate: 10, Burst: 1})
+	_, _ = s.Take(context.Background(), "slow", Limit{Rate: 0.001, Burst: 1})
+	ao.Len(s.buckets, 2)
+
+	now = now.Add(2 * sweepInterval)
+	_, _ = s.Take(context.Background(), "other", Limit{Rate: 10, Burst: 1})
+	ao.Contains(s.buckets, "slow")
+	ao.NotContains(s.buckets, "fast")
+}
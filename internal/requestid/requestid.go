@@ -0,0 +1,49 @@
+// Package requestid implements request correlation ID propagation through context.Context.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Header HTTP header with request ID.
+	Header = "X-Request-ID"
+	// MetadataKey notification metadata key with request ID.
+	MetadataKey = "request_id"
+
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// NewContext returns copy of ctx with request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns request ID or empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New generates request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether incoming request ID can be accepted as is.
+// Only short printable ASCII IDs are accepted, since request ID ends up in logs.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
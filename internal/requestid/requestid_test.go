@@ -0,0 +1,30 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	ao := assert.New(t)
+	ao.Equal("", FromContext(context.Background()))
+	ao.Equal("abc", FromContext(NewContext(context.Background(), "abc")))
+}
+
+func TestValid(t *testing.T) {
+	tests := map[string]bool{
+		"3d6f0eb1-2b1e-4d0f-b1a0-52f2b9249e85": true,
+		"abc_123:xyz":                          true,
+		"":                                     false,
+		"with space":                           false,
+		"new\nline":                            false,
+		"юникод":                               false,
+		strings.Repeat("a", 129):               false,
+	}
+	for id, expected := range tests {
+		assert.Equal(t, expected, Valid(id), id)
+	}
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"test_task/internal/config"
+)
+
+// collector is in-process stand-in of OTLP/HTTP collector.
+type collector struct {
+	mu      sync.Mutex
+	spans   []*tracepb.Span
+	service string
+	headers http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err = proto.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	c.headers = r.Header.Clone()
+	for _, rs := range req.GetResourceSpans() {
+		for _, attr := range rs.GetResource().GetAttributes() {
+			if attr.GetKey() == "service.name" {
+				c.service = attr.GetValue().GetStringValue()
+			}
+		}
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+	c.mu.Unlock()
+
+	data, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(data)
+}
+
+func TestSetup(t *testing.T) {
+	ao := assert.New(t)
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	shutdown, err := Setup(context.Background(), config.Tracing{
+		Enabled:     true,
+		Endpoint:    strings.TrimPrefix(srv.URL, "http://"),
+		Insecure:    true,
+		Headers:     map[string]string{"X-Tenant": "test"},
+		Timeout:     time.Second,
+		ServiceName: "users",
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	ctx, parent := Tracer("test").Start(context.Background(), "parent")
+	_, child := Tracer("test").Start(ctx, "child")
+	End(child, io.EOF)
+	End(parent, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ao.Equal("users", c.service)
+	ao.Equal("test", c.headers.Get("X-Tenant"))
+	ao.Len(c.spans, 2)
+	names := map[string]*tracepb.Span{}
+	for _, s := range c.spans {
+		names[s.GetName()] = s
+	}
+	ao.Equal(names["parent"].GetSpanId(), names["child"].GetParentSpanId())
+	ao.Equal(tracepb.Status_STATUS_CODE_ERROR, names["child"].GetStatus().GetCode())
+	ao.Equal("EOF", names["child"].GetStatus().GetMessage())
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.Tracing{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
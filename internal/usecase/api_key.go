@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=api_key.go --destination=api_key_mock.go --package=usecase
+
+const (
+	// apiKeyMarker makes keys recognisable for secret scanners.
+	apiKeyMarker      = "tt"
+	apiKeyPrefixBytes = 4
+	apiKeySecretBytes = 24
+
+	fieldAPIKeyPrefix = "api_key_prefix"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+var knownScopes = map[string]struct{}{
+	entity.ScopeUsersRead:  {},
+	entity.ScopeUsersWrite: {},
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (entity.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// APIKey is responsible for service-to-service API keys.
+type APIKey struct {
+	repo             APIKeyRepository
+	lastUsedInterval time.Duration
+	logger           logger.Logger
+	now              func() time.Time
+}
+
+// NewAPIKey creates new instance of APIKey. Last used time is written not more often than lastUsedInterval.
+func NewAPIKey(repo APIKeyRepository, lastUsedInterval time.Duration, l logger.Logger) *APIKey {
+	return &APIKey{repo: repo, lastUsedInterval: lastUsedInterval, logger: l, now: time.Now}
+}
+
+// Issue creates new key. Plain key is returned only once and is never stored.
+func (a *APIKey) Issue(ctx context.Context, name string, scopes []string, expiresAt time.Time) (entity.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return entity.APIKey{}, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, v := range scopes {
+		if _, ok := knownScopes[v]; !ok {
+			return entity.APIKey{}, "", fmt.Errorf("%w: %s", ErrInvalidScope, v)
+		}
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	plainKey := strings.Join([]string{apiKeyMarker, prefix, secret}, "_")
+
+	key, err := a.repo.Create(ctx, entity.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(plainKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("repo create api key: %w", err)
+	}
+	a.logger.WithContext(ctx).WithFields(logger.Fields{
+		logger.FieldOperation: "api key issue",
+		fieldAPIKeyPrefix:     key.Prefix,
+		"name":                key.Name,
+		"scopes":              key.Scopes,
+	}).Info("api key issued")
+	return key, plainKey, nil
+}
+
+func (a *APIKey) List(ctx context.Context) ([]entity.APIKey, error) {
+	res, err := a.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("repo list api keys: %w", err)
+	}
+	return res, nil
+}
+
+func (a *APIKey) Revoke(ctx context.Context, id string) error {
+	if err := a.repo.Revoke(ctx, id, a.now()); err != nil {
+		return fmt.Errorf("repo revoke api key: %w", err)
+	}
+	a.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "api key revoke", "api_key_id": id}).
+		Info("api key revoked")
+	return nil
+}
+
+// Authenticate finds active key and records its usage.
+func (a *APIKey) Authenticate(ctx context.Context, plainKey string) (entity.APIKey, error) {
+	parts := strings.Split(plainKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyMarker {
+		return entity.APIKey{}, ErrInvalidAPIKey
+	}
+	key, err := a.repo.GetByPrefix(ctx, parts[1])
+	if errors.Is(err, datastore.ErrNotFound) {
+		return entity.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("repo get api key: %w", err)
+	}
+
+	now := a.now()
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(plainKey))) != 1 || !key.Active(now) {
+		return entity.APIKey{}, ErrInvalidAPIKey
+	}
+	if now.Sub(key.LastUsedAt) >= a.lastUsedInterval {
+		if err = a.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			a.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "api key authenticate", fieldAPIKeyPrefix: key.Prefix}).
+				Error(fmt.Errorf("touch last used: %w", err))
+		} else {
+			key.LastUsedAt = now
+		}
+	}
+	return key, nil
+}
+
+func hashAPIKey(plainKey string) string {
+	sum := sha256.Sum256([]byte(plainKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("random: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+)
+
+func TestAPIKey_Issue(t *testing.T) {
+	type testCase struct {
+		name          string
+		scopes        []string
+		mockSetup     func(repo *MockAPIKeyRepository, l *logger.MockLogger)
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			name:   "success",
+			scopes: []string{entity.ScopeUsersRead},
+			mockSetup: func(repo *MockAPIKeyRepository, l *logger.MockLogger) {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key entity.APIKey) (entity.APIKey, error) {
+					key.ID = "1"
+					return key, nil
+				})
+				l.EXPECT().Info("api key issued")
+			},
+		},
+		{
+			name:          "unknown scope",
+			scopes:        []string{"users:admin"},
+			mockSetup:     func(repo *MockAPIKeyRepository, l *logger.MockLogger) {},
+			expectedError: errors.New("invalid scope: users:admin"),
+		},
+		{
+			name:          "no scopes",
+			mockSetup:     func(repo *MockAPIKeyRepository, l *logger.MockLogger) {},
+			expectedError: errors.New("invalid scope: at least one scope is required"),
+		},
+		{
+			name:   "repo error",
+			scopes: []string{entity.ScopeUsersWrite},
+			mockSetup: func(repo *MockAPIKeyRepository, l *logger.MockLogger) {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.APIKey{}, errors.New("db error"))
+			},
+			expectedError: errors.New("repo create api key: db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ao := assert.New(t)
+			mockRepo := NewMockAPIKeyRepository(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tc.mockSetup(mockRepo, mockLogger)
+
+			a := NewAPIKey(mockRepo, time.Minute, mockLogger)
+			key, plainKey, err := a.Issue(context.Background(), "billing job", tc.scopes, time.Time{})
+			if tc.expectedError != nil {
+				ao.EqualError(err, tc.expectedError.Error())
+				return
+			}
+			ao.NoError(err)
+			ao.True(strings.HasPrefix(plainKey, "tt_"+key.Prefix+"_"))
+			ao.Equal(hashAPIKey(plainKey), key.Hash)
+			ao.NotContains(key.Hash, plainKey)
+		})
+	}
+}
+
+func TestAPIKey_Authenticate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	plainKey := "tt_0a1b2c3d_secret"
+	active := entity.APIKey{ID: "1", Prefix: "0a1b2c3d", Hash: hashAPIKey(plainKey), LastUsedAt: now.Add(-time.Hour)}
+
+	type testCase struct {
+		name          string
+		plainKey      string
+		mockSetup     func(repo *MockAPIKeyRepository)
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success records last used",
+			plainKey: plainKey,
+			mockSetup: func(repo *MockAPIKeyRepository) {
+				repo.EXPECT().GetByPrefix(gomock.Any(), "0a1b2c3d").Return(active, nil)
+				repo.EXPECT().TouchLastUsed(gomock.Any(), "1", now).Return(nil)
+			},
+		},
+		{
+			name:     "recently used key is not touched",
+			plainKey: plainKey,
+			mockSetup: func(repo *MockAPIKeyRepository) {
+				recent := active
+				recent.LastUsedAt = now.Add(-time.Second)
+				repo.EXPECT().GetByPrefix(gomock.Any(), "0a1b2c3d").Return(recent, nil)
+			},
+		},
+		{
+			name:          "malformed key",
+			plainKey:      "secret",
+			mockSetup:     func(repo *MockAPIKeyRepository) {},
+			expectedError: ErrInvalidAPIKey,
+		},
+		{
+			name:     "unknown prefix",
+			plainKey: plainKey,
+			mockSetup: func(repo *MockAPIKeyRepository) {
+				repo.EXPECT().GetByPrefix(gomock.Any(), "0a1b2c3d").Return(entity.APIKey{}, datastore.ErrNotFound)
+			},
+			expectedError: ErrInvalidAPIKey,
+		},
+		{
+			name:     "wrong secret",
+			plainKey: "tt_0a1b2c3d_wrong",
+			mockSetup: func(repo *MockAPIKeyRepository) {
+				repo.EXPECT().GetByPrefix(gomock.Any(), "0a1b2c3d").Return(active, nil)
+			},
+			expectedError: ErrInvalidAPIKey,
+		},
+		{
+			name:     "expired key",
+			plainKey: plainKey,
+			mockSetup: func(repo *MockAPIKeyRepository) {
+				expired := active
+				expired.ExpiresAt = now.Add(-time.Second)
+				repo.EXPECT().GetByPrefix(gomock.Any(), "0a1b2c3d").Return(expired, nil)
+			},
+			expectedError: ErrInvalidAPIKey,
+		},
+		{
+			name:     "revoked key",
+			plainKey: plainKey,
+			mockSetup: func(repo *MockAPIKeyRepository) {
+				revoked := active
+				revoked.RevokedAt = now.Add(-time.Second)
+				repo.EXPECT().GetByPrefix(gomock.Any(), "0a1b2c3d").Return(revoked, nil)
+			},
+			expectedError: ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ao := assert.New(t)
+			mockRepo := NewMockAPIKeyRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			a := NewAPIKey(mockRepo, time.Minute, logger.NewMockLogger(ctrl))
+			a.now = func() time.Time { return now }
+			key, err := a.Authenticate(context.Background(), tc.plainKey)
+			if tc.expectedError != nil {
+				ao.ErrorIs(err, tc.expectedError)
+			} else {
+				ao.NoError(err)
+				ao.Equal("1", key.ID)
+			}
+		})
+	}
+}
+
+func TestAPIKey_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ao := assert.New(t)
+	mockRepo := NewMockAPIKeyRepository(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.EXPECT().Revoke(gomock.Any(), "1", now).Return(nil)
+	mockLogger.EXPECT().Info("api key revoked")
+	mockRepo.EXPECT().Revoke(gomock.Any(), "2", now).Return(datastore.ErrNotFound)
+
+	a := NewAPIKey(mockRepo, time.Minute, mockLogger)
+	a.now = func() time.Time { return now }
+	ao.NoError(a.Revoke(context.Background(), "1"))
+	ao.ErrorIs(a.Revoke(context.Background(), "2"), datastore.ErrNotFound)
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"test_task/internal/config"
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+)
+
+//go:generate go run github.com/golang/mock/mockgen --source=auth.go --destination=auth_mock.go --package=usecase
+
+const (
+	// accountKeyPrefix counts failures by user ID, so every login of the account(email, nickname) shares the counter.
+	accountKeyPrefix = "account:"
+	// unknownLoginKeyPrefix counts failures of login which doesn't belong to any account.
+	unknownLoginKeyPrefix = "login:"
+	ipKeyPrefix           = "ip:"
+
+	fieldLockoutKey = "lockout_key"
+)
+
+// ErrInvalidCredentials is returned when login or password is wrong.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LockedError is returned when account or client IP is temporarily locked out.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("locked until %s", e.Until.Format(time.RFC3339))
+}
+
+type CredentialsRepository interface {
+	GetByLogin(ctx context.Context, login string) (entity.User, error)
+}
+
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, keys ...string) ([]entity.LoginAttempt, error)
+	RegisterFailure(ctx context.Context, key string, at time.Time, window time.Duration) (entity.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, keys ...string) error
+}
+
+// Auth is responsible for password login with brute-force protection.
+type Auth struct {
+	users    CredentialsRepository
+	attempts LoginAttemptRepository
+	cfg      config.Login
+	logger   logger.Logger
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewAuth(users CredentialsRepository, attempts LoginAttemptRepository, cfg config.Login, l logger.Logger) *Auth {
+	return &Auth{users: users, attempts: attempts, cfg: cfg, logger: l, now: time.Now, sleep: sleep}
+}
+
+// Login checks credentials.
+// Every failure slows down the following attempts, reaching a threshold locks the account or client IP out.
+func (a *Auth) Login(ctx context.Context, credentials entity.Credentials) (entity.User, error) {
+	accountKey, user, err := a.accountKey(ctx, credentials.Login)
+	if err != nil {
+		return entity.User{}, err
+	}
+	// failures of unknown login are counted too, so lockout doesn't reveal whether the account exists
+	matched := user.ID != "" && subtle.ConstantTimeCompare([]byte(user.Password), []byte(credentials.Password)) == 1
+	if err = a.attempt(ctx, loginKeys(accountKey, credentials.ClientIP), matched); err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// CheckPassword checks password of the known user with the same brute-force protection as Login.
+func (a *Auth) CheckPassword(ctx context.Context, user entity.User, password, clientIP string) error {
+	matched := subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) == 1
+	return a.attempt(ctx, loginKeys(accountKeyPrefix+user.ID, clientIP), matched)
+}
+
+// attempt applies brute-force protection to the password check: locked keys are rejected, the check is delayed
+// by recent failures, failure is counted for every key and success resets the account counter(the first key).
+func (a *Auth) attempt(ctx context.Context, keys []string, matched bool) error {
+	attempts, err := a.attempts.Get(ctx, keys...)
+	if err != nil {
+		return fmt.Errorf("repo get login attempts: %w", err)
+	}
+
+	now := a.now()
+	var failures int
+	for _, v := range attempts {
+		if v.LockedUntil.After(now) {
+			return &LockedError{Until: v.LockedUntil}
+		}
+		if v.LastFailureAt.After(now.Add(-a.cfg.FailureWindow)) && v.Failures > failures {
+			failures = v.Failures
+		}
+	}
+	if err = a.sleep(ctx, a.delay(failures)); err != nil {
+		return err
+	}
+
+	if !matched {
+		if err = a.registerFailure(ctx, keys); err != nil {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+	if err = a.attempts.Reset(ctx, keys[0]); err != nil {
+		a.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "login", fieldLockoutKey: keys[0]}).
+			Error(fmt.Errorf("reset login attempts: %w", err))
+	}
+	return nil
+}
+
+// accountKey resolves login(email or nickname) to the key of account counter, user is empty if login is unknown.
+func (a *Auth) accountKey(ctx context.Context, login string) (string, entity.User, error) {
+	user, err := a.users.GetByLogin(ctx, login)
+	if errors.Is(err, datastore.ErrNotFound) {
+		return unknownLoginKeyPrefix + login, entity.User{}, nil
+	}
+	if err != nil {
+		return "", entity.User{}, fmt.Errorf("repo get user by login: %w", err)
+	}
+	return accountKeyPrefix + user.ID, user, nil
+}
+
+// Unlock removes lockout and failure counters for the given login and/or client IP.
+func (a *Auth) Unlock(ctx context.Context, login, clientIP string) error {
+	var accountKey string
+	if login != "" {
+		var err error
+		if accountKey, _, err = a.accountKey(ctx, login); err != nil {
+			return err
+		}
+	}
+	keys := loginKeys(accountKey, clientIP)
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := a.attempts.Reset(ctx, keys...); err != nil {
+		return fmt.Errorf("repo reset login attempts: %w", err)
+	}
+	a.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: "unlock", fieldLockoutKey: keys}).
+		Info("unlocked by admin")
+	return nil
+}
+
+func (a *Auth) registerFailure(ctx context.Context, keys []string) error {
+	now := a.now()
+	for _, key := range keys {
+		attempt, err := a.attempts.RegisterFailure(ctx, key, now, a.cfg.FailureWindow)
+		if err != nil {
+			return fmt.Errorf("repo register login failure: %w", err)
+		}
+		if attempt.Failures < a.threshold(key) {
+			continue
+		}
+		until := now.Add(a.cfg.LockoutDuration)
+		if err = a.attempts.Lock(ctx, key, until); err != nil {
+			return fmt.Errorf("repo lock login: %w", err)
+		}
+		a.logger.WithContext(ctx).WithFields(logger.Fields{
+			logger.FieldOperation: "login",
+			fieldLockoutKey:       key,
+			"locked_until":        until.Format(time.RFC3339),
+			"failures":            attempt.Failures,
+		}).Warn("locked after failed attempts")
+	}
+	return nil
+}
+
+func (a *Auth) threshold(key string) int {
+	if strings.HasPrefix(key, ipKeyPrefix) {
+		return a.cfg.MaxIPFailures
+	}
+	return a.cfg.MaxAccountFailures
+}
+
+// delay grows twice with every failure and is capped by MaxDelay.
+func (a *Auth) delay(failures int) time.Duration {
+	if failures == 0 || a.cfg.DelayStep <= 0 {
+		return 0
+	}
+	d := a.cfg.DelayStep
+	for i := 1; i < failures && d < a.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if a.cfg.MaxDelay > 0 && d > a.cfg.MaxDelay {
+		d = a.cfg.MaxDelay
+	}
+	return d
+}
+
+// loginKeys returns keys of counters, account key goes first.
+func loginKeys(accountKey, clientIP string) []string {
+	keys := make([]string, 0, 2)
+	if accountKey != "" {
+		keys = append(keys, accountKey)
+	}
+	if clientIP != "" {
+		keys = append(keys, ipKeyPrefix+clientIP)
+	}
+	return keys
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
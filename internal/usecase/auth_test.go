@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"test_task/internal/config"
+	"test_task/internal/datastore"
+	"test_task/internal/entity"
+	"test_task/internal/logger"
+)
+
+func TestAuth_Login(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.Login{
+		MaxAccountFailures: 3,
+		MaxIPFailures:      10,
+		FailureWindow:      time.Minute,
+		DelayStep:          100 * time.Millisecond,
+		MaxDelay:           time.Second,
+		LockoutDuration:    15 * time.Minute,
+	}
+	credentials := entity.Credentials{Login: "jdoe", Password: "password123", ClientIP: "10.0.0.1"}
+	user := entity.User{ID: "1", Nickname: "jdoe", Password: "password123"}
+
+	type testCase struct {
+		name          string
+		credentials   entity.Credentials
+		mockSetup     func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger)
+		expectedUser  entity.User
+		expectedDelay time.Duration
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			name:        "success",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, nil)
+				attempts.EXPECT().Reset(gomock.Any(), "account:1").Return(nil)
+			},
+			expectedUser: user,
+		},
+		{
+			name:        "success after failures is delayed",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return([]entity.LoginAttempt{
+					{Key: "account:1", Failures: 2, LastFailureAt: now.Add(-time.Second)},
+					{Key: "ip:10.0.0.1", Failures: 1, LastFailureAt: now.Add(-time.Second)},
+				}, nil)
+				attempts.EXPECT().Reset(gomock.Any(), "account:1").Return(nil)
+			},
+			expectedUser:  user,
+			expectedDelay: 200 * time.Millisecond,
+		},
+		{
+			name:        "failures out of window are not delayed",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return([]entity.LoginAttempt{
+					{Key: "account:1", Failures: 2, LastFailureAt: now.Add(-time.Hour)},
+				}, nil)
+				attempts.EXPECT().Reset(gomock.Any(), "account:1").Return(nil)
+			},
+			expectedUser: user,
+		},
+		{
+			name:        "locked",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return([]entity.LoginAttempt{
+					{Key: "ip:10.0.0.1", Failures: 10, LastFailureAt: now, LockedUntil: now.Add(time.Minute)},
+				}, nil)
+			},
+			expectedError: &LockedError{Until: now.Add(time.Minute)},
+		},
+		{
+			name:        "wrong password",
+			credentials: entity.Credentials{Login: "jdoe", Password: "wrong", ClientIP: "10.0.0.1"},
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "account:1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "account:1", Failures: 1}, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}, nil)
+			},
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:        "unknown user",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(entity.User{}, datastore.ErrNotFound)
+				attempts.EXPECT().Get(gomock.Any(), "login:jdoe", "ip:10.0.0.1").Return(nil, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "login:jdoe", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "login:jdoe", Failures: 1}, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}, nil)
+			},
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:        "threshold reached locks account",
+			credentials: entity.Credentials{Login: "jdoe", Password: "wrong", ClientIP: "10.0.0.1"},
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "account:1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "account:1", Failures: 3}, nil)
+				attempts.EXPECT().Lock(gomock.Any(), "account:1", now.Add(15*time.Minute)).Return(nil)
+				l.EXPECT().Warn("locked after failed attempts")
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "ip:10.0.0.1", Failures: 3}, nil)
+			},
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:        "email and nickname share account counter",
+			credentials: entity.Credentials{Login: "jdoe@example.com", Password: "wrong", ClientIP: "10.0.0.1"},
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe@example.com").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "account:1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "account:1", Failures: 1}, nil)
+				attempts.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", now, time.Minute).
+					Return(entity.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}, nil)
+			},
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:        "user repo error",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(entity.User{}, errors.New("db error"))
+			},
+			expectedError: errors.New("repo get user by login: db error"),
+		},
+		{
+			name:        "repo error",
+			credentials: credentials,
+			mockSetup: func(users *MockCredentialsRepository, attempts *MockLoginAttemptRepository, l *logger.MockLogger) {
+				users.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(user, nil)
+				attempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, errors.New("db error"))
+			},
+			expectedError: errors.New("repo get login attempts: db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ao := assert.New(t)
+			mockUsers := NewMockCredentialsRepository(ctrl)
+			mockAttempts := NewMockLoginAttemptRepository(ctrl)
+			mockLogger := logger.NewMockLogger(ctrl)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			tc.mockSetup(mockUsers, mockAttempts, mockLogger)
+
+			var delay time.Duration
+			a := NewAuth(mockUsers, mockAttempts, cfg, mockLogger)
+			a.now = func() time.Time { return now }
+			a.sleep = func(_ context.Context, d time.Duration) error {
+				delay = d
+				return nil
+			}
+
+			result, err := a.Login(context.Background(), tc.credentials)
+			ao.Equal(tc.expectedDelay, delay)
+			if tc.expectedError != nil {
+				ao.EqualError(err, tc.expectedError.Error())
+			} else {
+				ao.NoError(err)
+				ao.Equal(tc.expectedUser, result)
+			}
+		})
+	}
+}
+
+func TestAuth_CheckPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ao := assert.New(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockAttempts := NewMockLoginAttemptRepository(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	user := entity.User{ID: "1", Password: "password123"}
+
+	// the same counters as login of the account
+	gomock.InOrder(
+		mockAttempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, nil),
+		mockAttempts.EXPECT().RegisterFailure(gomock.Any(), "account:1", now, time.Minute).
+			Return(entity.LoginAttempt{Key: "account:1", Failures: 1}, nil),
+		mockAttempts.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", now, time.Minute).
+			Return(entity.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}, nil),
+		mockAttempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return([]entity.LoginAttempt{
+			{Key: "account:1", Failures: 5, LastFailureAt: now, LockedUntil: now.Add(time.Minute)},
+		}, nil),
+		mockAttempts.EXPECT().Get(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil, nil),
+		mockAttempts.EXPECT().Reset(gomock.Any(), "account:1").Return(nil),
+	)
+
+	a := NewAuth(nil, mockAttempts, config.Login{MaxAccountFailures: 5, MaxIPFailures: 10, FailureWindow: time.Minute}, mockLogger)
+	a.now = func() time.Time { return now }
+	ao.Equal(ErrInvalidCredentials, a.CheckPassword(context.Background(), user, "wrong", "10.0.0.1"))
+	ao.Equal(&LockedError{Until: now.Add(time.Minute)}, a.CheckPassword(context.Background(), user, "password123", "10.0.0.1"))
+	ao.NoError(a.CheckPassword(context.Background(), user, "password123", "10.0.0.1"))
+}
+
+func TestAuth_Unlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ao := assert.New(t)
+	mockAttempts := NewMockLoginAttemptRepository(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockUsers := NewMockCredentialsRepository(ctrl)
+	mockUsers.EXPECT().GetByLogin(gomock.Any(), "jdoe").Return(entity.User{ID: "1"}, nil)
+	mockUsers.EXPECT().GetByLogin(gomock.Any(), "ghost").Return(entity.User{}, datastore.ErrNotFound)
+	mockAttempts.EXPECT().Reset(gomock.Any(), "account:1", "ip:10.0.0.1").Return(nil)
+	mockAttempts.EXPECT().Reset(gomock.Any(), "login:ghost").Return(nil)
+	mockLogger.EXPECT().Info("unlocked by admin").Times(2)
+
+	a := NewAuth(mockUsers, mockAttempts, config.Login{}, mockLogger)
+	ao.NoError(a.Unlock(context.Background(), "jdoe", "10.0.0.1"))
+	ao.NoError(a.Unlock(context.Background(), "ghost", ""))
+	ao.NoError(a.Unlock(context.Background(), "", ""))
+}
+
+func TestAuth_delay(t *testing.T) {
+	a := NewAuth(nil, nil, config.Login{DelayStep: 100 * time.Millisecond, MaxDelay: time.Second}, nil)
+	tests := map[int]time.Duration{
+		0:  0,
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	}
+	for failures, expected := range tests {
+		assert.Equal(t, expected, a.delay(failures), "failures=%d", failures)
+	}
+}
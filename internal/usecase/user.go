@@ -4,11 +4,17 @@ import (
 	"context"
 	"fmt"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"test_task/internal/entity"
 	"test_task/internal/logger"
 	"test_task/internal/notificator"
+	"test_task/internal/password"
+	"test_task/internal/tracing"
 )
 
+const tracerName = "test_task/internal/usecase"
+
 //go:generate go run github.com/golang/mock/mockgen --source=user.go --destination=user_mock.go --package=usecase
 
 type UserRepository interface {
@@ -16,71 +22,271 @@ type UserRepository interface {
 	Update(ctx context.Context, user entity.User) (entity.User, error)
 	Delete(ctx context.Context, id string) error
 	GetList(ctx context.Context, query entity.UserFilter) ([]entity.User, int64, error)
+	GetByID(ctx context.Context, id string) (entity.User, error)
+	// GetByIDForUpdate locks the user until the end of the transaction.
+	GetByIDForUpdate(ctx context.Context, id string) (entity.User, error)
+	UpdatePassword(ctx context.Context, id string, password string) error
+}
+
+type PasswordHistoryRepository interface {
+	GetRecent(ctx context.Context, userID string, limit int) ([]string, error)
+	Add(ctx context.Context, userID string, passwordHash string) error
+}
+
+// PasswordChecker checks current password of the user, failures are limited like login attempts.
+type PasswordChecker interface {
+	CheckPassword(ctx context.Context, user entity.User, password, clientIP string) error
+}
+
+type PasswordValidator interface {
+	HistorySize() int
+	Validate(ctx context.Context, user entity.User, recentHashes []string) error
 }
 
 type Notificator interface {
 	Push(ctx context.Context, data notificator.Notification) error
 }
 
+// TransactionalNotificator stores notification in the transaction of the change(outbox), so failed push rolls the change back.
+// Other notificators are pushed after commit, so notification of rolled back change isn't published.
+type TransactionalNotificator interface {
+	Notificator
+	Transactional()
+}
+
+// Transactor runs fn in a transaction, repositories called with ctx passed to fn take part in it.
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type User struct {
-	repo        UserRepository
-	notificator Notificator
-	logger      logger.Logger
+	repo              UserRepository
+	tx                Transactor
+	passwordHistory   PasswordHistoryRepository
+	passwordValidator PasswordValidator
+	passwordChecker   PasswordChecker
+	notificator       Notificator
+	logger            logger.Logger
 }
 
-func NewUser(repo UserRepository, notificator Notificator, l logger.Logger) *User {
-	return &User{repo: repo, notificator: notificator, logger: l}
+// NewUser creates new instance of User. With TransactionalNotificator change and its notification are written
+// in one transaction, otherwise notification is pushed after commit and its failure is only logged.
+func NewUser(repo UserRepository, tx Transactor, passwordHistory PasswordHistoryRepository, passwordValidator PasswordValidator,
+	passwordChecker PasswordChecker, notificator Notificator, l logger.Logger) *User {
+	return &User{
+		repo:              repo,
+		tx:                tx,
+		passwordHistory:   passwordHistory,
+		passwordValidator: passwordValidator,
+		passwordChecker:   passwordChecker,
+		notificator:       notificator,
+		logger:            l,
+	}
 }
 
-func (u *User) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	createdUser, err := u.repo.Create(ctx, user)
+func (u *User) Create(ctx context.Context, user entity.User) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "User.Create")
+	defer func() { tracing.End(span, err) }()
+	_, err = u.validatePassword(ctx, user, nil)
 	if err != nil {
-		return entity.User{}, fmt.Errorf("repo create user: %w", err)
+		return entity.User{}, err
 	}
-	err = u.notificator.Push(ctx, notificator.Notification{
-		Type: notificator.Insert,
-		Data: createdUser,
+	var createdUser entity.User
+	err = u.change(ctx, func(ctx context.Context) (notificator.Notification, error) {
+		createdUser, err = u.repo.Create(ctx, user)
+		if err != nil {
+			return notificator.Notification{}, fmt.Errorf("repo create user: %w", err)
+		}
+		return notification(notificator.Insert, createdUser.ID, nil, &createdUser), nil
 	})
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user create: push notification: %w", err))
+		return entity.User{}, err
 	}
+	u.rememberPassword(ctx, createdUser.ID, createdUser.Password)
 	return createdUser, nil
 }
 
-func (u *User) Update(ctx context.Context, user entity.User) (entity.User, error) {
-	updatedUser, err := u.repo.Update(ctx, user)
+func (u *User) Update(ctx context.Context, user entity.User) (_ entity.User, err error) {
+	ctx, span := startSpan(ctx, "User.Update")
+	defer func() { tracing.End(span, err) }()
+	var (
+		updatedUser     entity.User
+		passwordChanged bool
+	)
+	err = u.change(ctx, func(ctx context.Context) (notificator.Notification, error) {
+		before, err := u.current(ctx, user.ID)
+		if err != nil {
+			return notificator.Notification{}, err
+		}
+		passwordChanged, err = u.validatePassword(ctx, user, &before)
+		if err != nil {
+			return notificator.Notification{}, err
+		}
+		updatedUser, err = u.repo.Update(ctx, user)
+		if err != nil {
+			return notificator.Notification{}, fmt.Errorf("repo update user: %w", err)
+		}
+		return notification(notificator.Update, updatedUser.ID, &before, &updatedUser), nil
+	})
 	if err != nil {
-		return entity.User{}, fmt.Errorf("repo update user: %w", err)
+		return entity.User{}, err
 	}
-	err = u.notificator.Push(ctx, notificator.Notification{
-		Type: notificator.Update,
-		Data: updatedUser,
+	if passwordChanged {
+		u.rememberPassword(ctx, updatedUser.ID, updatedUser.Password)
+	}
+	return updatedUser, nil
+}
+
+func (u *User) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "User.Delete")
+	defer func() { tracing.End(span, err) }()
+	return u.change(ctx, func(ctx context.Context) (notificator.Notification, error) {
+		before, err := u.current(ctx, id)
+		if err != nil {
+			return notificator.Notification{}, err
+		}
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return notificator.Notification{}, fmt.Errorf("repo delete user: %w", err)
+		}
+		return notification(notificator.Delete, id, &before, nil), nil
 	})
+}
+
+func (u *User) GetList(ctx context.Context, filter entity.UserFilter) (_ []entity.User, _ int64, err error) {
+	ctx, span := startSpan(ctx, "User.GetList")
+	defer func() { tracing.End(span, err) }()
+	res, total, err := u.repo.GetList(ctx, filter)
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user update: push notification: %w", err))
+		return nil, 0, fmt.Errorf("repo getList user: %w", err)
 	}
-	return updatedUser, nil
+	return res, total, nil
 }
 
-func (u *User) Delete(ctx context.Context, id string) error {
-	err := u.repo.Delete(ctx, id)
+// ChangePassword sets new password, if old password is correct.
+// Wrong old password is counted as failed login of the account and clientIP, so it can lock them out.
+// Old password is checked before the transaction, so failure is counted even though the change is rolled back.
+func (u *User) ChangePassword(ctx context.Context, id, oldPassword, newPassword, clientIP string) (err error) {
+	ctx, span := startSpan(ctx, "User.ChangePassword")
+	defer func() { tracing.End(span, err) }()
+	checked, err := u.repo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("repo delete user: %w", err)
+		return fmt.Errorf("repo get user: %w", err)
+	}
+	if err = u.passwordChecker.CheckPassword(ctx, checked, oldPassword, clientIP); err != nil {
+		return err
 	}
-	err = u.notificator.Push(ctx, notificator.Notification{
-		Type: notificator.Delete,
-		Data: entity.User{ID: id},
+	err = u.change(ctx, func(ctx context.Context) (notificator.Notification, error) {
+		before, err := u.current(ctx, id)
+		if err != nil {
+			return notificator.Notification{}, err
+		}
+		// password was changed concurrently after the check, so old password isn't correct anymore
+		if before.Password != checked.Password {
+			return notificator.Notification{}, ErrInvalidCredentials
+		}
+		after := before
+		after.Password = newPassword
+		if _, err := u.validatePassword(ctx, after, nil); err != nil {
+			return notificator.Notification{}, err
+		}
+		if err := u.repo.UpdatePassword(ctx, id, newPassword); err != nil {
+			return notificator.Notification{}, fmt.Errorf("repo update password: %w", err)
+		}
+		return notification(notificator.Update, id, &before, &after), nil
 	})
 	if err != nil {
-		u.logger.Error(fmt.Errorf("user delete: push notification: %w", err))
+		return err
 	}
+	u.rememberPassword(ctx, id, newPassword)
 	return nil
 }
 
-func (u *User) GetList(ctx context.Context, filter entity.UserFilter) ([]entity.User, int64, error) {
-	res, total, err := u.repo.GetList(ctx, filter)
+// validatePassword checks password policy and reports whether password differs from the current one.
+// Password equal to the stored password of current user is accepted without checks.
+func (u *User) validatePassword(ctx context.Context, user entity.User, current *entity.User) (bool, error) {
+	if current != nil && current.Password == user.Password {
+		return false, nil
+	}
+	var recent []string
+	if size := u.passwordValidator.HistorySize(); user.ID != "" && size > 0 {
+		var err error
+		recent, err = u.passwordHistory.GetRecent(ctx, user.ID, size)
+		if err != nil {
+			return false, fmt.Errorf("repo get password history: %w", err)
+		}
+	}
+	return true, u.passwordValidator.Validate(ctx, user, recent)
+}
+
+// current returns user state before the change and locks the user, so concurrent change waits for the transaction.
+// datastore.ErrNotFound is returned if the user doesn't exist.
+func (u *User) current(ctx context.Context, id string) (entity.User, error) {
+	user, err := u.repo.GetByIDForUpdate(ctx, id)
 	if err != nil {
-		return nil, 0, fmt.Errorf("repo getList user: %w", err)
+		return entity.User{}, fmt.Errorf("repo get user: %w", err)
 	}
-	return res, total, nil
+	return user, nil
+}
+
+// change runs fn in a transaction and pushes notification returned by fn. TransactionalNotificator is pushed
+// in the transaction, so failure rolls the change back. Other notificator is pushed after commit, its failure is logged.
+func (u *User) change(ctx context.Context, fn func(ctx context.Context) (notificator.Notification, error)) error {
+	if _, ok := u.notificator.(TransactionalNotificator); ok {
+		return u.tx.InTransaction(ctx, func(ctx context.Context) error {
+			n, err := fn(ctx)
+			if err != nil {
+				return err
+			}
+			return u.push(ctx, n)
+		})
+	}
+	var n notificator.Notification
+	err := u.tx.InTransaction(ctx, func(ctx context.Context) (err error) {
+		n, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// the change is committed already, lost notification doesn't fail it
+	if err = u.push(ctx, n); err != nil {
+		u.operationLogger(ctx, "notify", n.Key).Error(err)
+	}
+	return nil
+}
+
+func (u *User) push(ctx context.Context, n notificator.Notification) error {
+	if err := u.notificator.Push(ctx, n); err != nil {
+		return fmt.Errorf("push notification: %w", err)
+	}
+	return nil
+}
+
+// notification describes change of user from before to after state.
+func notification(operation notificator.OperationType, userID string, before, after *entity.User) notificator.Notification {
+	return notificator.Notification{
+		Type: operation,
+		Key:  userID,
+		Data: notificator.NewUserEventData(before, after),
+	}
+}
+
+// rememberPassword adds password to history. Failure doesn't fail the operation.
+func (u *User) rememberPassword(ctx context.Context, userID, pass string) {
+	hash, err := password.Hash(pass)
+	if err == nil {
+		err = u.passwordHistory.Add(ctx, userID, hash)
+	}
+	if err != nil {
+		u.operationLogger(ctx, "remember password", userID).Error(err)
+	}
+}
+
+func (u *User) operationLogger(ctx context.Context, operation, userID string) logger.Logger {
+	return u.logger.WithContext(ctx).WithFields(logger.Fields{logger.FieldOperation: operation, logger.FieldUserID: userID})
+}
+
+// startSpan starts usecase span.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer(tracerName).Start(ctx, name)
 }
@@ -65,6 +65,36 @@ func (mr *MockUserRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserRepository)(nil).Delete), ctx, id)
 }
 
+// GetByID mocks base method.
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDForUpdate mocks base method.
+func (m *MockUserRepository) GetByIDForUpdate(ctx context.Context, id string) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetByIDForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetByIDForUpdate), ctx, id)
+}
+
 // GetList mocks base method.
 func (m *MockUserRepository) GetList(ctx context.Context, query entity.UserFilter) ([]entity.User, int64, error) {
 	m.ctrl.T.Helper()
@@ -96,6 +126,160 @@ func (mr *MockUserRepositoryMockRecorder) Update(ctx, user interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, user)
 }
 
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(ctx, id, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), ctx, id, password)
+}
+
+// MockPasswordHistoryRepository is a mock of PasswordHistoryRepository interface.
+type MockPasswordHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordHistoryRepositoryMockRecorder
+}
+
+// MockPasswordHistoryRepositoryMockRecorder is the mock recorder for MockPasswordHistoryRepository.
+type MockPasswordHistoryRepositoryMockRecorder struct {
+	mock *MockPasswordHistoryRepository
+}
+
+// NewMockPasswordHistoryRepository creates a new mock instance.
+func NewMockPasswordHistoryRepository(ctrl *gomock.Controller) *MockPasswordHistoryRepository {
+	mock := &MockPasswordHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockPasswordHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordHistoryRepository) EXPECT() *MockPasswordHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockPasswordHistoryRepository) Add(ctx context.Context, userID, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockPasswordHistoryRepositoryMockRecorder) Add(ctx, userID, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockPasswordHistoryRepository)(nil).Add), ctx, userID, passwordHash)
+}
+
+// GetRecent mocks base method.
+func (m *MockPasswordHistoryRepository) GetRecent(ctx context.Context, userID string, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecent", ctx, userID, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecent indicates an expected call of GetRecent.
+func (mr *MockPasswordHistoryRepositoryMockRecorder) GetRecent(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecent", reflect.TypeOf((*MockPasswordHistoryRepository)(nil).GetRecent), ctx, userID, limit)
+}
+
+// MockPasswordChecker is a mock of PasswordChecker interface.
+type MockPasswordChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordCheckerMockRecorder
+}
+
+// MockPasswordCheckerMockRecorder is the mock recorder for MockPasswordChecker.
+type MockPasswordCheckerMockRecorder struct {
+	mock *MockPasswordChecker
+}
+
+// NewMockPasswordChecker creates a new mock instance.
+func NewMockPasswordChecker(ctrl *gomock.Controller) *MockPasswordChecker {
+	mock := &MockPasswordChecker{ctrl: ctrl}
+	mock.recorder = &MockPasswordCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordChecker) EXPECT() *MockPasswordCheckerMockRecorder {
+	return m.recorder
+}
+
+// CheckPassword mocks base method.
+func (m *MockPasswordChecker) CheckPassword(ctx context.Context, user entity.User, password, clientIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPassword", ctx, user, password, clientIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckPassword indicates an expected call of CheckPassword.
+func (mr *MockPasswordCheckerMockRecorder) CheckPassword(ctx, user, password, clientIP interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPassword", reflect.TypeOf((*MockPasswordChecker)(nil).CheckPassword), ctx, user, password, clientIP)
+}
+
+// MockPasswordValidator is a mock of PasswordValidator interface.
+type MockPasswordValidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordValidatorMockRecorder
+}
+
+// MockPasswordValidatorMockRecorder is the mock recorder for MockPasswordValidator.
+type MockPasswordValidatorMockRecorder struct {
+	mock *MockPasswordValidator
+}
+
+// NewMockPasswordValidator creates a new mock instance.
+func NewMockPasswordValidator(ctrl *gomock.Controller) *MockPasswordValidator {
+	mock := &MockPasswordValidator{ctrl: ctrl}
+	mock.recorder = &MockPasswordValidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordValidator) EXPECT() *MockPasswordValidatorMockRecorder {
+	return m.recorder
+}
+
+// HistorySize mocks base method.
+func (m *MockPasswordValidator) HistorySize() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HistorySize")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// HistorySize indicates an expected call of HistorySize.
+func (mr *MockPasswordValidatorMockRecorder) HistorySize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistorySize", reflect.TypeOf((*MockPasswordValidator)(nil).HistorySize))
+}
+
+// Validate mocks base method.
+func (m *MockPasswordValidator) Validate(ctx context.Context, user entity.User, recentHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", ctx, user, recentHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockPasswordValidatorMockRecorder) Validate(ctx, user, recentHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockPasswordValidator)(nil).Validate), ctx, user, recentHashes)
+}
+
 // MockNotificator is a mock of Notificator interface.
 type MockNotificator struct {
 	ctrl     *gomock.Controller
@@ -132,3 +316,89 @@ func (mr *MockNotificatorMockRecorder) Push(ctx, data interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockNotificator)(nil).Push), ctx, data)
 }
+
+// MockTransactionalNotificator is a mock of TransactionalNotificator interface.
+type MockTransactionalNotificator struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionalNotificatorMockRecorder
+}
+
+// MockTransactionalNotificatorMockRecorder is the mock recorder for MockTransactionalNotificator.
+type MockTransactionalNotificatorMockRecorder struct {
+	mock *MockTransactionalNotificator
+}
+
+// NewMockTransactionalNotificator creates a new mock instance.
+func NewMockTransactionalNotificator(ctrl *gomock.Controller) *MockTransactionalNotificator {
+	mock := &MockTransactionalNotificator{ctrl: ctrl}
+	mock.recorder = &MockTransactionalNotificatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionalNotificator) EXPECT() *MockTransactionalNotificatorMockRecorder {
+	return m.recorder
+}
+
+// Push mocks base method.
+func (m *MockTransactionalNotificator) Push(ctx context.Context, data notificator.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Push", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Push indicates an expected call of Push.
+func (mr *MockTransactionalNotificatorMockRecorder) Push(ctx, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockTransactionalNotificator)(nil).Push), ctx, data)
+}
+
+// Transactional mocks base method.
+func (m *MockTransactionalNotificator) Transactional() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Transactional")
+}
+
+// Transactional indicates an expected call of Transactional.
+func (mr *MockTransactionalNotificatorMockRecorder) Transactional() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transactional", reflect.TypeOf((*MockTransactionalNotificator)(nil).Transactional))
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// InTransaction mocks base method.
+func (m *MockTransactor) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockTransactorMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockTransactor)(nil).InTransaction), ctx, fn)
+}
@@ -5,14 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 
+	"test_task/internal/datastore"
 	"test_task/internal/entity"
 	"test_task/internal/logger"
 	"test_task/internal/notificator"
 	"test_task/internal/pagination"
+	"test_task/internal/password"
 )
 
 func TestUser_Create(t *testing.T) {
@@ -48,7 +57,7 @@ func TestUser_Create(t *testing.T) {
 			repoResult:    entity.User{ID: "1", FirstName: "John"},
 			repoError:     nil,
 			notifyError:   errors.New("notification error"),
-			expectedError: nil,
+			expectedError: fmt.Errorf("push notification: %w", errors.New("notification error")),
 		},
 	}
 
@@ -60,17 +69,26 @@ func TestUser_Create(t *testing.T) {
 			mockRepo := NewMockUserRepository(ctrl)
 			mockNotificator := notificator.NewMockNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			u := NewUser(mockRepo, mockNotificator, mockLogger)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockPasswordHistory := NewMockPasswordHistoryRepository(ctrl)
+			mockPasswordValidator := NewMockPasswordValidator(ctrl)
+			mockPasswordHistory.EXPECT().GetRecent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			mockPasswordHistory.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockPasswordValidator.EXPECT().HistorySize().Return(0).AnyTimes()
+			mockPasswordValidator.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			u := NewUser(mockRepo, noTransaction{}, mockPasswordHistory, mockPasswordValidator, nil, outbox{mockNotificator}, mockLogger)
 
 			mockRepo.EXPECT().Create(gomock.Any(), tc.input).Return(tc.repoResult, tc.repoError)
 			if tc.repoError == nil {
 				mockNotificator.EXPECT().Push(gomock.Any(), notificator.Notification{
 					Type: notificator.Insert,
-					Data: tc.input,
+					Key:  tc.repoResult.ID,
+					Data: notificator.UserEventData{
+						After:         &notificator.UserSnapshot{ID: "1", FirstName: "John"},
+						ChangedFields: []string{"first_name"},
+					},
 				}).Return(tc.notifyError)
-				if tc.notifyError != nil {
-					mockLogger.EXPECT().Error(gomock.Any()).Times(1)
-				}
 			}
 
 			result, err := u.Create(context.Background(), tc.input)
@@ -89,21 +107,43 @@ func TestUser_Update(t *testing.T) {
 	type testCase struct {
 		name          string
 		input         entity.User
+		stored        entity.User
+		storedError   error
 		repoResult    entity.User
 		repoError     error
 		notifyError   error
+		expectedData  notificator.UserEventData
 		expectedError error
 	}
 
+	changed := notificator.UserEventData{
+		Before:        &notificator.UserSnapshot{ID: "1", FirstName: "Jon", Email: "jon@example.com"},
+		After:         &notificator.UserSnapshot{ID: "1", FirstName: "John", Email: "jon@example.com"},
+		ChangedFields: []string{"first_name", "password"},
+	}
 	testCases := []testCase{
 		{
 			name:          "success",
-			input:         entity.User{ID: "1", FirstName: "John"},
-			repoResult:    entity.User{ID: "1", FirstName: "John"},
+			input:         entity.User{ID: "1", FirstName: "John", Password: "new", Email: "jon@example.com"},
+			stored:        entity.User{ID: "1", FirstName: "Jon", Password: "old", Email: "jon@example.com"},
+			repoResult:    entity.User{ID: "1", FirstName: "John", Password: "new", Email: "jon@example.com"},
 			repoError:     nil,
 			notifyError:   nil,
+			expectedData:  changed,
 			expectedError: nil,
 		},
+		{
+			name:          "unknown user",
+			input:         entity.User{ID: "1", FirstName: "John"},
+			storedError:   datastore.ErrNotFound,
+			expectedError: fmt.Errorf("repo get user: %w", datastore.ErrNotFound),
+		},
+		{
+			name:          "get error",
+			input:         entity.User{ID: "1", FirstName: "John"},
+			storedError:   errors.New("repo error"),
+			expectedError: fmt.Errorf("repo get user: %w", errors.New("repo error")),
+		},
 		{
 			name:          "repo error",
 			input:         entity.User{ID: "1", FirstName: "John"},
@@ -114,11 +154,13 @@ func TestUser_Update(t *testing.T) {
 		},
 		{
 			name:          "notificator error",
-			input:         entity.User{ID: "1", FirstName: "John"},
-			repoResult:    entity.User{ID: "1", FirstName: "John"},
+			input:         entity.User{ID: "1", FirstName: "John", Password: "new", Email: "jon@example.com"},
+			stored:        entity.User{ID: "1", FirstName: "Jon", Password: "old", Email: "jon@example.com"},
+			repoResult:    entity.User{ID: "1", FirstName: "John", Password: "new", Email: "jon@example.com"},
 			repoError:     nil,
 			notifyError:   errors.New("notification error"),
-			expectedError: nil,
+			expectedData:  changed,
+			expectedError: fmt.Errorf("push notification: %w", errors.New("notification error")),
 		},
 	}
 
@@ -129,17 +171,26 @@ func TestUser_Update(t *testing.T) {
 			mockRepo := NewMockUserRepository(ctrl)
 			mockNotificator := notificator.NewMockNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			u := NewUser(mockRepo, mockNotificator, mockLogger)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockPasswordHistory := NewMockPasswordHistoryRepository(ctrl)
+			mockPasswordValidator := NewMockPasswordValidator(ctrl)
+			mockPasswordHistory.EXPECT().GetRecent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			mockPasswordHistory.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockPasswordValidator.EXPECT().HistorySize().Return(0).AnyTimes()
+			mockPasswordValidator.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			u := NewUser(mockRepo, noTransaction{}, mockPasswordHistory, mockPasswordValidator, nil, outbox{mockNotificator}, mockLogger)
 
-			mockRepo.EXPECT().Update(gomock.Any(), tc.input).Return(tc.repoResult, tc.repoError)
-			if tc.repoError == nil {
+			mockRepo.EXPECT().GetByIDForUpdate(gomock.Any(), tc.input.ID).Return(tc.stored, tc.storedError)
+			if tc.storedError == nil {
+				mockRepo.EXPECT().Update(gomock.Any(), tc.input).Return(tc.repoResult, tc.repoError)
+			}
+			if tc.expectedData.ChangedFields != nil {
 				mockNotificator.EXPECT().Push(gomock.Any(), notificator.Notification{
 					Type: notificator.Update,
-					Data: tc.input,
+					Key:  tc.repoResult.ID,
+					Data: tc.expectedData,
 				}).Return(tc.notifyError)
-				if tc.notifyError != nil {
-					mockLogger.EXPECT().Error(gomock.Any()).Times(1)
-				}
 			}
 
 			result, err := u.Update(context.Background(), tc.input)
@@ -183,7 +234,7 @@ func TestUser_Delete(t *testing.T) {
 			input:         "1",
 			repoError:     nil,
 			notifyError:   errors.New("notification error"),
-			expectedError: nil,
+			expectedError: fmt.Errorf("push notification: %w", errors.New("notification error")),
 		},
 	}
 
@@ -195,17 +246,27 @@ func TestUser_Delete(t *testing.T) {
 			mockRepo := NewMockUserRepository(ctrl)
 			mockNotificator := notificator.NewMockNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			u := NewUser(mockRepo, mockNotificator, mockLogger)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockPasswordHistory := NewMockPasswordHistoryRepository(ctrl)
+			mockPasswordValidator := NewMockPasswordValidator(ctrl)
+			mockPasswordHistory.EXPECT().GetRecent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			mockPasswordHistory.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockPasswordValidator.EXPECT().HistorySize().Return(0).AnyTimes()
+			mockPasswordValidator.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			u := NewUser(mockRepo, noTransaction{}, mockPasswordHistory, mockPasswordValidator, nil, outbox{mockNotificator}, mockLogger)
 
+			mockRepo.EXPECT().GetByIDForUpdate(gomock.Any(), tc.input).Return(entity.User{ID: tc.input, Nickname: "jdoe", Password: "secret"}, nil)
 			mockRepo.EXPECT().Delete(gomock.Any(), tc.input).Return(tc.repoError)
 			if tc.repoError == nil {
 				mockNotificator.EXPECT().Push(gomock.Any(), notificator.Notification{
 					Type: notificator.Delete,
-					Data: entity.User{ID: tc.input},
+					Key:  tc.input,
+					Data: notificator.UserEventData{
+						Before:        &notificator.UserSnapshot{ID: tc.input, Nickname: "jdoe"},
+						ChangedFields: []string{"nickname", "password"},
+					},
 				}).Return(tc.notifyError)
-				if tc.notifyError != nil {
-					mockLogger.EXPECT().Error(gomock.Any()).Times(1)
-				}
 			}
 
 			err := u.Delete(context.Background(), tc.input)
@@ -262,7 +323,15 @@ func TestUser_GetList(t *testing.T) {
 			mockRepo := NewMockUserRepository(ctrl)
 			mockNotificator := notificator.NewMockNotificator(ctrl)
 			mockLogger := logger.NewMockLogger(ctrl)
-			u := NewUser(mockRepo, mockNotificator, mockLogger)
+			mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+			mockPasswordHistory := NewMockPasswordHistoryRepository(ctrl)
+			mockPasswordValidator := NewMockPasswordValidator(ctrl)
+			mockPasswordHistory.EXPECT().GetRecent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			mockPasswordHistory.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockPasswordValidator.EXPECT().HistorySize().Return(0).AnyTimes()
+			mockPasswordValidator.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			u := NewUser(mockRepo, noTransaction{}, mockPasswordHistory, mockPasswordValidator, nil, outbox{mockNotificator}, mockLogger)
 
 			mockRepo.EXPECT().GetList(gomock.Any(), tc.input).Return(tc.repoResult, tc.repoTotal, tc.repoError)
 
@@ -278,3 +347,291 @@ func TestUser_GetList(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_UpdatePasswordPolicy(t *testing.T) {
+	currentHash := "current-hash"
+	policyErr := &password.PolicyError{Reasons: []string{"must contain a digit"}}
+
+	type testCase struct {
+		name          string
+		input         entity.User
+		mockSetup     func(repo *MockUserRepository, history *MockPasswordHistoryRepository, validator *MockPasswordValidator)
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			// password is compared with the stored one, user may have no password history
+			name:  "unchanged password is not validated",
+			input: entity.User{ID: "1", Password: "Current-pass1"},
+			mockSetup: func(repo *MockUserRepository, history *MockPasswordHistoryRepository, validator *MockPasswordValidator) {
+				repo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(entity.User{ID: "1", Password: "Current-pass1"}, nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(entity.User{ID: "1", Password: "Current-pass1"}, nil)
+			},
+		},
+		{
+			name:  "changed password is validated and remembered",
+			input: entity.User{ID: "1", Password: "New-pass1"},
+			mockSetup: func(repo *MockUserRepository, history *MockPasswordHistoryRepository, validator *MockPasswordValidator) {
+				validator.EXPECT().HistorySize().Return(3)
+				history.EXPECT().GetRecent(gomock.Any(), "1", 3).Return([]string{currentHash}, nil)
+				validator.EXPECT().Validate(gomock.Any(), entity.User{ID: "1", Password: "New-pass1"}, []string{currentHash}).Return(nil)
+				repo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(entity.User{ID: "1", Password: "Current-pass1"}, nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(entity.User{ID: "1", Password: "New-pass1"}, nil)
+				history.EXPECT().Add(gomock.Any(), "1", passwordHashOf("New-pass1")).Return(nil)
+			},
+		},
+		{
+			name:  "policy violation",
+			input: entity.User{ID: "1", Password: "weak"},
+			mockSetup: func(repo *MockUserRepository, history *MockPasswordHistoryRepository, validator *MockPasswordValidator) {
+				repo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(entity.User{ID: "1", Password: "Current-pass1"}, nil)
+				validator.EXPECT().HistorySize().Return(3)
+				history.EXPECT().GetRecent(gomock.Any(), "1", 3).Return([]string{currentHash}, nil)
+				validator.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any()).Return(policyErr)
+			},
+			expectedError: policyErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ao := assert.New(t)
+			mockRepo := NewMockUserRepository(ctrl)
+			mockPasswordHistory := NewMockPasswordHistoryRepository(ctrl)
+			mockPasswordValidator := NewMockPasswordValidator(ctrl)
+			mockNotificator := notificator.NewMockNotificator(ctrl)
+			mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			tc.mockSetup(mockRepo, mockPasswordHistory, mockPasswordValidator)
+			u := NewUser(mockRepo, noTransaction{}, mockPasswordHistory, mockPasswordValidator, nil, mockNotificator, logger.NewMockLogger(ctrl))
+
+			_, err := u.Update(context.Background(), tc.input)
+			ao.Equal(tc.expectedError, err)
+		})
+	}
+}
+
+func TestUser_ChangePassword(t *testing.T) {
+	user := entity.User{ID: "1", Nickname: "jdoe", Password: "Old-pass1"}
+	policyErr := &password.PolicyError{Reasons: []string{"must not contain the nickname"}}
+	lockedErr := &LockedError{Until: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	type testCase struct {
+		name          string
+		oldPassword   string
+		newPassword   string
+		mockSetup     func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator)
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			name:        "success",
+			oldPassword: "Old-pass1",
+			newPassword: "New-pass1",
+			mockSetup: func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator) {
+				changed := entity.User{ID: "1", Nickname: "jdoe", Password: "New-pass1"}
+				repo.EXPECT().GetByID(gomock.Any(), "1").Return(user, nil)
+				checker.EXPECT().CheckPassword(gomock.Any(), user, "Old-pass1", "10.0.0.1").Return(nil)
+				repo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(user, nil)
+				validator.EXPECT().HistorySize().Return(2)
+				history.EXPECT().GetRecent(gomock.Any(), "1", 2).Return(nil, nil)
+				validator.EXPECT().Validate(gomock.Any(), changed, nil).Return(nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), "1", "New-pass1").Return(nil)
+				history.EXPECT().Add(gomock.Any(), "1", passwordHashOf("New-pass1")).Return(nil)
+				n.EXPECT().Push(gomock.Any(), notificator.Notification{Type: notificator.Update, Key: "1", Data: notificator.UserEventData{
+					Before:        &notificator.UserSnapshot{ID: "1", Nickname: "jdoe"},
+					After:         &notificator.UserSnapshot{ID: "1", Nickname: "jdoe"},
+					ChangedFields: []string{"password"},
+				}}).Return(nil)
+			},
+		},
+		{
+			name:        "wrong old password",
+			oldPassword: "wrong",
+			newPassword: "New-pass1",
+			mockSetup: func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator) {
+				repo.EXPECT().GetByID(gomock.Any(), "1").Return(user, nil)
+				checker.EXPECT().CheckPassword(gomock.Any(), user, "wrong", "10.0.0.1").Return(ErrInvalidCredentials)
+			},
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:        "locked out",
+			oldPassword: "wrong",
+			newPassword: "New-pass1",
+			mockSetup: func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator) {
+				repo.EXPECT().GetByID(gomock.Any(), "1").Return(user, nil)
+				checker.EXPECT().CheckPassword(gomock.Any(), user, "wrong", "10.0.0.1").Return(lockedErr)
+			},
+			expectedError: lockedErr,
+		},
+		{
+			name:        "password changed concurrently",
+			oldPassword: "Old-pass1",
+			newPassword: "New-pass1",
+			mockSetup: func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator) {
+				repo.EXPECT().GetByID(gomock.Any(), "1").Return(user, nil)
+				checker.EXPECT().CheckPassword(gomock.Any(), user, "Old-pass1", "10.0.0.1").Return(nil)
+				repo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(entity.User{ID: "1", Nickname: "jdoe", Password: "Other-pass1"}, nil)
+			},
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:        "policy violation",
+			oldPassword: "Old-pass1",
+			newPassword: "jdoe-pass1",
+			mockSetup: func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator) {
+				repo.EXPECT().GetByID(gomock.Any(), "1").Return(user, nil)
+				checker.EXPECT().CheckPassword(gomock.Any(), user, "Old-pass1", "10.0.0.1").Return(nil)
+				repo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(user, nil)
+				validator.EXPECT().HistorySize().Return(2)
+				history.EXPECT().GetRecent(gomock.Any(), "1", 2).Return(nil, nil)
+				validator.EXPECT().Validate(gomock.Any(), gomock.Any(), gomock.Any()).Return(policyErr)
+			},
+			expectedError: policyErr,
+		},
+		{
+			name:        "repo error",
+			oldPassword: "Old-pass1",
+			newPassword: "New-pass1",
+			mockSetup: func(repo *MockUserRepository, checker *MockPasswordChecker, history *MockPasswordHistoryRepository, validator *MockPasswordValidator, n *notificator.MockNotificator) {
+				repo.EXPECT().GetByID(gomock.Any(), "1").Return(entity.User{}, errors.New("repo error"))
+			},
+			expectedError: fmt.Errorf("repo get user: %w", errors.New("repo error")),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ao := assert.New(t)
+			mockRepo := NewMockUserRepository(ctrl)
+			mockPasswordHistory := NewMockPasswordHistoryRepository(ctrl)
+			mockPasswordValidator := NewMockPasswordValidator(ctrl)
+			mockPasswordChecker := NewMockPasswordChecker(ctrl)
+			mockNotificator := notificator.NewMockNotificator(ctrl)
+			tc.mockSetup(mockRepo, mockPasswordChecker, mockPasswordHistory, mockPasswordValidator, mockNotificator)
+			u := NewUser(mockRepo, noTransaction{}, mockPasswordHistory, mockPasswordValidator, mockPasswordChecker, mockNotificator,
+				logger.NewMockLogger(ctrl))
+
+			err := u.ChangePassword(context.Background(), "1", tc.oldPassword, tc.newPassword, "10.0.0.1")
+			ao.Equal(tc.expectedError, err)
+		})
+	}
+}
+
+func TestUser_DeletePushErrorRollsBack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ao := assert.New(t)
+	mockRepo := NewMockUserRepository(ctrl)
+	mockNotificator := notificator.NewMockNotificator(ctrl)
+	mockTransactor := NewMockTransactor(ctrl)
+	ctx := context.Background()
+
+	notifyErr := errors.New("notification error")
+	mockTransactor.EXPECT().InTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		// error of fn rolls transaction back
+		return fn(ctx)
+	})
+	mockRepo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(entity.User{ID: "1"}, nil)
+	mockRepo.EXPECT().Delete(gomock.Any(), "1").Return(nil)
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(notifyErr)
+
+	u := NewUser(mockRepo, mockTransactor, NewMockPasswordHistoryRepository(ctrl), NewMockPasswordValidator(ctrl), nil, outbox{mockNotificator},
+		logger.NewMockLogger(ctrl))
+	err := u.Delete(ctx, "1")
+	ao.ErrorIs(err, notifyErr)
+	ao.EqualError(err, "push notification: notification error")
+}
+
+func TestUser_PushAfterCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ao := assert.New(t)
+	mockRepo := NewMockUserRepository(ctrl)
+	mockNotificator := notificator.NewMockNotificator(ctrl)
+	mockTransactor := NewMockTransactor(ctrl)
+	mockLogger := logger.NewMockLogger(ctrl)
+	mockLogger.EXPECT().WithContext(gomock.Any()).Return(mockLogger).AnyTimes()
+	mockLogger.EXPECT().WithFields(gomock.Any()).Return(mockLogger).AnyTimes()
+	notifyErr := errors.New("notification buffer is full")
+
+	committed := false
+	mockTransactor.EXPECT().InTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		err := fn(ctx)
+		committed = err == nil
+		return err
+	}).Times(2)
+	mockRepo.EXPECT().GetByIDForUpdate(gomock.Any(), "1").Return(entity.User{ID: "1"}, nil).Times(2)
+	gomock.InOrder(
+		mockRepo.EXPECT().Delete(gomock.Any(), "1").Return(nil),
+		mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, notificator.Notification) error {
+			ao.True(committed)
+			return notifyErr
+		}),
+		// the change is kept, failed push is logged
+		mockLogger.EXPECT().Error(fmt.Errorf("push notification: %w", notifyErr)),
+		// rolled back change isn't published
+		mockRepo.EXPECT().Delete(gomock.Any(), "1").Return(errors.New("db error")),
+	)
+
+	u := NewUser(mockRepo, mockTransactor, NewMockPasswordHistoryRepository(ctrl), NewMockPasswordValidator(ctrl), nil, mockNotificator, mockLogger)
+	ao.NoError(u.Delete(context.Background(), "1"))
+	ao.EqualError(u.Delete(context.Background(), "1"), "repo delete user: db error")
+}
+
+func TestUser_Spans(t *testing.T) {
+	ao := assert.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockUserRepository(ctrl)
+	mockNotificator := notificator.NewMockNotificator(ctrl)
+	mockRepo.EXPECT().GetByIDForUpdate(gomock.Any(), gomock.Any()).Return(entity.User{}, nil).Times(2)
+	mockRepo.EXPECT().Delete(gomock.Any(), "1").DoAndReturn(func(ctx context.Context, _ string) error {
+		ao.True(trace.SpanContextFromContext(ctx).IsValid())
+		return nil
+	})
+	mockNotificator.EXPECT().Push(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Delete(gomock.Any(), "2").Return(errors.New("repo error"))
+
+	u := NewUser(mockRepo, noTransaction{}, NewMockPasswordHistoryRepository(ctrl), NewMockPasswordValidator(ctrl), nil, mockNotificator, logger.NewMockLogger(ctrl))
+	ao.NoError(u.Delete(context.Background(), "1"))
+	ao.Error(u.Delete(context.Background(), "2"))
+
+	spans := recorder.Ended()
+	ao.Len(spans, 2)
+	ao.Equal("User.Delete", spans[0].Name())
+	ao.Equal(codes.Unset, spans[0].Status().Code)
+	ao.Equal("User.Delete", spans[1].Name())
+	ao.Equal(codes.Error, spans[1].Status().Code)
+}
+
+// noTransaction runs functions without transaction.
+type noTransaction struct{}
+
+func (noTransaction) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// passwordHashOf matches password history hash of the password.
+type passwordHashOf string
+
+func (p passwordHashOf) Matches(x interface{}) bool {
+	hash, ok := x.(string)
+	return ok && password.MatchHash(hash, string(p))
+}
+
+func (p passwordHashOf) String() string {
+	return "is password hash of " + string(p)
+}
+
+// outbox is transactional notificator.
+type outbox struct {
+	*notificator.MockNotificator
+}
+
+func (outbox) Transactional() {}